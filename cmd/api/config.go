@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"go-sis-be/internal/configs"
+)
+
+// runConfig menjalankan subcommand "config print": menampilkan konfigurasi efektif dalam format .env
+// (nilai rahasia disamarkan) beserta asal setiap nilai, lalu hasil validasinya.
+func runConfig(cfg *configs.Config, cfgErr error, args []string) {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Println("Pemakaian: api [-config file] [-env profil] [-set KEY=VALUE] config print")
+		os.Exit(2)
+	}
+
+	fmt.Printf("# Profil: %s\n", cfg.Env)
+	for _, s := range cfg.Settings() {
+		fmt.Printf("%s=%s  # %s\n", s.Key, s.Value, s.Origin)
+	}
+
+	if cfgErr != nil {
+		fmt.Println()
+		fmt.Println("# Konfigurasi TIDAK VALID:")
+		for _, line := range strings.Split(cfgErr.Error(), "\n") {
+			fmt.Println("#   " + line)
+		}
+		os.Exit(1)
+	}
+	fmt.Println("\n# Konfigurasi valid.")
+}
+
+// warnConfig mencatat masalah konfigurasi tanpa menghentikan perintah yang hanya butuh database
+func warnConfig(err error) {
+	if err != nil {
+		log.Printf("Peringatan, konfigurasi tidak lengkap/valid (diabaikan untuk perintah ini):\n%v", err)
+	}
+}
@@ -9,42 +9,75 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"go-sis-be/internal/configs"
+	"go-sis-be/internal/handlers"
+	"go-sis-be/internal/migrations"
+	"go-sis-be/internal/utils"
 	"go-sis-be/routes"
 )
 
 func main() {
-	configs.ConnectDB()
-	configs.SeedDatabase()
-	configs.InitRedis()
-	r := routes.InitRouter()
+	opts, args, err := configs.ParseFlags(os.Args[1:])
+	if err != nil {
+		os.Exit(2)
+	}
+	cfg, cfgErr := configs.Load(opts)
 
-	host := "localhost"
-	if envHost := os.Getenv("HOST"); envHost != "" {
-		host = envHost
+	if len(args) > 0 {
+		switch args[0] {
+		case "config":
+			runConfig(cfg, cfgErr, args[1:])
+			return
+		case "migrate":
+			warnConfig(cfgErr)
+			runMigrate(cfg, args[1:])
+			return
+		case "seed":
+			runSeed(cfg, cfgErr, args[1:])
+			return
+		default:
+			log.Fatalf("Perintah %q tidak dikenal (config, migrate, seed)", args[0])
+		}
+	}
+	if cfgErr != nil {
+		log.Fatalf("Konfigurasi tidak valid:\n%v", cfgErr)
 	}
+	log.Printf("Profil konfigurasi: %s", cfg.Env)
 
-	port := ":8080"
-	if envPort := os.Getenv("PORT"); envPort != "" {
-		port = envPort
+	configs.ConnectDB(cfg.Database)
+	if err := utils.InitJWTKeys(cfg.JWT); err != nil {
+		log.Fatalf("Gagal memuat kunci JWT: %v", err)
+	}
+	log.Printf("Kunci JWT aktif: kid=%s", utils.ActiveKeyID())
+	if cfg.Database.MigrateOnStart {
+		n, err := migrations.Up(context.Background(), configs.DB)
+		if err != nil {
+			log.Fatalf("Migrasi database gagal: %v", err)
+		}
+		if n > 0 {
+			log.Printf("%d migrasi database diterapkan", n)
+		}
 	}
+	configs.InitRedis(cfg.Redis)
+	h := handlers.New(handlers.PostgresDeps(cfg), handlers.NewConfig(cfg))
+	r := routes.InitRouter(h, cfg)
 
 	srv := &http.Server{
-		Addr:         ":" + port,
+		Addr:         cfg.Server.Addr(),
 		Handler:      r,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
 	go func() {
 		fmt.Print("\033[H\033[2J")
 		fmt.Println("=================================================")
 		fmt.Println("STATUS   : SERVICE RUNNING")
-		fmt.Printf("PORT     : %s\n", port)
-		fmt.Printf("HOST     : %s\n", host)
+		fmt.Printf("PORT     : %d\n", cfg.Server.Port)
+		fmt.Printf("HOST     : %s\n", cfg.Server.Host)
+		fmt.Printf("ENV      : %s\n", cfg.Env)
 		fmt.Println("=================================================")
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start server: %v", err)
@@ -57,7 +90,7 @@ func main() {
 
 	fmt.Println("\nServer is shutdown...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"go-sis-be/internal/configs"
+	"go-sis-be/internal/migrations"
+)
+
+const migrateUsage = `Pemakaian:
+  api migrate up             menerapkan semua migrasi yang tertunda
+  api migrate down [N]       membatalkan N migrasi terakhir (default 1)
+  api migrate status         menampilkan status setiap migrasi
+  api migrate create <nama>  membuat pasangan file up/down baru di ` + migrations.SourceDir
+
+// runMigrate menjalankan subcommand "migrate" lalu keluar tanpa menyalakan server
+func runMigrate(cfg *configs.Config, args []string) {
+	if len(args) == 0 {
+		fmt.Println(migrateUsage)
+		os.Exit(2)
+	}
+
+	// create hanya menulis file, tidak butuh database
+	if args[0] == "create" {
+		if len(args) < 2 {
+			fmt.Println(migrateUsage)
+			os.Exit(2)
+		}
+		up, down, err := migrations.Create(migrations.SourceDir, args[1])
+		if err != nil {
+			log.Fatalf("Gagal membuat file migrasi: %v", err)
+		}
+		fmt.Printf("Dibuat:\n  %s\n  %s\n", up, down)
+		return
+	}
+
+	configs.ConnectDB(cfg.Database)
+	defer configs.CloseDB()
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		n, err := migrations.Up(ctx, configs.DB)
+		if err != nil {
+			log.Fatalf("Migrasi gagal: %v", err)
+		}
+		fmt.Printf("%d migrasi diterapkan.\n", n)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			v, err := strconv.Atoi(args[1])
+			if err != nil || v <= 0 {
+				log.Fatalf("Jumlah langkah tidak valid: %s", args[1])
+			}
+			steps = v
+		}
+		n, err := migrations.Down(ctx, configs.DB, steps)
+		if err != nil {
+			log.Fatalf("Rollback gagal: %v", err)
+		}
+		fmt.Printf("%d migrasi dibatalkan.\n", n)
+
+	case "status":
+		list, err := migrations.List(ctx, configs.DB)
+		if err != nil {
+			log.Fatalf("Gagal membaca status migrasi: %v", err)
+		}
+		for _, m := range list {
+			state := "pending"
+			if m.AppliedAt != nil {
+				state = "applied " + m.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			name := m.Name
+			if m.Missing {
+				name = "(tidak ada di binary ini)"
+			}
+			fmt.Printf("%04d  %-30s %s\n", m.Version, name, state)
+		}
+
+	default:
+		fmt.Println(migrateUsage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"go-sis-be/internal/configs"
+	"go-sis-be/internal/migrations"
+	"go-sis-be/internal/seeder"
+)
+
+// runSeed menjalankan subcommand "seed": membuat admin awal (jika belum ada user) lalu
+// membangkitkan guru, murid, wali dan kelas palsu.
+// Hanya berjalan jika konfigurasi valid dan profil eksplisit development/staging, supaya salah
+// ketik APP_ENV (yang jatuh ke default development) tidak berujung seeding database production.
+func runSeed(cfg *configs.Config, cfgErr error, args []string) {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	students := fs.Int("students", 300, "jumlah murid")
+	teachers := fs.Int("teachers", 30, "jumlah guru (guru pertama menjadi kepala sekolah)")
+	classes := fs.Int("classes", 3, "jumlah rombel per tingkat (7, 8, 9)")
+	seed := fs.Uint64("seed", 42, "seed random; seed dan tahun yang sama menghasilkan data yang sama")
+	year := fs.Int("year", academicYear(time.Now()), "tahun awal tahun ajaran")
+	password := fs.String("password", "", "password untuk semua akun hasil seed (wajib, harus lolos kebijakan password)")
+	dryRun := fs.Bool("dry-run", false, "hanya tampilkan ringkasan data tanpa menulis ke database")
+	fs.Parse(args)
+
+	ds := seeder.Generate(seeder.Options{
+		Seed:            *seed,
+		Students:        *students,
+		Teachers:        *teachers,
+		ClassesPerGrade: *classes,
+		Year:            *year,
+	})
+	if *dryRun {
+		nStudents := 0
+		for _, f := range ds.Families {
+			nStudents += len(f.Students)
+		}
+		fmt.Printf("Tahun ajaran %s: %d kelas, %d guru, %d keluarga, %d murid\n",
+			ds.AcademicYear, len(ds.Classes), len(ds.Teachers), len(ds.Families), nStudents)
+		return
+	}
+
+	if cfgErr != nil {
+		log.Fatalf("Konfigurasi tidak valid, seed dibatalkan:\n%v", cfgErr)
+	}
+	if !cfg.ExplicitEnv() {
+		log.Fatal("Perintah seed butuh profil eksplisit: set APP_ENV=development/staging atau gunakan -env")
+	}
+	if cfg.IsProduction() {
+		log.Fatal("Perintah seed tidak boleh dijalankan di profil production")
+	}
+	if *password == "" {
+		log.Fatal("Flag -password wajib diisi")
+	}
+	if err := cfg.PasswordPolicy.Validate(*password, ""); err != nil {
+		log.Fatalf("Password seed ditolak: %v", err)
+	}
+	configs.ConnectDB(cfg.Database)
+	defer configs.CloseDB()
+
+	ctx := context.Background()
+	if _, err := migrations.Up(ctx, configs.DB); err != nil {
+		log.Fatalf("Migrasi database gagal: %v", err)
+	}
+	configs.SeedDatabase(cfg.PasswordHash, cfg.PasswordPolicy.HistorySize, *password)
+
+	hash, err := cfg.PasswordHash.Hash(*password)
+	if err != nil {
+		log.Fatalf("Gagal hash password: %v", err)
+	}
+	sum, err := seeder.Insert(ctx, configs.DB, ds, hash)
+	if errors.Is(err, seeder.ErrAlreadySeeded) {
+		log.Fatal("Database sudah berisi data guru/murid/wali. Gunakan database kosong untuk seeding.")
+	}
+	if err != nil {
+		log.Fatalf("Seeding gagal: %v", err)
+	}
+
+	fmt.Printf("Seeder selesai (seed=%d, tahun ajaran %s): %d kelas, %d guru, %d murid, %d wali. Password semua akun: %s\n",
+		*seed, ds.AcademicYear, sum.Classes, sum.Teachers, sum.Students, sum.Parents, *password)
+}
+
+// academicYear: Tahun ajaran dimulai bulan Juli
+func academicYear(now time.Time) int {
+	if now.Month() < time.July {
+		return now.Year() - 1
+	}
+	return now.Year()
+}
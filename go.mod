@@ -9,10 +9,13 @@ require (
 	golang.org/x/crypto v0.45.0
 )
 
-require github.com/lib/pq v1.10.9
+require (
+	github.com/lib/pq v1.10.9
+	github.com/redis/go-redis/v9 v9.17.2
+)
 
 require (
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
-	github.com/redis/go-redis/v9 v9.17.2 // indirect
+	golang.org/x/sys v0.38.0 // indirect
 )
@@ -0,0 +1,167 @@
+// Package audit mencatat setiap aksi yang mengubah data ke tabel audit_logs.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"reflect"
+
+	"go-sis-be/internal/models"
+	"go-sis-be/internal/utils"
+	"go-sis-be/middleware"
+)
+
+// Nama aksi yang dicatat. Dipakai juga sebagai filter ?action= di GET /audit.
+const (
+	ActionUserCreate          = "user.create"
+	ActionUserDelete          = "user.delete"
+	ActionUserRoleChange      = "user.role_change"
+	ActionProfileUpdate       = "profile.update"
+	ActionProfileDelete       = "profile.delete"
+	ActionStudentRegister     = "student.register"
+	ActionTeacherRegister     = "teacher.register"
+	ActionAdminRegister       = "admin.register"
+	ActionParentRegister      = "parent.register"
+	ActionPasswordChange      = "password.change"
+	ActionPasswordReset       = "password.reset"
+	ActionPasswordResetByMail = "password.reset_forgotten"
+	ActionLoginUnlock         = "login.unlock"
+	ActionSessionRevoke       = "session.revoke"
+	ActionSessionRevokeAll    = "session.revoke_all"
+	ActionTwoFactorEnable     = "two_factor.enable"
+
+	ActionServiceAccountCreate = "service_account.create"
+	ActionServiceAccountUpdate = "service_account.update"
+	ActionServiceAccountDelete = "service_account.delete"
+	ActionAPIKeyCreate         = "api_key.create"
+	ActionAPIKeyRevoke         = "api_key.revoke"
+
+	ActionOIDCLink   = "oidc.link"
+	ActionOIDCUnlink = "oidc.unlink"
+
+	ActionImpersonationStart = "impersonation.start"
+	ActionImpersonationEnd   = "impersonation.end"
+)
+
+// Recorder menyimpan catatan audit ke store (Postgres di produksi, fake in-memory di test)
+type Recorder struct {
+	store models.AuditStore
+}
+
+// NewRecorder membuat Recorder yang menulis ke store
+func NewRecorder(store models.AuditStore) *Recorder {
+	return &Recorder{store: store}
+}
+
+// Record menyimpan satu catatan audit untuk request r.
+// Actor diambil dari JWT di context (kosong untuk endpoint publik), before/after boleh nil.
+// Aksi lewat token impersonation ditandai dengan uid admin aslinya.
+// Kegagalan menyimpan hanya di-log supaya aksi utama yang sudah sukses tidak ikut gagal.
+func (rec *Recorder) Record(r *http.Request, action, targetUID string, before, after interface{}) {
+	entry := newEntry(r, action, targetUID)
+	if claims, ok := middleware.ClaimsFromContext(r.Context()); ok {
+		entry.ActorUID, entry.ActorRole = claims.UID, claims.Role
+		if claims.Impersonated() {
+			entry.ImpersonatorUID = claims.Actor.UID
+		}
+	}
+	rec.save(r.Context(), entry, before, after)
+}
+
+// RecordAs sama seperti Record, tetapi actor ditentukan pemanggil. Dipakai di endpoint publik
+// yang actor-nya baru diketahui dari data lain (mis. callback OIDC).
+func (rec *Recorder) RecordAs(r *http.Request, actorUID, actorRole, action, targetUID string, before, after interface{}) {
+	entry := newEntry(r, action, targetUID)
+	entry.ActorUID, entry.ActorRole = actorUID, actorRole
+	rec.save(r.Context(), entry, before, after)
+}
+
+func newEntry(r *http.Request, action, targetUID string) *models.AuditLog {
+	return &models.AuditLog{
+		Action:    action,
+		TargetUID: targetUID,
+		IPAddress: utils.ClientIP(r),
+		UserAgent: r.UserAgent(),
+		RequestID: middleware.RequestIDFromContext(r.Context()),
+	}
+}
+
+func (rec *Recorder) save(ctx context.Context, entry *models.AuditLog, before, after interface{}) {
+	action := entry.Action
+	var err error
+	if entry.Before, err = marshal(before); err != nil {
+		log.Printf("AUDIT: gagal encode before %s: %v", action, err)
+	}
+	if entry.After, err = marshal(after); err != nil {
+		log.Printf("AUDIT: gagal encode after %s: %v", action, err)
+	}
+	if before != nil && after != nil {
+		if entry.Changes, err = Diff(before, after); err != nil {
+			log.Printf("AUDIT: gagal menghitung diff %s: %v", action, err)
+		}
+	}
+
+	// Aksi utama sudah terjadi; catatan audit tetap ditulis walau client memutus koneksi
+	if err := rec.store.InsertAuditLog(context.WithoutCancel(ctx), entry); err != nil {
+		log.Printf("AUDIT: %v (action=%s target=%s req=%s)", err, action, entry.TargetUID, entry.RequestID)
+	}
+}
+
+// FieldChange: Nilai satu field sebelum dan sesudah perubahan
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Diff membandingkan representasi JSON before dan after per field level atas.
+// Hanya field yang berubah yang dikembalikan; nil jika tidak ada perubahan.
+func Diff(before, after interface{}) (json.RawMessage, error) {
+	b, err := toMap(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := toMap(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]FieldChange{}
+	for k, bv := range b {
+		av, ok := a[k]
+		if !ok || !reflect.DeepEqual(bv, av) {
+			changes[k] = FieldChange{Before: bv, After: av}
+		}
+	}
+	for k, av := range a {
+		if _, ok := b[k]; !ok {
+			changes[k] = FieldChange{Before: nil, After: av}
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(changes)
+}
+
+func marshal(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// toMap mengubah nilai apa pun menjadi map lewat JSON, sehingga tag json ikut dihormati
+func toMap(v interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type profile struct {
+	Name     string  `json:"name"`
+	Email    *string `json:"email"`
+	Password string  `json:"-"`
+}
+
+func TestDiffOnlyChangedFields(t *testing.T) {
+	email := "a@sekolah.id"
+	before := profile{Name: "Budi", Password: "rahasia"}
+	after := profile{Name: "Budi", Email: &email, Password: "lain"}
+
+	raw, err := Diff(before, after)
+	if err != nil {
+		t.Fatalf("Diff error: %v", err)
+	}
+
+	var got map[string]FieldChange
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("hasil diff bukan JSON valid: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("harusnya hanya 1 field berubah, dapat %d: %s", len(got), raw)
+	}
+	change, ok := got["email"]
+	if !ok || change.Before != nil || change.After != email {
+		t.Fatalf("diff email salah: %s", raw)
+	}
+}
+
+func TestDiffNoChanges(t *testing.T) {
+	raw, err := Diff(map[string]int{"a": 1}, map[string]int{"a": 1})
+	if err != nil {
+		t.Fatalf("Diff error: %v", err)
+	}
+	if raw != nil {
+		t.Fatalf("harusnya nil jika tidak ada perubahan, dapat %s", raw)
+	}
+}
+
+func TestDiffRemovedField(t *testing.T) {
+	raw, err := Diff(map[string]string{"a": "x", "b": "y"}, map[string]string{"a": "x"})
+	if err != nil {
+		t.Fatalf("Diff error: %v", err)
+	}
+	var got map[string]FieldChange
+	json.Unmarshal(raw, &got)
+	if c, ok := got["b"]; !ok || c.Before != "y" || c.After != nil {
+		t.Fatalf("field yang dihapus harus tercatat: %s", raw)
+	}
+}
@@ -0,0 +1,471 @@
+package configs
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-sis-be/internal/mailer"
+	"go-sis-be/internal/utils"
+
+	"github.com/joho/godotenv"
+)
+
+// Profil environment. Setiap profil punya default sendiri (lihat profileDefaults).
+const (
+	EnvDevelopment = "development"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
+)
+
+// Config: Seluruh konfigurasi aplikasi, dimuat sekali oleh Load lalu diteruskan ke setiap subsistem
+type Config struct {
+	Env string
+	// envExplicit: Profil dipilih lewat -env / APP_ENV, bukan jatuh ke default development
+	envExplicit bool
+
+	Server   ServerConfig
+	Database DatabaseConfig
+	Redis    RedisConfig
+	JWT      utils.JWTKeyConfig
+	Mail     mailer.Config
+
+	PasswordResetURL string // Prefix link di email reset password, token ditempel di belakangnya
+	TrustProxy       bool   // Percaya X-Forwarded-For / X-Real-IP (aplikasi di belakang reverse proxy)
+
+	LoginLockout   LoginLockoutConfig
+	TwoFactor      TwoFactorConfig
+	PasswordPolicy utils.PasswordPolicy
+	PasswordHash   utils.PasswordHashConfig
+	TokenBlacklist TokenBlacklistConfig
+	Cookie         CookieConfig
+	CORS           CORSConfig
+	RateLimit      RateLimitConfig
+	OIDC           []OIDCProviderConfig
+
+	settings []Setting
+}
+
+// ServerConfig: Alamat dan timeout HTTP server
+type ServerConfig struct {
+	Host            string // Hanya untuk tampilan/log; server listen di semua interface
+	Port            int
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+}
+
+// Addr: Alamat listen untuk http.Server
+func (s ServerConfig) Addr() string {
+	return ":" + strconv.Itoa(s.Port)
+}
+
+// IsProduction: Profil production aktif
+func (c *Config) IsProduction() bool {
+	return c.Env == EnvProduction
+}
+
+// ExplicitEnv: Profil disebut eksplisit (flag -env, APP_ENV di environment atau file) dan dikenali
+func (c *Config) ExplicitEnv() bool {
+	return c.envExplicit
+}
+
+// Setting: Satu kunci konfigurasi yang sudah di-resolve beserta asal nilainya
+type Setting struct {
+	Key    string
+	Value  string
+	Origin string // default, profile:<nama>, file:<path>, env, flag
+	Secret bool
+}
+
+// Settings mengembalikan semua kunci yang dibaca Load, terurut, dengan nilai rahasia disamarkan
+func (c *Config) Settings() []Setting {
+	out := make([]Setting, len(c.settings))
+	for i, s := range c.settings {
+		if s.Secret && s.Value != "" {
+			s.Value = "******"
+		}
+		out[i] = s
+	}
+	return out
+}
+
+// LoadOptions: Sumber tambahan di atas environment variable
+type LoadOptions struct {
+	File      string            // File .env; kosong = ".env" jika ada
+	Env       string            // Paksa profil (mengalahkan APP_ENV)
+	Overrides map[string]string // Nilai dari flag, prioritas tertinggi
+}
+
+// profileDefaults: Default per profil, menimpa default kode tetapi kalah dari file, env dan flag
+var profileDefaults = map[string]map[string]string{
+	EnvDevelopment: {},
+	EnvStaging: {
+		"COOKIE_SECURE": "true",
+		"DB_SSLMODE":    "prefer",
+	},
+	EnvProduction: {
+		"COOKIE_SECURE":   "true",
+		"COOKIE_SAMESITE": "strict",
+		"DB_SSLMODE":      "require",
+		"MAIL_DRIVER":     "smtp",
+	},
+}
+
+// Load membaca konfigurasi dengan prioritas (rendah ke tinggi): default kode, default profil,
+// file .env, file .env.<profil>, environment variable, lalu flag. Config selalu dikembalikan
+// (berguna untuk "config print"); error berisi semua nilai yang tidak valid atau wajib tapi kosong.
+func Load(opts LoadOptions) (*Config, error) {
+	src := &source{used: map[string]Setting{}}
+
+	file := opts.File
+	explicitFile := file != ""
+	if !explicitFile {
+		file = ".env"
+	}
+	fileValues, err := readEnvFile(file, explicitFile)
+	if err != nil {
+		return Default(), err
+	}
+
+	// Profil ditentukan lebih dulu karena menentukan file profil dan default profil
+	env := opts.Env
+	for _, candidate := range []string{opts.Overrides["APP_ENV"], os.Getenv("APP_ENV"), fileValues["APP_ENV"]} {
+		if env == "" {
+			env = candidate
+		}
+	}
+	profile, ok := normalizeEnv(env)
+	if !ok {
+		src.fail("APP_ENV", "profil %q tidak dikenal (development, staging, production)", env)
+		profile = EnvDevelopment
+	}
+
+	profileFile := file + "." + profile
+	profileValues, err := readEnvFile(profileFile, false)
+	if err != nil {
+		return Default(), err
+	}
+
+	src.layers = []layer{
+		{"profile:" + profile, mapLookup(profileDefaults[profile])},
+		{"file:" + filepath.Clean(file), mapLookup(fileValues)},
+		{"file:" + filepath.Clean(profileFile), mapLookup(profileValues)},
+		{"env", os.LookupEnv},
+		{"flag", mapLookup(opts.Overrides)},
+	}
+	src.used["APP_ENV"] = Setting{Key: "APP_ENV", Value: profile, Origin: "resolved"}
+
+	cfg := build(src, profile)
+	cfg.envExplicit = ok && strings.TrimSpace(env) != ""
+	cfg.validate(src)
+	sort.Slice(src.errs, func(i, j int) bool { return src.errs[i].Error() < src.errs[j].Error() })
+	return cfg, errors.Join(src.errs...)
+}
+
+// Default: Konfigurasi default development tanpa membaca env, untuk nilai awal variabel paket dan test
+func Default() *Config {
+	return build(&source{used: map[string]Setting{}}, EnvDevelopment)
+}
+
+func build(s *source, profile string) *Config {
+	cfg := &Config{
+		Env: profile,
+		Server: ServerConfig{
+			Host:            s.String("HOST", "localhost"),
+			Port:            s.Port("PORT", 8080),
+			ReadTimeout:     s.Duration("SERVER_READ_TIMEOUT_SECONDS", 15, time.Second),
+			WriteTimeout:    s.Duration("SERVER_WRITE_TIMEOUT_SECONDS", 15, time.Second),
+			IdleTimeout:     s.Duration("SERVER_IDLE_TIMEOUT_SECONDS", 60, time.Second),
+			ShutdownTimeout: s.Duration("SERVER_SHUTDOWN_TIMEOUT_SECONDS", 15, time.Second),
+		},
+		Database:         loadDatabaseConfig(s),
+		Redis:            loadRedisConfig(s),
+		JWT:              loadJWTConfig(s),
+		Mail:             loadMailConfig(s),
+		PasswordResetURL: s.String("PASSWORD_RESET_URL", ""),
+		TrustProxy:       s.Bool("TRUST_PROXY", false),
+		LoginLockout:     loadLoginLockoutConfig(s),
+		TwoFactor:        loadTwoFactorConfig(s),
+		PasswordPolicy:   loadPasswordPolicy(s),
+		PasswordHash:     loadPasswordHashConfig(s),
+		TokenBlacklist:   loadTokenBlacklistConfig(s),
+		Cookie:           loadCookieConfig(s),
+		CORS:             loadCORSConfig(s),
+		RateLimit:        loadRateLimitConfig(s),
+		OIDC:             loadOIDCProviders(s),
+	}
+
+	for _, st := range s.used {
+		cfg.settings = append(cfg.settings, st)
+	}
+	sort.Slice(cfg.settings, func(i, j int) bool { return cfg.settings[i].Key < cfg.settings[j].Key })
+	return cfg
+}
+
+func loadJWTConfig(s *source) utils.JWTKeyConfig {
+	return utils.JWTKeyConfig{
+		PrivateKeyFile: s.String("JWT_PRIVATE_KEY_FILE", ""),
+		KeyID:          s.String("JWT_KEY_ID", ""),
+		PublicKeysDir:  s.String("JWT_PUBLIC_KEYS_DIR", ""),
+		RefreshSecret:  s.String("JWT_REFRESH_SECRET", ""),
+		Issuer:         s.String("JWT_ISSUER", utils.DefaultJWTIssuer),
+		Audience:       s.String("JWT_AUDIENCE", utils.DefaultJWTAudience),
+	}
+}
+
+func loadMailConfig(s *source) mailer.Config {
+	return mailer.Config{
+		Driver:       s.Enum("MAIL_DRIVER", mailer.DriverLog, mailer.DriverLog, mailer.DriverFile, mailer.DriverSMTP),
+		SMTPHost:     s.String("SMTP_HOST", ""),
+		SMTPPort:     s.String("SMTP_PORT", "587"),
+		SMTPUsername: s.String("SMTP_USERNAME", ""),
+		SMTPPassword: s.String("SMTP_PASSWORD", ""),
+		From:         s.String("MAIL_FROM", ""),
+		FileDir:      s.String("MAIL_FILE_DIR", "mail_outbox"),
+	}
+}
+
+// validate mencatat field wajib yang kosong dan kombinasi nilai yang tidak masuk akal
+func (c *Config) validate(s *source) {
+	required := []struct{ key, val string }{
+		{"DB_HOST", c.Database.Host},
+		{"DB_USER", c.Database.User},
+		{"DB_NAME", c.Database.Name},
+		{"REDIS_ADDR", c.Redis.Addr},
+		{"JWT_PRIVATE_KEY_FILE", c.JWT.PrivateKeyFile},
+		{"JWT_REFRESH_SECRET", c.JWT.RefreshSecret},
+	}
+	for _, r := range required {
+		if r.val == "" {
+			s.fail(r.key, "wajib diisi")
+		}
+	}
+
+	if c.Mail.Driver == mailer.DriverSMTP && (c.Mail.SMTPHost == "" || c.Mail.From == "") {
+		s.fail("MAIL_DRIVER", "smtp membutuhkan SMTP_HOST dan MAIL_FROM")
+	}
+	for _, o := range c.CORS.AllowedOrigins {
+		// Browser menolak Allow-Origin "*" untuk request dengan cookie
+		if o == "*" && c.CORS.AllowCredentials {
+			s.fail("CORS_ALLOWED_ORIGINS", "* tidak bisa dipakai dengan CORS_ALLOW_CREDENTIALS=true; daftarkan origin frontend secara eksplisit")
+		}
+	}
+	// Browser menolak SameSite=None tanpa Secure
+	if c.Cookie.SameSite == http.SameSiteNoneMode && !c.Cookie.Secure {
+		s.fail("COOKIE_SAMESITE", "none membutuhkan COOKIE_SECURE=true")
+	}
+
+	if c.IsProduction() {
+		if !c.Cookie.Secure {
+			s.fail("COOKIE_SECURE", "wajib true di production")
+		}
+		if c.JWT.RefreshSecret != "" && len(c.JWT.RefreshSecret) < 32 {
+			s.fail("JWT_REFRESH_SECRET", "minimal 32 karakter di production")
+		}
+	}
+}
+
+// ParseFlags membaca flag global sebelum subcommand:
+//
+//	-config <file>  file .env (default .env bila ada)
+//	-env <profil>   development, staging atau production
+//	-host, -port    menimpa HOST dan PORT
+//	-set KEY=VALUE  menimpa kunci konfigurasi apa pun (boleh berulang)
+//
+// Mengembalikan sisa argumen (subcommand dan argumennya).
+func ParseFlags(args []string) (LoadOptions, []string, error) {
+	opts := LoadOptions{Overrides: map[string]string{}}
+	fs := flag.NewFlagSet("api", flag.ContinueOnError)
+	fs.StringVar(&opts.File, "config", "", "file konfigurasi format .env (default .env bila ada)")
+	fs.StringVar(&opts.Env, "env", "", "profil: development, staging, production (default APP_ENV)")
+	host := fs.String("host", "", "menimpa HOST")
+	port := fs.String("port", "", "menimpa PORT")
+	fs.Func("set", "menimpa kunci konfigurasi, format KEY=VALUE (boleh berulang)", func(v string) error {
+		key, val, ok := strings.Cut(v, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return fmt.Errorf("format harus KEY=VALUE")
+		}
+		opts.Overrides[strings.ToUpper(strings.TrimSpace(key))] = val
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return opts, nil, err
+	}
+	if *host != "" {
+		opts.Overrides["HOST"] = *host
+	}
+	if *port != "" {
+		opts.Overrides["PORT"] = *port
+	}
+	return opts, fs.Args(), nil
+}
+
+func normalizeEnv(env string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(env)) {
+	case "", "dev", "development", "local":
+		return EnvDevelopment, true
+	case "stage", "staging":
+		return EnvStaging, true
+	case "prod", "production":
+		return EnvProduction, true
+	}
+	return "", false
+}
+
+// readEnvFile membaca file format .env. File yang tidak ada hanya error bila diminta eksplisit.
+func readEnvFile(path string, required bool) (map[string]string, error) {
+	values, err := godotenv.Read(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !required {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("gagal membaca file konfigurasi %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// isSecretKey: Nilai kunci ini tidak boleh tampil di "config print" atau log
+func isSecretKey(key string) bool {
+	return strings.HasSuffix(key, "PASSWORD") || strings.HasSuffix(key, "SECRET")
+}
+
+// layer: Satu sumber nilai konfigurasi
+type layer struct {
+	origin string
+	lookup func(key string) (string, bool)
+}
+
+func mapLookup(m map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := m[key]
+		return v, ok
+	}
+}
+
+// source: Pembaca kunci konfigurasi dari beberapa layer. Mencatat setiap kunci yang dibaca
+// (untuk "config print") dan setiap nilai yang tidak valid (dikembalikan Load sebagai error).
+type source struct {
+	layers []layer
+	used   map[string]Setting
+	errs   []error
+}
+
+// raw mengembalikan nilai dari layer prioritas tertinggi yang mengisi key (nilai kosong diabaikan)
+func (s *source) raw(key string) (string, string, bool) {
+	for i := len(s.layers) - 1; i >= 0; i-- {
+		if v, ok := s.layers[i].lookup(key); ok && strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v), s.layers[i].origin, true
+		}
+	}
+	return "", "default", false
+}
+
+func (s *source) record(key, value, origin string) {
+	s.used[key] = Setting{Key: key, Value: value, Origin: origin, Secret: isSecretKey(key)}
+}
+
+func (s *source) fail(key, format string, args ...any) {
+	s.errs = append(s.errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+}
+
+// String membaca nilai teks, atau def jika tidak diisi
+func (s *source) String(key, def string) string {
+	v, origin, ok := s.raw(key)
+	if !ok {
+		v = def
+	}
+	s.record(key, v, origin)
+	return v
+}
+
+// Bool membaca nilai boolean (true/false/1/0)
+func (s *source) Bool(key string, def bool) bool {
+	v, origin, ok := s.raw(key)
+	if !ok {
+		s.record(key, strconv.FormatBool(def), origin)
+		return def
+	}
+	val, err := strconv.ParseBool(v)
+	if err != nil {
+		s.fail(key, "%q bukan boolean", v)
+		val = def
+	}
+	s.record(key, strconv.FormatBool(val), origin)
+	return val
+}
+
+// Int membaca bilangan bulat >= min
+func (s *source) Int(key string, def, min int) int {
+	v, origin, ok := s.raw(key)
+	if !ok {
+		s.record(key, strconv.Itoa(def), origin)
+		return def
+	}
+	val, err := strconv.Atoi(v)
+	if err != nil || val < min {
+		s.fail(key, "%q harus bilangan bulat >= %d", v, min)
+		val = def
+	}
+	s.record(key, strconv.Itoa(val), origin)
+	return val
+}
+
+// Duration membaca bilangan bulat positif dalam satuan unit (mis. *_SECONDS, *_MINUTES)
+func (s *source) Duration(key string, def int, unit time.Duration) time.Duration {
+	return time.Duration(s.Int(key, def, 1)) * unit
+}
+
+// Port membaca nomor port 1-65535. Awalan ":" (mis. PORT=:8080) diterima.
+func (s *source) Port(key string, def int) int {
+	v, origin, ok := s.raw(key)
+	if !ok {
+		s.record(key, strconv.Itoa(def), origin)
+		return def
+	}
+	val, err := strconv.Atoi(strings.TrimPrefix(v, ":"))
+	if err != nil || val < 1 || val > 65535 {
+		s.fail(key, "%q bukan nomor port yang valid", v)
+		val = def
+	}
+	s.record(key, strconv.Itoa(val), origin)
+	return val
+}
+
+// List membaca daftar yang dipisah koma, atau def jika tidak diisi
+func (s *source) List(key string, def []string) []string {
+	v, origin, ok := s.raw(key)
+	list := def
+	if ok {
+		list = splitList(v)
+	}
+	s.record(key, strings.Join(list, ","), origin)
+	return list
+}
+
+// Enum membaca salah satu dari allowed (huruf kecil), atau def jika tidak diisi
+func (s *source) Enum(key, def string, allowed ...string) string {
+	v, origin, ok := s.raw(key)
+	if !ok {
+		s.record(key, def, origin)
+		return def
+	}
+	v = strings.ToLower(v)
+	for _, a := range allowed {
+		if v == a {
+			s.record(key, v, origin)
+			return v
+		}
+	}
+	s.fail(key, "%q tidak dikenal (%s)", v, strings.Join(allowed, ", "))
+	s.record(key, def, origin)
+	return def
+}
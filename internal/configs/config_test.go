@@ -0,0 +1,157 @@
+package configs
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeEnvFile menulis file .env sementara dan mengembalikan path-nya
+func writeEnvFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const validBase = `
+DB_HOST=localhost
+DB_USER=sis
+DB_NAME=sis
+REDIS_ADDR=localhost:6379
+JWT_PRIVATE_KEY_FILE=keys/dev.pem
+JWT_REFRESH_SECRET=rahasia-refresh-yang-cukup-panjang-32
+`
+
+func TestLoadPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	file := writeEnvFile(t, dir, ".env", validBase+"PORT=7000\nLOGIN_MAX_ATTEMPTS=3\nHOST=dari-file\n")
+	t.Setenv("APP_ENV", "")
+	t.Setenv("PORT", "7100")
+	t.Setenv("HOST", "")
+
+	cfg, err := Load(LoadOptions{File: file, Overrides: map[string]string{"LOGIN_MAX_ATTEMPTS": "9"}})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.Port != 7100 {
+		t.Errorf("env harus mengalahkan file: port %d", cfg.Server.Port)
+	}
+	if cfg.LoginLockout.MaxUserAttempts != 9 {
+		t.Errorf("flag harus mengalahkan file: %d", cfg.LoginLockout.MaxUserAttempts)
+	}
+	if cfg.Server.Host != "dari-file" {
+		t.Errorf("env kosong tidak boleh menimpa file: %q", cfg.Server.Host)
+	}
+	if cfg.Server.Addr() != ":7100" {
+		t.Errorf("Addr() = %q", cfg.Server.Addr())
+	}
+	if cfg.Env != EnvDevelopment || cfg.Cookie.Secure {
+		t.Errorf("default harus development tanpa cookie Secure: %s %v", cfg.Env, cfg.Cookie.Secure)
+	}
+	if cfg.ExplicitEnv() {
+		t.Error("profil default tidak boleh dianggap eksplisit")
+	}
+	if cfg.Database.QueryTimeout != 5*time.Second || cfg.Redis.Timeout != 500*time.Millisecond {
+		t.Errorf("default timeout salah: db %s redis %s", cfg.Database.QueryTimeout, cfg.Redis.Timeout)
+	}
+
+	// 0 = tanpa batas waktu query
+	cfg, err = Load(LoadOptions{File: file, Overrides: map[string]string{"DB_QUERY_TIMEOUT_MS": "0"}})
+	if err != nil {
+		t.Fatalf("DB_QUERY_TIMEOUT_MS=0 harus diterima: %v", err)
+	}
+	if cfg.Database.QueryTimeout != 0 {
+		t.Errorf("DB_QUERY_TIMEOUT_MS=0 harus menonaktifkan timeout: %s", cfg.Database.QueryTimeout)
+	}
+}
+
+func TestLoadProductionProfile(t *testing.T) {
+	dir := t.TempDir()
+	file := writeEnvFile(t, dir, ".env", validBase)
+	writeEnvFile(t, dir, ".env.production", "SMTP_HOST=smtp.sekolah.sch.id\nMAIL_FROM=sis@sekolah.sch.id\n")
+	t.Setenv("APP_ENV", "prod")
+
+	cfg, err := Load(LoadOptions{File: file})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !cfg.IsProduction() || !cfg.ExplicitEnv() || !cfg.Cookie.Secure || cfg.Cookie.SameSite != http.SameSiteStrictMode {
+		t.Errorf("profil production harus Secure + Strict: %+v", cfg.Cookie)
+	}
+	if cfg.Database.SSLMode != "require" || cfg.Mail.Driver != "smtp" || cfg.Mail.SMTPHost != "smtp.sekolah.sch.id" {
+		t.Errorf("default profil/file profil tidak terpakai: %+v %+v", cfg.Database, cfg.Mail)
+	}
+
+	// Profil masih bisa ditimpa per kunci
+	cfg, err = Load(LoadOptions{File: file, Overrides: map[string]string{"COOKIE_SECURE": "false"}})
+	if err == nil || !strings.Contains(err.Error(), "COOKIE_SECURE") {
+		t.Errorf("COOKIE_SECURE=false di production harus ditolak, err=%v", err)
+	}
+}
+
+func TestLoadValidation(t *testing.T) {
+	dir := t.TempDir()
+	file := writeEnvFile(t, dir, ".env", "PORT=abc\nRATE_LIMIT_LOGIN=10\nCOOKIE_SAMESITE=longgar\nDB_PASSWORD=rahasia\n")
+	t.Setenv("APP_ENV", "")
+
+	cfg, err := Load(LoadOptions{File: file})
+	if err == nil {
+		t.Fatal("konfigurasi tidak lengkap harus error")
+	}
+	for _, want := range []string{"DB_HOST", "REDIS_ADDR", "JWT_REFRESH_SECRET", "PORT", "RATE_LIMIT_LOGIN", "COOKIE_SAMESITE"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error harus menyebut %s:\n%v", want, err)
+		}
+	}
+	// Nilai tidak valid jatuh ke default agar "config print" tetap bisa menampilkan sesuatu
+	if cfg.Server.Port != 8080 || cfg.RateLimit.Routes[RateLimitLogin].Window != time.Minute {
+		t.Errorf("nilai tidak valid harus memakai default: port %d", cfg.Server.Port)
+	}
+
+	if _, err := Load(LoadOptions{File: filepath.Join(dir, "tidak-ada.env")}); err == nil {
+		t.Error("file yang diminta eksplisit tapi tidak ada harus error")
+	}
+}
+
+func TestSettingsRedactSecrets(t *testing.T) {
+	dir := t.TempDir()
+	file := writeEnvFile(t, dir, ".env", validBase+"DB_PASSWORD=sangat-rahasia\n")
+	t.Setenv("APP_ENV", "")
+
+	cfg, _ := Load(LoadOptions{File: file})
+	found := map[string]Setting{}
+	for _, s := range cfg.Settings() {
+		found[s.Key] = s
+		if strings.Contains(s.Value, "rahasia") {
+			t.Errorf("%s bocor di Settings(): %s", s.Key, s.Value)
+		}
+	}
+	if found["DB_PASSWORD"].Value != "******" || !strings.HasPrefix(found["DB_PASSWORD"].Origin, "file:") {
+		t.Errorf("DB_PASSWORD harus disamarkan dan bersumber dari file: %+v", found["DB_PASSWORD"])
+	}
+	if found["DB_HOST"].Value != "localhost" || found["TOKEN_BLACKLIST_FAIL_MODE"].Value != "open" {
+		t.Errorf("nilai bukan rahasia tidak boleh disamarkan: %+v %+v", found["DB_HOST"], found["TOKEN_BLACKLIST_FAIL_MODE"])
+	}
+	if cfg.Database.Password != "sangat-rahasia" {
+		t.Error("Config sendiri tetap memegang nilai asli")
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	opts, rest, err := ParseFlags([]string{"-env", "staging", "-port", "9000", "-set", "db_host=db", "migrate", "up"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Env != "staging" || opts.Overrides["PORT"] != "9000" || opts.Overrides["DB_HOST"] != "db" {
+		t.Errorf("flag salah dibaca: %+v", opts)
+	}
+	if strings.Join(rest, " ") != "migrate up" {
+		t.Errorf("sisa argumen salah: %v", rest)
+	}
+}
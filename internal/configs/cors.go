@@ -0,0 +1,36 @@
+package configs
+
+import (
+	"strings"
+	"time"
+)
+
+// CORSConfig: Origin frontend yang boleh memanggil API dari browser
+type CORSConfig struct {
+	AllowedOrigins   []string // Origin persis (https://sis.sekolah.sch.id) atau wildcard subdomain (https://*.sekolah.sch.id)
+	AllowCredentials bool     // Izinkan cookie (refresh token) ikut dikirim lintas origin
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string // Header respons yang boleh dibaca JavaScript
+	MaxAge           time.Duration
+}
+
+// loadCORSConfig membaca CORS_ALLOWED_ORIGINS, CORS_ALLOW_CREDENTIALS, CORS_EXPOSED_HEADERS
+// dan CORS_MAX_AGE_SECONDS. Tanpa CORS_ALLOWED_ORIGINS tidak ada origin lain yang diizinkan.
+func loadCORSConfig(s *source) CORSConfig {
+	cfg := CORSConfig{
+		AllowedOrigins:   s.List("CORS_ALLOWED_ORIGINS", nil),
+		AllowCredentials: s.Bool("CORS_ALLOW_CREDENTIALS", true),
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", "X-API-Key", "X-CSRF-Token", "X-Request-ID"},
+		ExposedHeaders: s.List("CORS_EXPOSED_HEADERS", []string{
+			"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy",
+			"Retry-After", "X-Request-ID", "X-CSRF-Token",
+		}),
+		MaxAge: s.Duration("CORS_MAX_AGE_SECONDS", 600, time.Second),
+	}
+	for i, o := range cfg.AllowedOrigins {
+		cfg.AllowedOrigins[i] = strings.ToLower(strings.TrimSuffix(o, "/"))
+	}
+	return cfg
+}
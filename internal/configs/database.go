@@ -1,41 +1,65 @@
 package configs
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
-	"os"
 	"time"
 
-	"github.com/joho/godotenv"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 var DB *sql.DB
 
-func ConnectDB() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("file env tidak ditemukan")
-	}
+// DatabaseConfig: Koneksi PostgreSQL dan ukuran pool
+type DatabaseConfig struct {
+	Host            string
+	Port            string
+	User            string
+	Password        string
+	Name            string
+	SSLMode         string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	QueryTimeout    time.Duration // Batas waktu per operasi (query/transaksi) sebelum dibatalkan, 0 = tanpa batas
+	MigrateOnStart  bool          // Jalankan migrasi yang tertunda saat server start
+}
 
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s connect_timeout=3",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_SSLMODE"),
-	)
+// DSN: Connection string lib/pq
+func (c DatabaseConfig) DSN() string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s connect_timeout=3",
+		c.Host, c.Port, c.User, c.Password, c.Name, c.SSLMode)
+}
 
-	DB, err = sql.Open("postgres", connStr)
+func loadDatabaseConfig(s *source) DatabaseConfig {
+	return DatabaseConfig{
+		Host:            s.String("DB_HOST", ""),
+		Port:            s.String("DB_PORT", "5432"),
+		User:            s.String("DB_USER", ""),
+		Password:        s.String("DB_PASSWORD", ""),
+		Name:            s.String("DB_NAME", ""),
+		SSLMode:         s.Enum("DB_SSLMODE", "disable", "disable", "allow", "prefer", "require", "verify-ca", "verify-full"),
+		MaxOpenConns:    s.Int("DB_MAX_OPEN_CONNS", 25, 1),
+		MaxIdleConns:    s.Int("DB_MAX_IDLE_CONNS", 25, 0),
+		ConnMaxLifetime: s.Duration("DB_CONN_MAX_LIFETIME_MINUTES", 5, time.Minute),
+		QueryTimeout:    time.Duration(s.Int("DB_QUERY_TIMEOUT_MS", 5000, 0)) * time.Millisecond,
+		MigrateOnStart:  s.Bool("MIGRATE_ON_START", true),
+	}
+}
+
+func ConnectDB(cfg DatabaseConfig) {
+	var err error
+	DB, err = sql.Open("postgres", cfg.DSN())
 	if err != nil {
 		log.Fatal("Gagal tersambung ke database: \n", err)
 	}
 
-	DB.SetMaxOpenConns(25)
-	DB.SetMaxIdleConns(25)
-	DB.SetConnMaxLifetime(5 * time.Minute)
+	DB.SetMaxOpenConns(cfg.MaxOpenConns)
+	DB.SetMaxIdleConns(cfg.MaxIdleConns)
+	DB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
 	err = DB.Ping()
 	if err != nil {
@@ -43,6 +67,40 @@ func ConnectDB() {
 	}
 }
 
+// WithQueryTimeout menurunkan context request dengan batas waktu timeout (DB_QUERY_TIMEOUT_MS, 0 = tanpa batas).
+// Deadline milik request yang lebih dekat tetap berlaku; cancel wajib dipanggil.
+func WithQueryTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// QueryError menandai *pq.Error 57014 (query_canceled) sebagai context.DeadlineExceeded bila
+// penyebabnya deadline ctx query sendiri. lib/pq membatalkan query di server saat context selesai,
+// sehingga kode yang sama muncul baik karena timeout maupun karena client memutus request.
+func QueryError(ctx context.Context, err error) error {
+	if isQueryCanceled(err) && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", context.DeadlineExceeded, err)
+	}
+	return err
+}
+
+// IsQueryTimeout: err berasal dari batas waktu query, bukan dari client yang membatalkan request.
+// 57014 tanpa penanda deadline hanya dihitung timeout bila deadline ctx (request) memang terlewati;
+// pembatalan oleh client atau statement_timeout server tidak dilaporkan sebagai 504.
+func IsQueryTimeout(ctx context.Context, err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	return isQueryCanceled(err) && errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
+
+func isQueryCanceled(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "57014"
+}
+
 func CloseDB() {
 	if DB != nil {
 		log.Println("Menutup koneksi database...")
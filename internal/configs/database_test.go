@@ -0,0 +1,40 @@
+package configs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestQueryTimeoutIgnoresClientCancel(t *testing.T) {
+	queryCanceled := fmt.Errorf("gagal query: %w", &pq.Error{Code: "57014", Message: "canceling statement due to user request"})
+
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Deadline query sendiri: wrapper repository menandainya sebagai DeadlineExceeded
+	err := QueryError(expired, queryCanceled)
+	if !errors.Is(err, context.DeadlineExceeded) || !IsQueryTimeout(context.Background(), err) {
+		t.Errorf("57014 karena deadline harus jadi timeout, dapat %v", err)
+	}
+	if !IsQueryTimeout(expired, queryCanceled) {
+		t.Error("57014 dengan deadline request terlewati harus timeout")
+	}
+
+	// Client membatalkan request: kode 57014 yang sama bukan timeout
+	if err := QueryError(canceled, queryCanceled); errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("57014 karena pembatalan client tidak boleh ditandai deadline, dapat %v", err)
+	}
+	if IsQueryTimeout(canceled, queryCanceled) {
+		t.Error("57014 karena pembatalan client tidak boleh dianggap timeout")
+	}
+	if IsQueryTimeout(context.Background(), queryCanceled) {
+		t.Error("57014 tanpa deadline terlewati tidak boleh dianggap timeout")
+	}
+}
@@ -0,0 +1,52 @@
+package configs
+
+import (
+	"strings"
+)
+
+// OIDCProviderConfig: Satu identity provider OpenID Connect (Google Workspace, Microsoft 365, IdP lokal, dll)
+type OIDCProviderConfig struct {
+	Name         string // Dipakai di URL: /auth/oidc/{name}/login
+	Issuer       string // URL issuer; discovery di {issuer}/.well-known/openid-configuration
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string   // Harus sama persis dengan yang didaftarkan di IdP (.../auth/oidc/{name}/callback)
+	Scopes       []string // Default: openid email profile
+	// AllowedDomains: Domain email yang boleh dicocokkan otomatis ke akun lokal.
+	// Jika diisi, email dari domain ini dianggap terverifikasi walau IdP tidak mengirim email_verified.
+	AllowedDomains []string
+}
+
+// loadOIDCProviders membaca OIDC_PROVIDERS (nama dipisah koma) lalu OIDC_<NAMA>_* untuk tiap provider.
+// Provider yang terdaftar wajib punya issuer, client ID dan redirect URL.
+func loadOIDCProviders(s *source) []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+	for _, name := range s.List("OIDC_PROVIDERS", nil) {
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		cfg := OIDCProviderConfig{
+			Name:           strings.ToLower(name),
+			Issuer:         strings.TrimSuffix(s.String(prefix+"ISSUER", ""), "/"),
+			ClientID:       s.String(prefix+"CLIENT_ID", ""),
+			ClientSecret:   s.String(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:    s.String(prefix+"REDIRECT_URL", ""),
+			Scopes:         s.List(prefix+"SCOPES", []string{"openid", "email", "profile"}),
+			AllowedDomains: splitList(strings.ToLower(strings.Join(s.List(prefix+"ALLOWED_DOMAINS", nil), ","))),
+		}
+		if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+			s.fail(prefix+"*", "ISSUER, CLIENT_ID dan REDIRECT_URL wajib diisi karena %s ada di OIDC_PROVIDERS", name)
+		}
+		providers = append(providers, cfg)
+	}
+	return providers
+}
+
+// splitList memecah nilai env yang dipisah koma dan membuang elemen kosong
+func splitList(value string) []string {
+	var out []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,84 @@
+package configs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Nama grup rate limit. Endpoint publik dibatasi per IP, endpoint terproteksi per uid.
+const (
+	RateLimitLogin          = "login"
+	RateLimitLoginTwoFactor = "login_2fa"
+	RateLimitRefresh        = "refresh"
+	RateLimitPasswordForgot = "password_forgot"
+	RateLimitPasswordReset  = "password_reset"
+	RateLimitOIDC           = "oidc"
+	RateLimitAPI            = "api"
+)
+
+// RateLimit: Maksimal Limit request dalam jendela geser Window
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
+
+// RateLimitConfig: Batas request per grup route
+type RateLimitConfig struct {
+	Enabled bool
+	Routes  map[string]RateLimit
+}
+
+// defaultRateLimits: Batas bawaan jika RATE_LIMIT_<GRUP> tidak diisi
+var defaultRateLimits = map[string]RateLimit{
+	RateLimitLogin:          {10, time.Minute},
+	RateLimitLoginTwoFactor: {10, time.Minute},
+	RateLimitRefresh:        {30, time.Minute},
+	RateLimitPasswordForgot: {5, 15 * time.Minute},
+	RateLimitPasswordReset:  {10, 15 * time.Minute},
+	RateLimitOIDC:           {20, time.Minute},
+	RateLimitAPI:            {300, time.Minute},
+}
+
+// loadRateLimitConfig membaca RATE_LIMIT_ENABLED dan RATE_LIMIT_<GRUP> (format "jumlah/durasi",
+// mis. RATE_LIMIT_LOGIN=10/1m)
+func loadRateLimitConfig(s *source) RateLimitConfig {
+	cfg := RateLimitConfig{
+		Enabled: s.Bool("RATE_LIMIT_ENABLED", true),
+		Routes:  map[string]RateLimit{},
+	}
+	for name, def := range defaultRateLimits {
+		key := "RATE_LIMIT_" + strings.ToUpper(name)
+		raw := s.String(key, def.String())
+		limit, err := ParseRateLimit(raw)
+		if err != nil {
+			s.fail(key, "%q tidak valid: %v", raw, err)
+			limit = def
+		}
+		cfg.Routes[name] = limit
+	}
+	return cfg
+}
+
+// String: Format "jumlah/durasi", kebalikan ParseRateLimit
+func (l RateLimit) String() string {
+	return fmt.Sprintf("%d/%s", l.Limit, l.Window)
+}
+
+// ParseRateLimit membaca format "jumlah/durasi", mis. "10/1m" atau "300/30s"
+func ParseRateLimit(s string) (RateLimit, error) {
+	count, window, ok := strings.Cut(s, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("format harus jumlah/durasi")
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || limit <= 0 {
+		return RateLimit{}, fmt.Errorf("jumlah harus bilangan bulat positif")
+	}
+	dur, err := time.ParseDuration(strings.TrimSpace(window))
+	if err != nil || dur < time.Second {
+		return RateLimit{}, fmt.Errorf("durasi minimal 1s")
+	}
+	return RateLimit{Limit: limit, Window: dur}, nil
+}
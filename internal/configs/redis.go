@@ -2,29 +2,59 @@ package configs
 
 import (
 	"context"
-	"os"
-	"github.com/redis/go-redis/v9"
 	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
 )
 
 var RedisClient *redis.Client
-var Ctx = context.Background()
 
-func InitRedis() {
-	addr := os.Getenv("REDIS_ADDR")
-	pass := os.Getenv("REDIS_PASSWORD")
+// RedisBreaker: Circuit breaker untuk semua perintah lewat RedisClient, sekaligus sumber status kesehatan Redis
+var RedisBreaker = NewCircuitBreaker(5, 10*time.Second)
+
+// RedisConfig: Koneksi Redis dan ambang circuit breaker
+type RedisConfig struct {
+	Addr            string
+	Password        string
+	DB              int
+	Timeout         time.Duration // Batas waktu baca/tulis per perintah
+	BreakerFailures int           // Kegagalan koneksi berturut-turut sebelum breaker terbuka
+	BreakerCooldown time.Duration // Lama breaker terbuka sebelum mencoba lagi
+}
 
+func loadRedisConfig(s *source) RedisConfig {
+	return RedisConfig{
+		Addr:            s.String("REDIS_ADDR", ""),
+		Password:        s.String("REDIS_PASSWORD", ""),
+		DB:              s.Int("REDIS_DB", 0, 0),
+		Timeout:         s.Duration("REDIS_TIMEOUT_MS", 500, time.Millisecond),
+		BreakerFailures: s.Int("REDIS_BREAKER_FAILURES", 5, 1),
+		BreakerCooldown: s.Duration("REDIS_BREAKER_COOLDOWN_SECONDS", 10, time.Second),
+	}
+}
+
+func InitRedis(cfg RedisConfig) {
 	RedisClient = redis.NewClient(&redis.Options{
-		Addr:     addr,
-		Password: pass,
-		DB:       0,
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+		// Deadline dari context request ikut dipakai sebagai batas waktu socket
+		ContextTimeoutEnabled: true,
+		ReadTimeout:           cfg.Timeout,
+		WriteTimeout:          cfg.Timeout,
 	})
 
 	// Test koneksi
-	_, err := RedisClient.Ping(Ctx).Result()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	_, err := RedisClient.Ping(ctx).Result()
 	if err != nil {
 		log.Fatalf("Gagal terhubung ke Redis: %v", err)
 	}
 
+	RedisBreaker = NewCircuitBreaker(cfg.BreakerFailures, cfg.BreakerCooldown)
+	RedisClient.AddHook(RedisBreaker)
+
 	log.Println("Koneksi ke Redis Berhasil!")
-}
\ No newline at end of file
+}
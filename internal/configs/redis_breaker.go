@@ -0,0 +1,168 @@
+package configs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrRedisUnavailable: Perintah tidak dikirim karena circuit breaker Redis sedang terbuka
+var ErrRedisUnavailable = errors.New("redis tidak tersedia (circuit breaker terbuka)")
+
+// Status circuit breaker
+const (
+	CircuitClosed   = "closed"    // Normal, semua perintah diteruskan
+	CircuitOpen     = "open"      // Redis dianggap mati, perintah langsung gagal tanpa menunggu timeout
+	CircuitHalfOpen = "half_open" // Masa cooldown habis, satu perintah percobaan diteruskan
+)
+
+// CircuitBreaker memutus akses ke Redis setelah Threshold kegagalan koneksi berturut-turut,
+// lalu mencoba lagi setelah Cooldown. Dipasang sebagai hook go-redis sehingga berlaku untuk semua pemanggil.
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu            sync.Mutex
+	state         string
+	failures      int
+	openedAt      time.Time
+	probing       bool
+	lastError     string
+	lastFailureAt time.Time
+	lastSuccessAt time.Time
+}
+
+// RedisHealth: Potret kondisi Redis untuk endpoint health
+type RedisHealth struct {
+	Healthy             bool       `json:"healthy"`
+	Circuit             string     `json:"circuit"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+	LastSuccessAt       *time.Time `json:"last_success_at,omitempty"`
+}
+
+// NewCircuitBreaker membuat breaker dalam keadaan tertutup (normal)
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{Threshold: threshold, Cooldown: cooldown, state: CircuitClosed}
+}
+
+// Health mengembalikan kondisi breaker saat ini. Redis dianggap sehat hanya jika breaker tertutup.
+func (b *CircuitBreaker) Health() RedisHealth {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	h := RedisHealth{
+		Healthy:             b.state == CircuitClosed,
+		Circuit:             b.state,
+		ConsecutiveFailures: b.failures,
+	}
+	if !b.lastFailureAt.IsZero() {
+		t := b.lastFailureAt
+		h.LastFailureAt = &t
+	}
+	if !b.lastSuccessAt.IsZero() {
+		t := b.lastSuccessAt
+		h.LastSuccessAt = &t
+	}
+	return h
+}
+
+// allow memutuskan apakah perintah boleh dikirim ke Redis
+func (b *CircuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if time.Since(b.openedAt) < b.Cooldown {
+			return false
+		}
+		b.state, b.probing = CircuitHalfOpen, true
+		return true
+	case CircuitHalfOpen:
+		// Hanya satu perintah percobaan sekaligus
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+// record mencatat hasil satu perintah. Hanya error koneksi/timeout yang dihitung gagal;
+// balasan error dari server (mis. NOSCRIPT) dan redis.Nil berarti Redis hidup.
+func (b *CircuitBreaker) record(err error) {
+	failed := isRedisConnError(err)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+
+	if !failed {
+		if b.state != CircuitClosed {
+			log.Printf("REDIS: koneksi pulih, circuit breaker ditutup")
+		}
+		b.state, b.failures, b.lastError = CircuitClosed, 0, ""
+		b.lastSuccessAt = time.Now()
+		return
+	}
+
+	b.failures++
+	b.lastFailureAt = time.Now()
+	if msg := err.Error(); msg != b.lastError {
+		b.lastError = msg
+		log.Printf("REDIS: perintah gagal (%d berturut-turut): %v", b.failures, err)
+	}
+	if b.state == CircuitHalfOpen || (b.state == CircuitClosed && b.failures >= b.Threshold) {
+		if b.state == CircuitClosed {
+			log.Printf("REDIS: circuit breaker dibuka, perintah Redis dilewati selama %s", b.Cooldown)
+		}
+		b.state, b.openedAt = CircuitOpen, time.Now()
+	}
+}
+
+func isRedisConnError(err error) bool {
+	if err == nil || errors.Is(err, redis.Nil) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var replyErr redis.Error
+	return !errors.As(err, &replyErr)
+}
+
+// DialHook: Implementasi redis.Hook, koneksi baru tidak dibatasi di sini
+func (b *CircuitBreaker) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook: Implementasi redis.Hook untuk perintah tunggal
+func (b *CircuitBreaker) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if !b.allow() {
+			cmd.SetErr(ErrRedisUnavailable)
+			return ErrRedisUnavailable
+		}
+		err := next(ctx, cmd)
+		b.record(err)
+		return err
+	}
+}
+
+// ProcessPipelineHook: Implementasi redis.Hook untuk pipeline dan transaksi
+func (b *CircuitBreaker) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if !b.allow() {
+			for _, cmd := range cmds {
+				cmd.SetErr(ErrRedisUnavailable)
+			}
+			return ErrRedisUnavailable
+		}
+		err := next(ctx, cmds)
+		b.record(err)
+		return err
+	}
+}
@@ -0,0 +1,75 @@
+package configs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// replyError: Balasan error dari server Redis (Redis hidup)
+type replyError string
+
+func (e replyError) Error() string { return string(e) }
+func (replyError) RedisError()     {}
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	b := NewCircuitBreaker(2, 50*time.Millisecond)
+	var nextErr error
+	calls := 0
+	process := b.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+		calls++
+		return nextErr
+	})
+	run := func() error {
+		return process(context.Background(), redis.NewStatusCmd(context.Background(), "ping"))
+	}
+
+	// Balasan error server dan redis.Nil tidak dihitung gagal
+	for _, err := range []error{redis.Nil, replyError("NOSCRIPT")} {
+		nextErr = err
+		run()
+	}
+	if h := b.Health(); !h.Healthy || h.ConsecutiveFailures != 0 {
+		t.Fatalf("balasan server tidak boleh membuka breaker: %+v", h)
+	}
+
+	nextErr = errors.New("dial tcp: connection refused")
+	run()
+	run()
+	if h := b.Health(); h.Healthy || h.Circuit != CircuitOpen {
+		t.Fatalf("breaker harus terbuka setelah 2 kegagalan: %+v", h)
+	}
+
+	calls = 0
+	if err := run(); !errors.Is(err, ErrRedisUnavailable) || calls != 0 {
+		t.Fatalf("saat terbuka perintah tidak boleh dikirim: err=%v calls=%d", err, calls)
+	}
+
+	// Setelah cooldown satu percobaan diteruskan; sukses menutup breaker
+	time.Sleep(60 * time.Millisecond)
+	nextErr = nil
+	if err := run(); err != nil || calls != 1 {
+		t.Fatalf("percobaan half-open harus diteruskan: err=%v calls=%d", err, calls)
+	}
+	if h := b.Health(); !h.Healthy || h.Circuit != CircuitClosed {
+		t.Fatalf("breaker harus tertutup lagi: %+v", h)
+	}
+}
+
+func TestCircuitBreakerHalfOpenFailureReopens(t *testing.T) {
+	b := NewCircuitBreaker(1, 20*time.Millisecond)
+	process := b.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+		return context.DeadlineExceeded
+	})
+	run := func() { process(context.Background(), redis.NewStatusCmd(context.Background(), "ping")) }
+
+	run()
+	time.Sleep(30 * time.Millisecond)
+	run()
+	if h := b.Health(); h.Circuit != CircuitOpen {
+		t.Fatalf("percobaan half-open yang gagal harus membuka breaker lagi: %+v", h)
+	}
+}
@@ -0,0 +1,133 @@
+package configs
+
+import (
+	"net/http"
+	"time"
+
+	"go-sis-be/internal/utils"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// LoginLockoutConfig: Ambang batas proteksi brute-force pada /login
+type LoginLockoutConfig struct {
+	MaxUserAttempts int           // Gagal berturut-turut per username sebelum dikunci
+	MaxIPAttempts   int           // Gagal per IP (semua username) sebelum IP dikunci
+	Window          time.Duration // Jendela waktu penghitungan kegagalan
+	LockDuration    time.Duration // Lama penguncian sementara
+	BaseDelay       time.Duration // Delay awal setelah gagal, berlipat dua tiap kegagalan
+	MaxDelay        time.Duration // Batas atas delay progresif
+}
+
+// loadLoginLockoutConfig membaca konfigurasi lockout LOGIN_*, dengan nilai default yang aman
+func loadLoginLockoutConfig(s *source) LoginLockoutConfig {
+	return LoginLockoutConfig{
+		MaxUserAttempts: s.Int("LOGIN_MAX_ATTEMPTS", 5, 1),
+		MaxIPAttempts:   s.Int("LOGIN_MAX_ATTEMPTS_PER_IP", 50, 1),
+		Window:          s.Duration("LOGIN_ATTEMPT_WINDOW_MINUTES", 15, time.Minute),
+		LockDuration:    s.Duration("LOGIN_LOCKOUT_MINUTES", 15, time.Minute),
+		BaseDelay:       s.Duration("LOGIN_DELAY_BASE_MS", 250, time.Millisecond),
+		MaxDelay:        s.Duration("LOGIN_DELAY_MAX_MS", 4000, time.Millisecond),
+	}
+}
+
+// TwoFactorConfig: Pengaturan TOTP 2FA
+type TwoFactorConfig struct {
+	Issuer        string   // Nama yang tampil di aplikasi authenticator
+	RequiredRoles []string // Role yang wajib memakai 2FA (nama role, mis. "admin")
+}
+
+// loadTwoFactorConfig membaca TWO_FACTOR_ISSUER dan TWO_FACTOR_REQUIRED_ROLES (dipisah koma)
+func loadTwoFactorConfig(s *source) TwoFactorConfig {
+	return TwoFactorConfig{
+		Issuer:        s.String("TWO_FACTOR_ISSUER", "SIS"),
+		RequiredRoles: s.List("TWO_FACTOR_REQUIRED_ROLES", nil),
+	}
+}
+
+// RequiredFor mengecek apakah role wajib memakai 2FA
+func (c TwoFactorConfig) RequiredFor(role string) bool {
+	for _, r := range c.RequiredRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// loadPasswordPolicy membaca kebijakan password PASSWORD_*, default dari utils.DefaultPasswordPolicy
+func loadPasswordPolicy(s *source) utils.PasswordPolicy {
+	def := utils.DefaultPasswordPolicy()
+	return utils.PasswordPolicy{
+		MinLength:        s.Int("PASSWORD_MIN_LENGTH", def.MinLength, 1),
+		RequireUppercase: s.Bool("PASSWORD_REQUIRE_UPPERCASE", def.RequireUppercase),
+		RequireLowercase: s.Bool("PASSWORD_REQUIRE_LOWERCASE", def.RequireLowercase),
+		RequireDigit:     s.Bool("PASSWORD_REQUIRE_DIGIT", def.RequireDigit),
+		RequireSymbol:    s.Bool("PASSWORD_REQUIRE_SYMBOL", def.RequireSymbol),
+		DisallowUsername: s.Bool("PASSWORD_DISALLOW_USERNAME", def.DisallowUsername),
+		RejectCommon:     s.Bool("PASSWORD_REJECT_COMMON", def.RejectCommon),
+		HistorySize:      s.Int("PASSWORD_HISTORY", def.HistorySize, 0), // 0 mematikan cek riwayat
+	}
+}
+
+// loadPasswordHashConfig membaca algoritma dan parameter hash password PASSWORD_HASH_*,
+// default dari utils.DefaultPasswordHashConfig. Nilai yang pas untuk server bisa dicari dengan
+// benchmark BenchmarkCalibratePasswordHash di internal/utils.
+func loadPasswordHashConfig(s *source) utils.PasswordHashConfig {
+	cfg := utils.DefaultPasswordHashConfig()
+	cfg.Algorithm = s.Enum("PASSWORD_HASH_ALGORITHM", cfg.Algorithm, utils.HashArgon2id, utils.HashBcrypt)
+
+	cfg.BcryptCost = s.Int("PASSWORD_HASH_BCRYPT_COST", cfg.BcryptCost, bcrypt.MinCost)
+	if cfg.BcryptCost > bcrypt.MaxCost {
+		s.fail("PASSWORD_HASH_BCRYPT_COST", "maksimal %d", bcrypt.MaxCost)
+		cfg.BcryptCost = bcrypt.MaxCost
+	}
+	cfg.Argon2.Memory = uint32(s.Int("PASSWORD_HASH_ARGON2_MEMORY_KB", int(cfg.Argon2.Memory), 8))
+	cfg.Argon2.Iterations = uint32(s.Int("PASSWORD_HASH_ARGON2_ITERATIONS", int(cfg.Argon2.Iterations), 1))
+	par := s.Int("PASSWORD_HASH_ARGON2_PARALLELISM", int(cfg.Argon2.Parallelism), 1)
+	if par > 255 {
+		s.fail("PASSWORD_HASH_ARGON2_PARALLELISM", "maksimal 255")
+		par = 255
+	}
+	cfg.Argon2.Parallelism = uint8(par)
+	return cfg
+}
+
+// TokenBlacklistConfig: Perilaku blacklist access token saat Redis bermasalah
+type TokenBlacklistConfig struct {
+	FailClosed  bool          // true: token dianggap dicabut jika status blacklist tidak bisa dicek
+	FallbackTTL time.Duration // Lama token yang baru di-blacklist diingat di memori proses sebagai cadangan
+}
+
+// loadTokenBlacklistConfig membaca TOKEN_BLACKLIST_FAIL_MODE (open/closed, default open)
+// dan TOKEN_BLACKLIST_FALLBACK_MINUTES (default 15, sama dengan umur access token)
+func loadTokenBlacklistConfig(s *source) TokenBlacklistConfig {
+	return TokenBlacklistConfig{
+		FailClosed:  s.Enum("TOKEN_BLACKLIST_FAIL_MODE", "open", "open", "closed") == "closed",
+		FallbackTTL: s.Duration("TOKEN_BLACKLIST_FALLBACK_MINUTES", 15, time.Minute),
+	}
+}
+
+// CookieConfig: Atribut cookie refresh token & CSRF, berbeda per environment
+type CookieConfig struct {
+	Secure      bool
+	SameSite    http.SameSite
+	Domain      string // Kosong = host API saja
+	RefreshPath string // Cookie refresh token hanya dikirim ke path ini
+}
+
+// loadCookieConfig membaca COOKIE_SECURE, COOKIE_SAMESITE (lax/strict/none), COOKIE_DOMAIN
+// dan REFRESH_COOKIE_PATH. Profil staging/production mengaktifkan Secure, production juga Strict.
+func loadCookieConfig(s *source) CookieConfig {
+	sameSite := map[string]http.SameSite{
+		"lax":    http.SameSiteLaxMode,
+		"strict": http.SameSiteStrictMode,
+		"none":   http.SameSiteNoneMode,
+	}
+	return CookieConfig{
+		Secure:      s.Bool("COOKIE_SECURE", false),
+		SameSite:    sameSite[s.Enum("COOKIE_SAMESITE", "lax", "lax", "strict", "none")],
+		Domain:      s.String("COOKIE_DOMAIN", ""),
+		RefreshPath: s.String("REFRESH_COOKIE_PATH", "/api/v1/refresh"),
+	}
+}
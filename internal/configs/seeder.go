@@ -14,8 +14,11 @@ const (
 	WALI_ROLE_ID  = 4
 )
 
-func SeedDatabase() {
-	// Pastikan hanya berjalan saat DEVELOPMENT (misal, cek Environment Variable)
+// SeedDatabase membuat role dan admin awal bila tabel login_users masih kosong.
+// Hanya dipanggil perintah "api seed" (tidak pernah saat server start), yang sudah memastikan
+// profil eksplisit non-production dan password lolos kebijakan. Password admin awal di-hash
+// dengan konfigurasi hash dan dicatat di riwayat password (historySize 0 = tanpa riwayat).
+func SeedDatabase(hash utils.PasswordHashConfig, historySize int, password string) {
 	var count int
 	err := DB.QueryRow("SELECT COUNT(*) FROM login_users").Scan(&count)
 	if err != nil {
@@ -29,9 +32,9 @@ func SeedDatabase() {
 	fmt.Println("Memulai Seeder Database...")
 
 	seedRoles()
-	seedInitialAdmin()
+	seedInitialAdmin(hash, historySize, password)
 
-	fmt.Println("Seeder Selesai! 1 Admin dan 1000 User Dummy berhasil dibuat.")
+	fmt.Println("Seeder Selesai! Admin awal berhasil dibuat.")
 }
 
 func seedRoles() {
@@ -39,6 +42,7 @@ func seedRoles() {
 		ADMIN_ROLE_ID: "admin",
 		GURU_ROLE_ID:  "guru",
 		MURID_ROLE_ID: "murid",
+		WALI_ROLE_ID:  "wali",
 	}
 
 	for id, name := range roles {
@@ -49,12 +53,14 @@ func seedRoles() {
 			log.Printf("Gagal seeding role %s: %v", name, err)
 		}
 	}
-	fmt.Println("   -> Roles (Admin & User) dipastikan ada.")
+	fmt.Println("   -> Roles (admin, guru, murid, wali) dipastikan ada.")
 }
 
-func seedInitialAdmin() {
-	password := "admin123"
-	hashedPassword, _ := utils.HashPassword(password)
+func seedInitialAdmin(hash utils.PasswordHashConfig, historySize int, password string) {
+	hashedPassword, err := hash.Hash(password)
+	if err != nil {
+		log.Fatalf("Gagal hash password Admin awal: %v", err)
+	}
 	username := "admin"
 
 	// --- Mulai Transaksi untuk Admin (Wajib 2 INSERT) ---
@@ -67,8 +73,9 @@ func seedInitialAdmin() {
 	var uid string
 
 	// 1. INSERT ke login_users
-	queryLogin := `INSERT INTO login_users (username, pass, role_id) 
-		VALUES ($1, $2, $3) RETURNING uid`
+	// Password default wajib diganti saat login pertama
+	queryLogin := `INSERT INTO login_users (username, pass, role_id, must_change_password) 
+		VALUES ($1, $2, $3, TRUE) RETURNING uid`
 
 	err = tx.QueryRow(queryLogin, username, hashedPassword, ADMIN_ROLE_ID).Scan(&uid)
 	if err != nil {
@@ -99,10 +106,18 @@ func seedInitialAdmin() {
 		log.Fatalf("Gagal membuat Admin awal (person): %v", err)
 	}
 
-	// 3. Commit Transaksi
+	// 3. Riwayat password, sama seperti akun yang dibuat lewat API
+	if historySize > 0 {
+		_, err = tx.Exec(`INSERT INTO password_history (uid, password_hash, created_at) VALUES ($1, $2, NOW())`, uid, hashedPassword)
+		if err != nil {
+			log.Fatalf("Gagal menyimpan riwayat password Admin awal: %v", err)
+		}
+	}
+
+	// 4. Commit Transaksi
 	if err := tx.Commit(); err != nil {
 		log.Fatalf("Gagal commit transaksi Admin: %v", err)
 	}
 
-	fmt.Printf(" 	-> Admin awal dibuat (Username: %s, Pass: %s). UID: %s\n", username, password, uid)
+	fmt.Printf(" 	-> Admin awal dibuat (Username: %s, password sesuai flag -password). UID: %s\n", username, uid)
 }
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"go-sis-be/internal/models"
+	"go-sis-be/internal/utils"
+)
+
+// HandleListAudit menangani GET /audit: daftar audit log dengan filter
+// actor_uid, impersonator_uid, target_uid, action, from, to (YYYY-MM-DD atau RFC3339), page, limit
+func (h *Handler) HandleListAudit(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter := models.AuditFilter{
+		ActorUID:        q.Get("actor_uid"),
+		ImpersonatorUID: q.Get("impersonator_uid"),
+		TargetUID:       q.Get("target_uid"),
+		Action:          q.Get("action"),
+		Page:            utils.ParseIntQuery(q.Get("page"), 1),
+		Limit:           utils.ParseIntQuery(q.Get("limit"), 20),
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+
+	var err error
+	if filter.From, err = parseAuditTime(q.Get("from"), false); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Format 'from' tidak valid (YYYY-MM-DD atau RFC3339)")
+		return
+	}
+	if filter.To, err = parseAuditTime(q.Get("to"), true); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Format 'to' tidak valid (YYYY-MM-DD atau RFC3339)")
+		return
+	}
+
+	logs, totalCount, err := h.Audit.ListAuditLogs(r.Context(), filter)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error list audit log: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal mengambil audit log")
+		return
+	}
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"total_data": totalCount,
+		"page":       filter.Page,
+		"limit":      filter.Limit,
+		"data":       logs,
+	})
+}
+
+// parseAuditTime mem-parse batas tanggal filter. Untuk batas akhir berformat tanggal saja,
+// hasilnya digeser ke awal hari berikutnya supaya seluruh hari tersebut ikut.
+func parseAuditTime(value string, endOfRange bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	if endOfRange {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
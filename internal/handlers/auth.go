@@ -1,14 +1,19 @@
 package handlers
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json" // Tambahkan fmt untuk logging dan error message
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	// Tambahkan strings untuk membuat array ENUM
+	"go-sis-be/internal/configs"
 	"go-sis-be/internal/models"
 	"go-sis-be/internal/utils"
 	"go-sis-be/middleware"
@@ -20,7 +25,8 @@ import (
 
 type LoginRequest struct {
 	Username string `json:"username"`
-	Pass     string `json:"password"` // Sebenarnya pass (DB) tapi tag JSON-nya password (Client)
+	Pass     string `json:"password"`         // Sebenarnya pass (DB) tapi tag JSON-nya password (Client)
+	Device   string `json:"device,omitempty"` // Nama perangkat (opsional), default dari User-Agent
 }
 
 type TokenResponse struct {
@@ -35,63 +41,222 @@ type RefreshRequest struct {
 // ==========================================
 // 1. LOGIN HANDLER
 // ==========================================
-func LoginHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	var req LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
-	user, role, err := models.GetUserForLogin(req.Username)
+	clientIP := utils.ClientIP(r)
+
+	// Tolak lebih awal jika username atau IP sedang dikunci
+	if ttl := h.LoginAttempts.GetLoginLockTTL(r.Context(), req.Username, clientIP); ttl > 0 {
+		respondLoginLocked(w, ttl)
+		return
+	}
+
+	user, role, err := h.Users.GetUserForLogin(r.Context(), req.Username)
 	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
 		http.Error(w, "Server error", http.StatusInternalServerError)
 		return
 	}
 
 	hashStart := time.Now()
 	if user == nil || !utils.CheckPasswordHash(req.Pass, user.Pass) {
+		uid := ""
+		if user != nil {
+			uid = user.UID
+		}
+		h.handleLoginFailure(r.Context(), req.Username, uid, clientIP)
 		http.Error(w, "Username atau password salah", http.StatusUnauthorized)
 		return
 	}
 	log.Printf("Checking Credential: %s", time.Since(hashStart))
+	// Penghitung gagal baru di-reset saat token terbit (issueLoginTokens), bukan di sini:
+	// user dengan 2FA masih harus lolos kode TOTP yang kegagalannya ikut dihitung
+	h.rehashPassword(r.Context(), user.UID, user.Pass, req.Pass)
+
+	device := strings.TrimSpace(req.Device)
+	if device == "" {
+		device = utils.DeviceLabel(r.UserAgent())
+	}
+
+	h.completeLogin(w, r, &models.UserIdentity{
+		UID:                user.UID,
+		Username:           user.Username,
+		Role:               role,
+		MustChangePassword: user.MustChangePassword,
+		TokenVersion:       user.TokenVersion,
+	}, device)
+}
+
+// rehashPassword memperbarui hash password yang lebih lemah dari konfigurasi hash aktif
+// (mis. bcrypt lama ke argon2id). Kegagalan hanya di-log karena login tetap sah.
+func (h *Handler) rehashPassword(ctx context.Context, uid, storedHash, password string) {
+	if !h.cfg.PasswordHash.NeedsRehash(storedHash) {
+		return
+	}
+	newHash, err := h.cfg.PasswordHash.Hash(password)
+	if err != nil {
+		log.Printf("Error rehash password %s: %v", uid, err)
+		return
+	}
+	if err := h.Users.UpgradePasswordHash(ctx, uid, storedHash, newHash); err != nil {
+		log.Printf("Error rehash password %s: %v", uid, err)
+	}
+}
+
+// completeLogin dijalankan setelah faktor pertama lolos (password atau OIDC):
+// meminta kode 2FA jika aktif, atau langsung menerbitkan token.
+func (h *Handler) completeLogin(w http.ResponseWriter, r *http.Request, ident *models.UserIdentity, device string) {
+	twoFactorEnabled, err := h.TwoFactor.IsTwoFactorEnabled(r.Context(), ident.UID)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error cek 2FA %s: %v", ident.UID, err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	// 2FA aktif: token asli baru diterbitkan setelah kode diverifikasi di /login/2fa
+	if twoFactorEnabled {
+		challenge, _, err := utils.GenerateTwoFactorChallenge(ident.UID, device)
+		if err != nil {
+			http.Error(w, "Server error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set(utils.ContentHeader, utils.Mime)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":             "Masukkan kode 2FA",
+			"two_factor_required": true,
+			"challenge_token":     challenge,
+		})
+		return
+	}
+
+	flags := utils.AccessFlags{
+		MustChangePassword:     ident.MustChangePassword,
+		TwoFactorSetupRequired: h.cfg.TwoFactor.RequiredFor(ident.Role),
+	}
+	h.issueLoginTokens(w, r, ident.UID, ident.Username, ident.Role, device, ident.TokenVersion, flags)
+}
+
+// issueLoginTokens membuka sesi baru lalu menerbitkan access token + refresh token cookie.
+// Dipakai oleh login biasa dan login langkah kedua (2FA). Semua faktor sudah lolos,
+// jadi penghitung gagal login username di-reset di sini.
+func (h *Handler) issueLoginTokens(w http.ResponseWriter, r *http.Request, uid, username, role, device string, tokenVersion int, flags utils.AccessFlags) {
+	h.LoginAttempts.ResetLoginFailures(r.Context(), username)
+
+	// Setiap login membuka sesi baru; ID sesi sekaligus menjadi token family
+	sessionID, err := h.Sessions.CreateSession(r.Context(), uid, device, utils.ClientIP(r), r.UserAgent())
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error membuat sesi: %v", err)
+		http.Error(w, "Gagal menyimpan session", http.StatusInternalServerError)
+		return
+	}
 
 	// Generate Tokens
-	accessToken, _ := utils.GenerateAccessToken(user.UID, user.Username, role)
-	refreshToken, _ := utils.GenerateRefreshToken(user.UID)
+	accessToken, _ := utils.GenerateAccessToken(uid, username, role, sessionID, tokenVersion, flags)
+	refreshToken, refreshClaims, err := utils.GenerateRefreshToken(uid, sessionID)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
 
 	// Simpan Refresh Token ke DB (PENTING!)
-	if err := models.UpdateRefreshToken(user.UID, refreshToken); err != nil {
+	if err := h.Sessions.CreateRefreshToken(r.Context(), refreshClaims.ID, uid, sessionID, refreshClaims.ExpiresAt.Time); err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
 		http.Error(w, "Gagal menyimpan session", http.StatusInternalServerError)
 		return
 	}
-	http.SetCookie(w, &http.Cookie{
-		Name:     "refresh_token",
-		Value:    refreshToken,
-		Expires:  time.Now().Add(7 * 24 * time.Hour),
-		HttpOnly: true,
-		Secure:   false,
-		Path:     "/",
-		SameSite: http.SameSiteLaxMode,
-	})
+	if err := h.setRefreshCookie(w, refreshToken); err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set(utils.ContentHeader, utils.Mime)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message":      "Login Success!",
-		"access_token": accessToken,
+		"message":                   "Login Success!",
+		"access_token":              accessToken,
+		"must_change_password":      flags.MustChangePassword,
+		"two_factor_setup_required": flags.TwoFactorSetupRequired,
 	})
 }
 
+// handleLoginFailure mencatat percobaan gagal, mengunci jika perlu, lalu menahan respons
+// dengan delay yang berlipat dua setiap kegagalan berturut-turut.
+func (h *Handler) handleLoginFailure(ctx context.Context, username, uid, ip string) {
+	// Penghitung tidak boleh lolos hanya karena penyerang membatalkan request lebih dulu
+	ctx = context.WithoutCancel(ctx)
+	res, err := h.LoginAttempts.RegisterLoginFailure(ctx, username, ip, h.cfg.LoginLockout)
+	if err != nil {
+		log.Printf("Redis error mencatat gagal login: %v", err)
+		return
+	}
+
+	if res.UserLocked {
+		h.Audit.RecordAuthEvent(ctx, models.AuthEvent{
+			EventType: models.AuthEventLoginLocked,
+			UID:       uid,
+			Username:  username,
+			IPAddress: ip,
+			Detail:    fmt.Sprintf("username dikunci %s setelah %d percobaan gagal", h.cfg.LoginLockout.LockDuration, res.UserFailures),
+		})
+	}
+	if res.IPLocked {
+		h.Audit.RecordAuthEvent(ctx, models.AuthEvent{
+			EventType: models.AuthEventLoginLocked,
+			Username:  username,
+			IPAddress: ip,
+			Detail:    fmt.Sprintf("IP dikunci %s setelah %d percobaan gagal", h.cfg.LoginLockout.LockDuration, res.IPFailures),
+		})
+	}
+
+	time.Sleep(loginFailureDelay(res.UserFailures, h.cfg.LoginLockout))
+}
+
+// loginFailureDelay menghitung delay progresif: BaseDelay * 2^(fails-1), dibatasi MaxDelay
+func loginFailureDelay(fails int64, cfg configs.LoginLockoutConfig) time.Duration {
+	if fails <= 0 {
+		return 0
+	}
+	delay := cfg.BaseDelay
+	for i := int64(1); i < fails && delay < cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > cfg.MaxDelay {
+		delay = cfg.MaxDelay
+	}
+	return delay
+}
+
+// respondLoginLocked mengirim 429 beserta header Retry-After
+func respondLoginLocked(w http.ResponseWriter, ttl time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(ttl.Seconds())+1))
+	respondWithError(w, http.StatusTooManyRequests,
+		fmt.Sprintf("Terlalu banyak percobaan login gagal. Coba lagi dalam %d menit.", int(ttl.Minutes())+1))
+}
+
 // ==========================================
 // 2. REFRESH TOKEN HANDLER
 // ==========================================
-func RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
 	cookie, err := r.Cookie("refresh_token")
 	if err != nil {
-		fmt.Println("KOK KOSONG? Errornya:", err)
 		http.Error(w, "Cookie gak ada Aa", http.StatusUnauthorized)
 		return
 	}
-	fmt.Println("Kuncinya dapet nih:", cookie.Value)
 	refreshTokenString := cookie.Value
 
 	claims, err := utils.ValidateRefreshToken(refreshTokenString)
@@ -100,13 +265,88 @@ func RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userSess, err := models.GetUserSessionByUID(claims.UID)
-	if err != nil || userSess.RefreshToken != refreshTokenString {
+	record, err := h.Sessions.GetRefreshTokenRecord(r.Context(), claims.ID)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	if record == nil || record.UID != claims.UID {
+		http.Error(w, "Sesi kadaluarsa atau sudah logout", http.StatusUnauthorized)
+		return
+	}
+
+	// Token yang sudah dirotasi dipakai lagi => kemungkinan dicuri. Cabut seluruh family.
+	if record.RevokedAt.Valid {
+		h.handleRefreshTokenReuse(r, record)
+		h.clearRefreshCookie(w)
+		http.Error(w, "Sesi kadaluarsa atau sudah logout", http.StatusUnauthorized)
+		return
+	}
+
+	session, err := h.Sessions.GetSession(r.Context(), record.FamilyID)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	if session == nil || session.RevokedAt != nil {
+		h.clearRefreshCookie(w)
+		http.Error(w, "Sesi kadaluarsa atau sudah logout", http.StatusUnauthorized)
+		return
+	}
+
+	ident, err := h.Users.GetUserIdentityByUID(r.Context(), record.UID)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
 		http.Error(w, "Sesi kadaluarsa atau sudah logout", http.StatusUnauthorized)
 		return
 	}
+	flags, err := h.accessFlagsFor(r.Context(), ident)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error cek 2FA %s: %v", ident.UID, err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	// Rotasi: terbitkan refresh token baru di family yang sama, cabut yang lama
+	newRefreshToken, newClaims, err := utils.GenerateRefreshToken(record.UID, record.FamilyID)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.Sessions.RotateRefreshToken(r.Context(), record.JTI, newClaims.ID, newClaims.ExpiresAt.Time); err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		if errors.Is(err, models.ErrRefreshTokenReused) {
+			h.handleRefreshTokenReuse(r, record)
+			h.clearRefreshCookie(w)
+			http.Error(w, "Sesi kadaluarsa atau sudah logout", http.StatusUnauthorized)
+			return
+		}
+		log.Printf("Error rotasi refresh token: %v", err)
+		http.Error(w, "Gagal memperbarui session", http.StatusInternalServerError)
+		return
+	}
+	if err := h.Sessions.TouchSession(r.Context(), session.ID, utils.ClientIP(r)); err != nil {
+		log.Printf("Error memperbarui sesi %s: %v", session.ID, err)
+	}
 
-	newAccessToken, _ := utils.GenerateAccessToken(userSess.UID, userSess.Username, userSess.Role)
+	newAccessToken, _ := utils.GenerateAccessToken(ident.UID, ident.Username, ident.Role, session.ID, ident.TokenVersion, flags)
+	if err := h.setRefreshCookie(w, newRefreshToken); err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set(utils.ContentHeader, utils.Mime)
 	json.NewEncoder(w).Encode(map[string]string{
@@ -115,10 +355,27 @@ func RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleRefreshTokenReuse mencabut seluruh token family dan mencatat kejadiannya
+func (h *Handler) handleRefreshTokenReuse(r *http.Request, record *models.RefreshTokenRecord) {
+	log.Printf("[SECURITY] Refresh token reuse terdeteksi: uid=%s family=%s jti=%s ip=%s ua=%q",
+		record.UID, record.FamilyID, record.JTI, utils.ClientIP(r), r.UserAgent())
+
+	// Family = sesi, jadi cabut sesinya sekaligus (ikut mencabut semua token di dalamnya).
+	// Tetap dijalankan walau request pemicunya dibatalkan.
+	ctx := context.WithoutCancel(r.Context())
+	err := h.Sessions.RevokeSession(ctx, record.UID, record.FamilyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = h.Sessions.RevokeTokenFamily(ctx, record.FamilyID)
+	}
+	if err != nil {
+		log.Printf("Error mencabut token family %s: %v", record.FamilyID, err)
+	}
+}
+
 // ==========================================
 // 3. LOGOUT HANDLER
 // ==========================================
-func LogoutHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	claimsContext := r.Context().Value(middleware.UserInfoKey)
 	claims, ok := claimsContext.(*utils.JWTClaims)
 
@@ -127,32 +384,87 @@ func LogoutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	uid := claims.UID
-
-	err := models.DeleteRefreshToken(uid)
-	if err != nil {
-		log.Printf("Error deleting refresh token: %v", err)
+	// Logout hanya mengakhiri sesi perangkat ini; sesi di perangkat lain tetap aktif
+	err := h.Sessions.RevokeSession(r.Context(), claims.UID, claims.SessionID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error revoking session: %v", err)
 	}
 
 	authHeader := r.Header.Get("Authorization")
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
-	errBlacklist := models.BlacklistToken(tokenString, 15*time.Minute)
-	if errBlacklist != nil {
-		log.Printf("ERROR REDIS BLACKLIST: %v", errBlacklist)
+	// Blacklist selama sisa umur token, supaya token tidak hidup lagi setelah entri blacklist kadaluarsa
+	ttl := utils.AccessTokenTTL
+	if claims.ExpiresAt != nil {
+		ttl = time.Until(claims.ExpiresAt.Time)
+	}
+	if ttl > 0 {
+		if err := h.Tokens.BlacklistToken(r.Context(), tokenString, ttl); err != nil {
+			log.Printf("ERROR REDIS BLACKLIST: %v", err)
+		}
+	}
+
+	h.clearRefreshCookie(w)
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Logout Success!"})
+}
+
+// ==========================================
+// HELPER COOKIE REFRESH TOKEN
+// ==========================================
+// setRefreshCookie menyimpan refresh token (HttpOnly, hanya untuk path /refresh) beserta
+// CSRF token baru yang wajib dikirim ulang lewat header X-CSRF-Token ke /refresh dan /logout.
+// CSRF token juga dikirim di header respons untuk frontend yang beda domain.
+func (h *Handler) setRefreshCookie(w http.ResponseWriter, refreshToken string) error {
+	csrfToken, err := utils.RandomHex(32)
+	if err != nil {
+		return err
 	}
+	expires := time.Now().Add(utils.RefreshTokenTTL)
 
 	http.SetCookie(w, &http.Cookie{
 		Name:     "refresh_token",
-		Value:    "",
-		Path:     "/",
-		Expires:  time.Unix(0, 0),
-		MaxAge:   -1,
+		Value:    refreshToken,
+		Expires:  expires,
 		HttpOnly: true,
-		SameSite: http.SameSiteLaxMode,
+		Secure:   h.cfg.Cookie.Secure,
+		Domain:   h.cfg.Cookie.Domain,
+		Path:     h.cfg.Cookie.RefreshPath,
+		SameSite: h.cfg.Cookie.SameSite,
 	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     middleware.CSRFCookieName,
+		Value:    csrfToken,
+		Expires:  expires,
+		Secure:   h.cfg.Cookie.Secure,
+		Domain:   h.cfg.Cookie.Domain,
+		Path:     "/",
+		SameSite: h.cfg.Cookie.SameSite,
+	})
+	w.Header().Set(middleware.CSRFHeader, csrfToken)
+	return nil
+}
 
-	w.Header().Set(utils.ContentHeader, utils.Mime)
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Logout Success!"})
+func (h *Handler) clearRefreshCookie(w http.ResponseWriter) {
+	for _, c := range []struct {
+		name, path string
+		httpOnly   bool
+	}{
+		{"refresh_token", h.cfg.Cookie.RefreshPath, true},
+		{middleware.CSRFCookieName, "/", false},
+	} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     c.name,
+			Value:    "",
+			Path:     c.path,
+			Domain:   h.cfg.Cookie.Domain,
+			Expires:  time.Unix(0, 0),
+			MaxAge:   -1,
+			HttpOnly: c.httpOnly,
+			Secure:   h.cfg.Cookie.Secure,
+			SameSite: h.cfg.Cookie.SameSite,
+		})
+	}
 }
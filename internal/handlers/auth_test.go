@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"go-sis-be/internal/configs"
+)
+
+func TestLoginFailureDelay(t *testing.T) {
+	cfg := configs.LoginLockoutConfig{
+		BaseDelay: 250 * time.Millisecond,
+		MaxDelay:  2 * time.Second,
+	}
+
+	tests := []struct {
+		fails int64
+		want  time.Duration
+	}{
+		{0, 0},
+		{1, 250 * time.Millisecond},
+		{2, 500 * time.Millisecond},
+		{3, time.Second},
+		{4, 2 * time.Second},
+		{10, 2 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := loginFailureDelay(tt.fails, cfg); got != tt.want {
+			t.Errorf("loginFailureDelay(%d) = %v, want %v", tt.fails, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"go-sis-be/internal/audit"
+	"go-sis-be/internal/configs"
+	"go-sis-be/internal/mailer"
+	"go-sis-be/internal/models"
+	"go-sis-be/internal/oidc"
+	"go-sis-be/internal/policy"
+	"go-sis-be/internal/utils"
+)
+
+// Deps: Dependensi penyimpanan yang dipakai handler. Produksi memakai implementasi
+// Postgres/Redis dari package models, test memakai fake in-memory (models/memory).
+type Deps struct {
+	Users         models.UserRepository
+	Profiles      models.ProfileRepository
+	Sessions      models.SessionStore
+	Tokens        models.TokenStore
+	LoginAttempts models.LoginAttemptStore
+	TwoFactor     models.TwoFactorStore
+	Audit         models.AuditStore
+	Relations     policy.Relations // Relasi wali-murid & guru-murid untuk policy profil
+
+	Passwords       models.PasswordStore
+	ResetTokens     models.ResetTokenStore
+	OIDC            models.OIDCStore
+	ServiceAccounts models.ServiceAccountStore
+	Auth            models.AuthStore // Dipakai AuthMiddleware, bukan oleh handler
+
+	Mailer        mailer.Sender // Kanal email reset password
+	OIDCProviders oidc.Registry // Identity provider eksternal yang aktif
+}
+
+// Config: Pengaturan perilaku handler, diambil dari configs.Config lewat NewConfig
+type Config struct {
+	Cookie           configs.CookieConfig
+	LoginLockout     configs.LoginLockoutConfig
+	TwoFactor        configs.TwoFactorConfig
+	PasswordHash     utils.PasswordHashConfig // Untuk password sementara dan rehash saat login
+	PasswordResetURL string                   // Prefix link reset password di email. Kosong = hanya token.
+}
+
+// NewConfig mengambil pengaturan handler dari cfg
+func NewConfig(cfg *configs.Config) Config {
+	return Config{
+		Cookie:           cfg.Cookie,
+		LoginLockout:     cfg.LoginLockout,
+		TwoFactor:        cfg.TwoFactor,
+		PasswordHash:     cfg.PasswordHash,
+		PasswordResetURL: cfg.PasswordResetURL,
+	}
+}
+
+// Handler: Kumpulan HTTP handler API. Dibuat sekali saat startup lewat New lalu dipasang di router.
+type Handler struct {
+	Deps
+	cfg      Config
+	recorder *audit.Recorder
+}
+
+// New membuat Handler dengan dependensi deps dan pengaturan cfg
+func New(deps Deps, cfg Config) *Handler {
+	return &Handler{
+		Deps:     deps,
+		cfg:      cfg,
+		recorder: audit.NewRecorder(deps.Audit),
+	}
+}
+
+// PostgresDeps: Dependensi produksi (configs.DB & configs.RedisClient harus sudah terhubung)
+func PostgresDeps(cfg *configs.Config) Deps {
+	passwords := models.PasswordConfig{Policy: cfg.PasswordPolicy, Hash: cfg.PasswordHash}
+	timeout := models.QueryTimeout{Timeout: cfg.Database.QueryTimeout}
+	return Deps{
+		Users:         models.PostgresUsers{QueryTimeout: timeout, Passwords: passwords},
+		Profiles:      models.PostgresProfiles{QueryTimeout: timeout, Passwords: passwords},
+		Sessions:      models.PostgresSessions{QueryTimeout: timeout},
+		Tokens:        models.RedisTokens{QueryTimeout: timeout, Blacklist: cfg.TokenBlacklist},
+		LoginAttempts: models.RedisLoginAttempts{},
+		TwoFactor:     models.PostgresTwoFactor{QueryTimeout: timeout},
+		Audit:         models.PostgresAudit{QueryTimeout: timeout},
+		Relations:     policy.DBRelations{Timeout: cfg.Database.QueryTimeout},
+
+		Passwords:       models.PostgresPasswords{QueryTimeout: timeout, Passwords: passwords},
+		ResetTokens:     models.RedisResetTokens{QueryTimeout: timeout},
+		OIDC:            models.PostgresOIDC{QueryTimeout: timeout},
+		ServiceAccounts: models.PostgresServiceAccounts{QueryTimeout: timeout},
+		Auth:            models.RedisAuth{QueryTimeout: timeout, Blacklist: cfg.TokenBlacklist},
+
+		Mailer:        mailer.New(cfg.Mail),
+		OIDCProviders: oidc.NewRegistry(cfg.OIDC),
+	}
+}
@@ -0,0 +1,794 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-sis-be/internal/audit"
+	"go-sis-be/internal/configs"
+	"go-sis-be/internal/mailer"
+	"go-sis-be/internal/models"
+	"go-sis-be/internal/models/memory"
+	"go-sis-be/internal/oidc"
+	"go-sis-be/internal/utils"
+	"go-sis-be/middleware"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+const testPassword = "kopi-susu-2024"
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "handlers-test")
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "test.pem")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		log.Fatal(err)
+	}
+	if err := utils.InitJWTKeys(utils.JWTKeyConfig{PrivateKeyFile: keyFile, RefreshSecret: "rahasia-refresh-untuk-test"}); err != nil {
+		log.Fatal(err)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// testConfig: Pengaturan handler untuk test. Delay gagal login dibuat sangat kecil agar test tidak lambat.
+func testConfig() Config {
+	cfg := NewConfig(configs.Default())
+	cfg.LoginLockout = configs.LoginLockoutConfig{
+		MaxUserAttempts: 3,
+		MaxIPAttempts:   100,
+		Window:          time.Minute,
+		LockDuration:    time.Minute,
+		BaseDelay:       time.Millisecond,
+		MaxDelay:        time.Millisecond,
+	}
+	cfg.TwoFactor = configs.TwoFactorConfig{}
+	return cfg
+}
+
+// newTestHandler membuat Handler yang seluruh dependensinya fake in-memory
+func newTestHandler() (*Handler, *memory.Store) {
+	store := memory.NewStore()
+	return New(Deps{
+		Users:           store,
+		Profiles:        store,
+		Sessions:        store,
+		Tokens:          store,
+		LoginAttempts:   store,
+		TwoFactor:       store,
+		Audit:           store,
+		Relations:       store,
+		Passwords:       store,
+		ResetTokens:     store,
+		OIDC:            store,
+		ServiceAccounts: store,
+		Auth:            store,
+		Mailer:          mailer.LogSender{},
+		OIDCProviders:   oidc.Registry{},
+	}, testConfig()), store
+}
+
+type requestOption func(*http.Request) *http.Request
+
+// asUser menaruh klaim JWT di context seperti yang dilakukan AuthMiddleware
+func asUser(claims *utils.JWTClaims) requestOption {
+	return func(r *http.Request) *http.Request {
+		return r.WithContext(context.WithValue(r.Context(), middleware.UserInfoKey, claims))
+	}
+}
+
+func withVars(vars map[string]string) requestOption {
+	return func(r *http.Request) *http.Request { return mux.SetURLVars(r, vars) }
+}
+
+func withCookie(c *http.Cookie) requestOption {
+	return func(r *http.Request) *http.Request {
+		r.AddCookie(c)
+		return r
+	}
+}
+
+func withBearer(token string) requestOption {
+	return func(r *http.Request) *http.Request {
+		r.Header.Set("Authorization", "Bearer "+token)
+		return r
+	}
+}
+
+// serve menjalankan handler dengan body di-encode sebagai JSON
+func serve(t *testing.T, handler http.HandlerFunc, method string, body interface{}, opts ...requestOption) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := httptest.NewRequest(method, "/", &buf)
+	for _, opt := range opts {
+		r = opt(r)
+	}
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	var out map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("respons bukan JSON (%d): %s", w.Code, w.Body.String())
+	}
+	return out
+}
+
+func findCookie(w *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, c := range w.Result().Cookies() {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func basePerson(username, nik string, roleID int) models.RegisterBaseRequest {
+	return models.RegisterBaseRequest{
+		Username:      username,
+		Password:      testPassword,
+		RoleID:        roleID,
+		FullName:      "Siti Rahmawati",
+		BirthDate:     "1990-04-12",
+		NIK:           nik,
+		Gender:        models.GenderFemale,
+		Religion:      models.ReligionIslam,
+		MaritalStatus: models.MaritalMarried,
+		Address:       "Jl. Merdeka No. 1, Bandung",
+	}
+}
+
+func studentRequest(username, nik, nisn string) models.RegisterStudentRequest {
+	req := models.RegisterStudentRequest{RegisterBaseRequest: basePerson(username, nik, 0)}
+	req.MaritalStatus = models.MaritalSingle
+	req.NISN = nisn
+	req.FamilyStatus = models.FamilyKandung
+	req.FatherJob = models.JobWiraswasta
+	req.MotherJob = models.JobIRT
+	req.ReceivedDate = "2025-07-14"
+	return req
+}
+
+// login menjalankan POST /login dan mengembalikan respons beserta klaim access token-nya
+func login(t *testing.T, h *Handler, username, password string) (*httptest.ResponseRecorder, *utils.JWTClaims) {
+	t.Helper()
+	w := serve(t, h.LoginHandler, http.MethodPost, LoginRequest{Username: username, Pass: password})
+	if w.Code != http.StatusOK {
+		return w, nil
+	}
+	token, _ := decodeBody(t, w)["access_token"].(string)
+	claims, err := utils.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("access token tidak valid: %v", err)
+	}
+	return w, claims
+}
+
+func TestLogin(t *testing.T) {
+	h, store := newTestHandler()
+	admin := basePerson("admin.tu", "3273015204900001", models.ADMIN_ROLE_ID)
+	if _, err := store.RegisterBaseUser(context.Background(), &admin); err != nil {
+		t.Fatal(err)
+	}
+
+	w, claims := login(t, h, "admin.tu", testPassword)
+	if w.Code != http.StatusOK {
+		t.Fatalf("login valid harus 200, dapat %d: %s", w.Code, w.Body.String())
+	}
+	if claims.Username != "admin.tu" || claims.Role != models.ADMIN_ROLE_NAME || claims.SessionID == "" {
+		t.Errorf("klaim salah: %+v", claims)
+	}
+	if c := findCookie(w, "refresh_token"); c == nil || !c.HttpOnly {
+		t.Error("refresh token harus dikirim sebagai cookie HttpOnly")
+	}
+	if w.Header().Get(middleware.CSRFHeader) == "" {
+		t.Error("CSRF token harus dikirim di header respons")
+	}
+	if sessions, _ := store.ListActiveSessions(context.Background(), claims.UID); len(sessions) != 1 {
+		t.Errorf("login harus membuka satu sesi, ada %d", len(sessions))
+	}
+
+	if w, _ := login(t, h, "admin.tu", "salah-password-1"); w.Code != http.StatusUnauthorized {
+		t.Errorf("password salah harus 401, dapat %d", w.Code)
+	}
+	if w, _ := login(t, h, "tidak.ada", testPassword); w.Code != http.StatusUnauthorized {
+		t.Errorf("username tidak dikenal harus 401, dapat %d", w.Code)
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	h, store := newTestHandler()
+	admin := basePerson("admin.tu", "3273015204900001", models.ADMIN_ROLE_ID)
+	if _, err := store.RegisterBaseUser(context.Background(), &admin); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		login(t, h, "admin.tu", "salah-password-1")
+	}
+	w, _ := login(t, h, "admin.tu", testPassword)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("username terkunci harus 429 dengan Retry-After, dapat %d", w.Code)
+	}
+
+	events := store.AuthEvents()
+	if len(events) != 1 || events[0].EventType != models.AuthEventLoginLocked {
+		t.Errorf("penguncian harus dicatat sebagai auth event: %+v", events)
+	}
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	h, store := newTestHandler()
+	admin := basePerson("admin.tu", "3273015204900001", models.ADMIN_ROLE_ID)
+	if _, err := store.RegisterBaseUser(context.Background(), &admin); err != nil {
+		t.Fatal(err)
+	}
+	lw, claims := login(t, h, "admin.tu", testPassword)
+	oldCookie := findCookie(lw, "refresh_token")
+
+	w := serve(t, h.RefreshTokenHandler, http.MethodPost, nil, withCookie(oldCookie))
+	if w.Code != http.StatusOK {
+		t.Fatalf("refresh harus 200, dapat %d: %s", w.Code, w.Body.String())
+	}
+	newCookie := findCookie(w, "refresh_token")
+	if newCookie == nil || newCookie.Value == oldCookie.Value {
+		t.Fatal("refresh harus merotasi refresh token")
+	}
+	if token, _ := decodeBody(t, w)["access_token"].(string); token == "" {
+		t.Error("refresh harus menerbitkan access token baru")
+	}
+
+	// Token lama dipakai lagi: dianggap dicuri, seluruh sesi dicabut
+	if w := serve(t, h.RefreshTokenHandler, http.MethodPost, nil, withCookie(oldCookie)); w.Code != http.StatusUnauthorized {
+		t.Fatalf("refresh token lama harus 401, dapat %d", w.Code)
+	}
+	if sess, _ := store.GetSession(context.Background(), claims.SessionID); sess == nil || sess.RevokedAt == nil {
+		t.Error("pemakaian ulang refresh token harus mencabut sesi")
+	}
+	if w := serve(t, h.RefreshTokenHandler, http.MethodPost, nil, withCookie(newCookie)); w.Code != http.StatusUnauthorized {
+		t.Errorf("token pengganti di family yang dicabut harus 401, dapat %d", w.Code)
+	}
+
+	if w := serve(t, h.RefreshTokenHandler, http.MethodPost, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("tanpa cookie harus 401, dapat %d", w.Code)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	h, store := newTestHandler()
+	admin := basePerson("admin.tu", "3273015204900001", models.ADMIN_ROLE_ID)
+	if _, err := store.RegisterBaseUser(context.Background(), &admin); err != nil {
+		t.Fatal(err)
+	}
+	lw, claims := login(t, h, "admin.tu", testPassword)
+	accessToken := decodeBody(t, lw)["access_token"].(string)
+
+	w := serve(t, h.LogoutHandler, http.MethodPost, nil, asUser(claims), withBearer(accessToken))
+	if w.Code != http.StatusOK {
+		t.Fatalf("logout harus 200, dapat %d: %s", w.Code, w.Body.String())
+	}
+	if !store.IsTokenBlacklisted(context.Background(), accessToken) {
+		t.Error("access token harus di-blacklist setelah logout")
+	}
+	if !store.IsSessionRevoked(context.Background(), claims.SessionID) {
+		t.Error("sesi harus dicabut setelah logout")
+	}
+	if c := findCookie(w, "refresh_token"); c == nil || c.MaxAge >= 0 {
+		t.Error("cookie refresh token harus dihapus")
+	}
+
+	// Refresh token dari sesi yang sudah logout tidak bisa dipakai lagi
+	if w := serve(t, h.RefreshTokenHandler, http.MethodPost, nil, withCookie(findCookie(lw, "refresh_token"))); w.Code != http.StatusUnauthorized {
+		t.Errorf("refresh setelah logout harus 401, dapat %d", w.Code)
+	}
+
+	if w := serve(t, h.LogoutHandler, http.MethodPost, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("logout tanpa klaim harus 401, dapat %d", w.Code)
+	}
+}
+
+func TestRegistration(t *testing.T) {
+	h, store := newTestHandler()
+
+	student := studentRequest("budi.santoso", "3273011503100002", "0101234567")
+	w := serve(t, h.HandleStudentRegistration, http.MethodPost, student)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("registrasi murid harus 201, dapat %d: %s", w.Code, w.Body.String())
+	}
+	uid, _ := decodeBody(t, w)["uid"].(string)
+	if role, err := store.GetRoleIDByUID(context.Background(), uid); err != nil || role != models.STUDENT_ROLE_ID {
+		t.Errorf("role murid harus di-set handler, dapat %d (%v)", role, err)
+	}
+	if logs, _, _ := store.ListAuditLogs(context.Background(), models.AuditFilter{Action: audit.ActionStudentRegister, Page: 1, Limit: 10}); len(logs) != 1 {
+		t.Error("registrasi murid harus tercatat di audit log")
+	}
+	if w, _ := login(t, h, "budi.santoso", testPassword); w.Code != http.StatusOK {
+		t.Errorf("murid hasil registrasi harus bisa login, dapat %d", w.Code)
+	}
+
+	teacher := models.RegisterTeacherRequest{RegisterBaseRequest: basePerson("bu.siti", "3273015204900003", 0)}
+	teacher.EmploymentStatus = models.StatusPNS
+	teacher.FunctionalPosition = models.EmploymentGuruMatPel
+	teacher.LastEducation = models.EduS1
+	teacher.University = "Universitas Pendidikan Indonesia"
+	if w := serve(t, h.HandleTeacherRegistration, http.MethodPost, teacher); w.Code != http.StatusCreated {
+		t.Errorf("registrasi guru harus 201, dapat %d: %s", w.Code, w.Body.String())
+	}
+
+	parent := basePerson("pak.ahmad", "3273010101800004", 0)
+	if w := serve(t, h.HandleParentRegistration, http.MethodPost, parent); w.Code != http.StatusCreated {
+		t.Errorf("registrasi wali harus 201, dapat %d: %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		name string
+		req  models.RegisterStudentRequest
+		code int
+	}{
+		{"gender tidak valid", func() models.RegisterStudentRequest {
+			r := studentRequest("murid.a", "3273011503100005", "0101234568")
+			r.Gender = "L"
+			return r
+		}(), http.StatusBadRequest},
+		{"NISN kosong", studentRequest("murid.b", "3273011503100006", ""), http.StatusBadRequest},
+		{"password lemah", func() models.RegisterStudentRequest {
+			r := studentRequest("murid.c", "3273011503100007", "0101234569")
+			r.Password = "pendek"
+			return r
+		}(), http.StatusBadRequest},
+		{"NIK duplikat", studentRequest("murid.d", "3273011503100002", "0101234570"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, h.HandleStudentRegistration, http.MethodPost, tt.req)
+			if w.Code != tt.code {
+				t.Errorf("status %d, harusnya %d: %s", w.Code, tt.code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestProfileCRUD(t *testing.T) {
+	h, store := newTestHandler()
+
+	student := studentRequest("budi.santoso", "3273011503100002", "0101234567")
+	student.RoleID = models.STUDENT_ROLE_ID
+	resp, err := store.RegisterStudent(context.Background(), &student)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := studentRequest("ani.lestari", "3273014708100003", "0101234568")
+	other.RoleID = models.STUDENT_ROLE_ID
+	otherResp, err := store.RegisterStudent(context.Background(), &other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent := basePerson("pak.ahmad", "3273010101800004", models.PARENT_ROLE_ID)
+	parentResp, err := store.RegisterBaseUser(context.Background(), &parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.LinkParent(parentResp.UID, resp.UID)
+
+	admin := &utils.JWTClaims{UID: "00000000-0000-4000-8000-999999999999", Role: models.ADMIN_ROLE_NAME}
+	self := &utils.JWTClaims{UID: resp.UID, Role: models.STUDENT_ROLE_NAME}
+	target := withVars(map[string]string{"uid": resp.UID})
+
+	// Read
+	w := serve(t, h.HandleGetUserDetail, http.MethodGet, nil, target, asUser(admin))
+	if w.Code != http.StatusOK || decodeBody(t, w)["nisn"] != "0101234567" {
+		t.Fatalf("admin harus bisa membaca profil murid, dapat %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(t, h.HandleGetUserDetail, http.MethodGet, nil, target, asUser(&utils.JWTClaims{UID: parentResp.UID, Role: models.PARENT_ROLE_NAME})); w.Code != http.StatusOK {
+		t.Errorf("wali harus bisa membaca profil anaknya, dapat %d", w.Code)
+	}
+	if w := serve(t, h.HandleGetUserDetail, http.MethodGet, nil, target, asUser(&utils.JWTClaims{UID: otherResp.UID, Role: models.STUDENT_ROLE_NAME})); w.Code != http.StatusForbidden {
+		t.Errorf("murid lain harus 403, dapat %d", w.Code)
+	}
+
+	// Update oleh pemilik profil
+	edit := models.EditStudentRequest{
+		FullName:      "Budi Santoso Putra",
+		BirthDate:     student.BirthDate,
+		Religion:      student.Religion,
+		MaritalStatus: student.MaritalStatus,
+		Address:       "Jl. Asia Afrika No. 8, Bandung",
+		NISN:          student.NISN,
+		ReceivedDate:  student.ReceivedDate,
+	}
+	if w := serve(t, h.HandleEditProfile, http.MethodPut, edit, target, asUser(self)); w.Code != http.StatusOK {
+		t.Fatalf("murid harus bisa mengedit profilnya sendiri, dapat %d: %s", w.Code, w.Body.String())
+	}
+	profile, _ := store.GetProfileAndFormat(context.Background(), resp.UID)
+	if p := profile.(models.StudentProfileResponse); p.FullName != edit.FullName || p.Address != edit.Address {
+		t.Errorf("profil tidak berubah: %+v", p)
+	}
+	logs, _, _ := store.ListAuditLogs(context.Background(), models.AuditFilter{Action: audit.ActionProfileUpdate, Page: 1, Limit: 10})
+	if len(logs) != 1 || logs[0].ActorUID != resp.UID || len(logs[0].Changes) == 0 {
+		t.Errorf("edit profil harus tercatat di audit log beserta diff-nya: %+v", logs)
+	}
+	if w := serve(t, h.HandleEditProfile, http.MethodPut, edit, target, asUser(&utils.JWTClaims{UID: parentResp.UID, Role: models.PARENT_ROLE_NAME})); w.Code != http.StatusForbidden {
+		t.Errorf("wali tidak boleh mengedit profil anak, dapat %d", w.Code)
+	}
+
+	// Delete
+	if w := serve(t, h.HandleDeleteProfile, http.MethodDelete, nil, target, asUser(admin)); w.Code != http.StatusOK {
+		t.Fatalf("hapus profil harus 200, dapat %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(t, h.HandleGetUserDetail, http.MethodGet, nil, target, asUser(admin)); w.Code != http.StatusNotFound {
+		t.Errorf("profil yang sudah dihapus harus 404, dapat %d", w.Code)
+	}
+	if w := serve(t, h.HandleDeleteProfile, http.MethodDelete, nil, target, asUser(admin)); w.Code != http.StatusNotFound {
+		t.Errorf("hapus ulang harus 404, dapat %d", w.Code)
+	}
+
+	// Role di luar guru/murid tidak bisa diedit lewat endpoint ini
+	parentTarget := withVars(map[string]string{"uid": parentResp.UID})
+	if w := serve(t, h.HandleDeleteProfile, http.MethodDelete, nil, parentTarget, asUser(admin)); w.Code != http.StatusForbidden {
+		t.Errorf("hapus profil wali harus 403, dapat %d", w.Code)
+	}
+}
+
+func TestServiceAccountProfileAccess(t *testing.T) {
+	h, store := newTestHandler()
+	student := studentRequest("budi.santoso", "3273011503100002", "0101234567")
+	student.RoleID = models.STUDENT_ROLE_ID
+	resp, err := store.RegisterStudent(context.Background(), &student)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Principal API key, seolah scope-nya lolos RBAC untuk ketiga verb
+	service := &utils.JWTClaims{
+		UID:    "00000000-0000-4000-8000-5a0000000001",
+		Role:   models.SERVICE_ROLE_NAME,
+		Scopes: []string{"users:read", "users:update", "users:delete"},
+	}
+	target := withVars(map[string]string{"uid": resp.UID})
+
+	if w := serve(t, h.HandleGetUserDetail, http.MethodGet, nil, target, asUser(service)); w.Code != http.StatusOK {
+		t.Errorf("service account dengan users:read harus bisa membaca profil, dapat %d: %s", w.Code, w.Body.String())
+	}
+	edit := models.EditStudentRequest{FullName: "Diubah Integrasi", NISN: student.NISN}
+	if w := serve(t, h.HandleEditProfile, http.MethodPut, edit, target, asUser(service)); w.Code != http.StatusForbidden {
+		t.Errorf("service account tidak boleh mengedit profil, dapat %d", w.Code)
+	}
+	if w := serve(t, h.HandleDeleteProfile, http.MethodDelete, nil, target, asUser(service)); w.Code != http.StatusForbidden {
+		t.Errorf("service account tidak boleh menghapus profil, dapat %d", w.Code)
+	}
+	if _, err := store.GetProfileAndFormat(context.Background(), resp.UID); err != nil {
+		t.Errorf("profil tidak boleh berubah/terhapus: %v", err)
+	}
+}
+
+// stalledProfiles: ProfileRepository yang macet sampai context habis, seperti query yang tertahan lock
+type stalledProfiles struct{ *memory.Store }
+
+func (stalledProfiles) GetProfileAndFormat(ctx context.Context, uid string) (interface{}, error) {
+	<-ctx.Done()
+	return nil, fmt.Errorf("gagal mengambil profil: %w", ctx.Err())
+}
+
+// canceledProfiles: ProfileRepository yang query-nya dibatalkan server (57014 query_canceled)
+type canceledProfiles struct{ *memory.Store }
+
+func (canceledProfiles) GetProfileAndFormat(ctx context.Context, uid string) (interface{}, error) {
+	return nil, fmt.Errorf("gagal mengambil profil: %w", &pq.Error{Code: "57014", Message: "canceling statement due to user request"})
+}
+
+// withContext menjalankan request dengan context ctx (deadline/cancel dari sisi client)
+func withContext(ctx context.Context) requestOption {
+	return func(r *http.Request) *http.Request { return r.WithContext(ctx) }
+}
+
+func TestProfileQueryTimeout(t *testing.T) {
+	h, store := newTestHandler()
+	h.Profiles = stalledProfiles{store}
+	admin := &utils.JWTClaims{UID: "00000000-0000-4000-8000-999999999999", Role: models.ADMIN_ROLE_NAME}
+	target := withVars(map[string]string{"uid": "00000000-0000-4000-8000-000000000001"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	w := serve(t, h.HandleGetUserDetail, http.MethodGet, nil, withContext(ctx), target, asUser(admin))
+	if w.Code != http.StatusGatewayTimeout || decodeBody(t, w)["error"] != utils.ErrMsgTimeout {
+		t.Errorf("query melewati deadline harus 504, dapat %d: %s", w.Code, w.Body.String())
+	}
+
+	// Client sudah memutus koneksi: tidak ada respons yang ditulis
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	w = serve(t, h.HandleGetUserDetail, http.MethodGet, nil, withContext(ctx), target, asUser(admin))
+	if w.Body.Len() != 0 {
+		t.Errorf("request yang dibatalkan tidak boleh dijawab, dapat %d: %s", w.Code, w.Body.String())
+	}
+
+	// lib/pq membatalkan query di server dan mengembalikan 57014, bukan context.DeadlineExceeded
+	h.Profiles = canceledProfiles{store}
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	w = serve(t, h.HandleGetUserDetail, http.MethodGet, nil, withContext(ctx), target, asUser(admin))
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("query_canceled karena deadline harus 504, dapat %d: %s", w.Code, w.Body.String())
+	}
+
+	// Kode 57014 yang sama saat client membatalkan request bukan timeout
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	w = serve(t, h.HandleGetUserDetail, http.MethodGet, nil, withContext(ctx), target, asUser(admin))
+	if w.Body.Len() != 0 {
+		t.Errorf("query_canceled karena client batal tidak boleh dijawab, dapat %d: %s", w.Code, w.Body.String())
+	}
+
+	// Tanpa deadline yang terlewati (mis. pg_cancel_backend) bukan 504
+	w = serve(t, h.HandleGetUserDetail, http.MethodGet, nil, target, asUser(admin))
+	if w.Code == http.StatusGatewayTimeout {
+		t.Errorf("query_canceled tanpa deadline tidak boleh 504, dapat %d", w.Code)
+	}
+}
+
+func TestChangeMyPassword(t *testing.T) {
+	h, store := newTestHandler()
+	admin := basePerson("admin.tu", "3273015204900001", models.ADMIN_ROLE_ID)
+	if _, err := store.RegisterBaseUser(context.Background(), &admin); err != nil {
+		t.Fatal(err)
+	}
+	lw, claims := login(t, h, "admin.tu", testPassword)
+	accessToken := decodeBody(t, lw)["access_token"].(string)
+
+	w := serve(t, h.HandleChangeMyPassword, http.MethodPut,
+		ChangePasswordRequest{OldPassword: "salah-password-1", NewPassword: "teh-manis-2025"}, asUser(claims))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("password lama salah harus 401, dapat %d", w.Code)
+	}
+
+	w = serve(t, h.HandleChangeMyPassword, http.MethodPut,
+		ChangePasswordRequest{OldPassword: testPassword, NewPassword: "teh-manis-2025"}, asUser(claims), withBearer(accessToken))
+	if w.Code != http.StatusOK {
+		t.Fatalf("ganti password harus 200, dapat %d: %s", w.Code, w.Body.String())
+	}
+	if !store.IsTokenBlacklisted(context.Background(), accessToken) {
+		t.Error("access token yang dipakai harus di-blacklist")
+	}
+	if w, _ := login(t, h, "admin.tu", testPassword); w.Code != http.StatusUnauthorized {
+		t.Errorf("password lama tidak boleh bisa dipakai login, dapat %d", w.Code)
+	}
+	if w, _ := login(t, h, "admin.tu", "teh-manis-2025"); w.Code != http.StatusOK {
+		t.Errorf("password baru harus bisa dipakai login, dapat %d", w.Code)
+	}
+}
+
+func TestTwoFactorSetupAndVerify(t *testing.T) {
+	h, store := newTestHandler()
+	admin := basePerson("admin.tu", "3273015204900001", models.ADMIN_ROLE_ID)
+	if _, err := store.RegisterBaseUser(context.Background(), &admin); err != nil {
+		t.Fatal(err)
+	}
+	_, claims := login(t, h, "admin.tu", testPassword)
+
+	if w := serve(t, h.HandleTwoFactorVerify, http.MethodPost, TwoFactorVerifyRequest{Code: "000000"}, asUser(claims)); w.Code != http.StatusBadRequest {
+		t.Fatalf("verify sebelum setup harus 400, dapat %d", w.Code)
+	}
+
+	w := serve(t, h.HandleTwoFactorSetup, http.MethodPost, nil, asUser(claims))
+	if w.Code != http.StatusOK {
+		t.Fatalf("setup 2FA harus 200, dapat %d: %s", w.Code, w.Body.String())
+	}
+	secret := decodeBody(t, w)["secret"].(string)
+	code, err := utils.TOTPCode(secret, utils.TOTPStep(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w = serve(t, h.HandleTwoFactorVerify, http.MethodPost, TwoFactorVerifyRequest{Code: code}, asUser(claims))
+	if w.Code != http.StatusOK {
+		t.Fatalf("verify 2FA harus 200, dapat %d: %s", w.Code, w.Body.String())
+	}
+	if codes, _ := decodeBody(t, w)["recovery_codes"].([]interface{}); len(codes) != recoveryCodeCount {
+		t.Errorf("harus ada %d recovery code, dapat %d", recoveryCodeCount, len(codes))
+	}
+	if enabled, _ := store.IsTwoFactorEnabled(context.Background(), claims.UID); !enabled {
+		t.Error("2FA harus aktif setelah verify")
+	}
+	if w := serve(t, h.HandleTwoFactorSetup, http.MethodPost, nil, asUser(claims)); w.Code != http.StatusConflict {
+		t.Errorf("setup ulang saat 2FA aktif harus 409, dapat %d", w.Code)
+	}
+}
+
+func TestTwoFactorLoginLockout(t *testing.T) {
+	h, store := newTestHandler()
+	admin := basePerson("admin.tu", "3273015204900001", models.ADMIN_ROLE_ID)
+	resp, err := store.RegisterBaseUser(context.Background(), &admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.SetTwoFactorEnabled(resp.UID, true)
+
+	// Pemegang password login ulang tiap kali untuk challenge baru, lalu menebak kode
+	for i := 0; i < testConfig().LoginLockout.MaxUserAttempts; i++ {
+		w := serve(t, h.LoginHandler, http.MethodPost, LoginRequest{Username: "admin.tu", Pass: testPassword})
+		if w.Code != http.StatusOK {
+			t.Fatalf("percobaan %d: login password harus 200, dapat %d", i+1, w.Code)
+		}
+		challenge, _ := decodeBody(t, w)["challenge_token"].(string)
+		w = serve(t, h.HandleTwoFactorLogin, http.MethodPost, TwoFactorLoginRequest{ChallengeToken: challenge, Code: "000000"})
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("percobaan %d: kode salah harus 401, dapat %d", i+1, w.Code)
+		}
+	}
+
+	w := serve(t, h.LoginHandler, http.MethodPost, LoginRequest{Username: "admin.tu", Pass: testPassword})
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("kode 2FA salah berulang harus mengunci username, dapat %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestOIDCCallbackRequiresStateCookie(t *testing.T) {
+	h, store := newTestHandler()
+	h.OIDCProviders = oidc.NewRegistry([]configs.OIDCProviderConfig{{Name: "google"}})
+	state := "state-milik-penyerang"
+	if err := store.SaveOIDCState(context.Background(), state, models.OIDCState{Provider: "google", LinkUID: "uid-penyerang"}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	callback := func(opts ...requestOption) *httptest.ResponseRecorder {
+		opts = append(opts, withVars(map[string]string{"provider": "google"}), func(r *http.Request) *http.Request {
+			r.URL.RawQuery = "state=" + state + "&code=kode-dari-idp"
+			return r
+		})
+		return serve(t, h.HandleOIDCCallback, http.MethodGet, nil, opts...)
+	}
+
+	// Korban menyelesaikan alur yang dimulai penyerang: browser korban tidak punya cookie state
+	if w := callback(); w.Code != http.StatusBadRequest {
+		t.Errorf("callback tanpa cookie state harus 400, dapat %d", w.Code)
+	}
+	if w := callback(withCookie(&http.Cookie{Name: oidcStateCookie, Value: hashOIDCState("state-lain")})); w.Code != http.StatusBadRequest {
+		t.Errorf("callback dengan cookie state lain harus 400, dapat %d", w.Code)
+	}
+
+	// Browser yang memulai alur lolos cek state lalu lanjut ke IdP (di sini gagal karena IdP fiktif)
+	w := callback(withCookie(&http.Cookie{Name: oidcStateCookie, Value: hashOIDCState(state)}))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("callback dengan cookie state yang cocok harus lolos cek state, dapat %d: %s", w.Code, w.Body.String())
+	}
+	if st, _ := store.ConsumeOIDCState(context.Background(), state); st != nil {
+		t.Error("state harus sudah dikonsumsi oleh callback yang sah")
+	}
+}
+
+func TestAuthMiddlewareUsesStore(t *testing.T) {
+	h, store := newTestHandler()
+	admin := basePerson("admin.tu", "3273015204900001", models.ADMIN_ROLE_ID)
+	if _, err := store.RegisterBaseUser(context.Background(), &admin); err != nil {
+		t.Fatal(err)
+	}
+	lw, claims := login(t, h, "admin.tu", testPassword)
+	accessToken := decodeBody(t, lw)["access_token"].(string)
+
+	protected := middleware.AuthMiddleware(store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	call := func() int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer "+accessToken)
+		w := httptest.NewRecorder()
+		protected.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := call(); code != http.StatusNoContent {
+		t.Fatalf("token valid harus lolos, dapat %d", code)
+	}
+	if err := store.RevokeSession(context.Background(), claims.UID, claims.SessionID); err != nil {
+		t.Fatal(err)
+	}
+	if code := call(); code != http.StatusUnauthorized {
+		t.Errorf("token dari sesi yang dicabut harus 401, dapat %d", code)
+	}
+}
+
+func TestRoleChangeRevokesTokens(t *testing.T) {
+	h, store := newTestHandler()
+	adminReq := basePerson("admin.tu", "3273015204900001", models.ADMIN_ROLE_ID)
+	resp, err := store.RegisterBaseUser(context.Background(), &adminReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lw, _ := login(t, h, "admin.tu", testPassword)
+	accessToken := decodeBody(t, lw)["access_token"].(string)
+
+	protected := middleware.AuthMiddleware(store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	call := func() int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer "+accessToken)
+		w := httptest.NewRecorder()
+		protected.ServeHTTP(w, r)
+		return w.Code
+	}
+	if code := call(); code != http.StatusNoContent {
+		t.Fatalf("token valid harus lolos, dapat %d", code)
+	}
+
+	// Admin lain menurunkan role user ini menjadi wali
+	actor := &utils.JWTClaims{UID: "00000000-0000-4000-8000-999999999999", Role: models.ADMIN_ROLE_NAME}
+	target := withVars(map[string]string{"uid": resp.UID})
+	w := serve(t, h.HandleChangeUserRole, http.MethodPut, ChangeRoleRequest{RoleID: models.PARENT_ROLE_ID}, target, asUser(actor))
+	if w.Code != http.StatusOK {
+		t.Fatalf("ganti role harus 200, dapat %d: %s", w.Code, w.Body.String())
+	}
+	if code := call(); code != http.StatusUnauthorized {
+		t.Errorf("token dengan role lama harus langsung ditolak, dapat %d", code)
+	}
+
+	if w := serve(t, h.HandleChangeUserRole, http.MethodPut, ChangeRoleRequest{RoleID: 99}, target, asUser(actor)); w.Code != http.StatusBadRequest {
+		t.Errorf("role_id tidak dikenal harus 400, dapat %d", w.Code)
+	}
+}
+
+// canceledAuth: AuthStore yang verifikasi API key-nya dibatalkan server (57014 query_canceled)
+type canceledAuth struct{ *memory.Store }
+
+func (canceledAuth) AuthenticateAPIKey(ctx context.Context, key, ip string) (*models.APIKeyPrincipal, error) {
+	return nil, &pq.Error{Code: "57014", Message: "canceling statement due to user request"}
+}
+
+func TestAuthMiddlewareAPIKeyTimeout(t *testing.T) {
+	_, store := newTestHandler()
+	protected := middleware.AuthMiddleware(canceledAuth{store})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	call := func(ctx context.Context) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+		r.Header.Set(middleware.APIKeyHeader, "sk_test_0123456789")
+		w := httptest.NewRecorder()
+		protected.ServeHTTP(w, r)
+		return w
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if w := call(ctx); w.Code != http.StatusGatewayTimeout {
+		t.Errorf("query_canceled karena deadline saat verifikasi API key harus 504, dapat %d: %s", w.Code, w.Body.String())
+	}
+
+	// Client memutus koneksi: 57014 bukan timeout dan tidak ada respons
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if w := call(ctx); w.Body.Len() != 0 {
+		t.Errorf("query_canceled karena client batal tidak boleh dijawab, dapat %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go-sis-be/internal/configs"
+	"go-sis-be/internal/utils"
+)
+
+// HandleHealth menangani GET /health: status API dan Redis untuk monitoring.
+// Selalu 200 selama proses hidup; "degraded" berarti pencabutan token (blacklist, sesi) tidak bisa dijamin.
+func (h *Handler) HandleHealth(w http.ResponseWriter, r *http.Request) {
+	redisHealth := configs.RedisBreaker.Health()
+	blacklist := h.Tokens.TokenBlacklistStatus()
+
+	status := "ok"
+	if !redisHealth.Healthy {
+		status = "degraded"
+	}
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":           status,
+		"redis":            redisHealth,
+		"token_revocation": blacklist,
+	})
+}
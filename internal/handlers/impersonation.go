@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-sis-be/internal/audit"
+	"go-sis-be/internal/models"
+	"go-sis-be/internal/utils"
+	"go-sis-be/middleware"
+
+	"github.com/gorilla/mux"
+)
+
+type ImpersonateRequest struct {
+	Reason string `json:"reason"` // Wajib: alasan/tiket support, ikut dicatat di audit log
+}
+
+// HandleImpersonate menangani POST /users/{uid}/impersonate: admin menerima access token
+// berumur pendek atas nama user target, dengan klaim act berisi admin aslinya.
+func (h *Handler) HandleImpersonate(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	uid := mux.Vars(r)["uid"]
+
+	var req ImpersonateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, utils.ErrMsgInvalidPayload)
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		respondWithError(w, http.StatusBadRequest, "Alasan impersonation wajib diisi.")
+		return
+	}
+
+	if uid == claims.UID {
+		respondWithError(w, http.StatusBadRequest, "Tidak bisa impersonate diri sendiri")
+		return
+	}
+
+	target, err := h.Users.GetUserIdentityByUID(r.Context(), uid)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		respondWithError(w, http.StatusNotFound, "User tidak ditemukan")
+		return
+	}
+	if target.Role == models.ADMIN_ROLE_NAME {
+		respondWithError(w, http.StatusForbidden, "Tidak bisa impersonate sesama admin")
+		return
+	}
+
+	token, tokenClaims, err := utils.GenerateImpersonationToken(
+		target.UID, target.Username, target.Role, claims.SessionID, target.TokenVersion,
+		utils.ActorClaim{UID: claims.UID, Username: claims.Username, TokenVersion: claims.TokenVersion},
+	)
+	if err != nil {
+		log.Printf("Error membuat token impersonation: %v", err)
+		respondWithError(w, http.StatusInternalServerError, utils.ErrMsgServerError)
+		return
+	}
+	expiresAt := tokenClaims.ExpiresAt.Time
+
+	h.Audit.RecordAuthEvent(r.Context(), models.AuthEvent{
+		EventType: models.AuthEventImpersonationStarted,
+		UID:       target.UID,
+		Username:  target.Username,
+		IPAddress: utils.ClientIP(r),
+		ActorUID:  claims.UID,
+		Detail:    req.Reason,
+	})
+	h.recorder.Record(r, audit.ActionImpersonationStart, target.UID, nil, map[string]interface{}{
+		"reason":     req.Reason,
+		"token_id":   tokenClaims.ID,
+		"expires_at": expiresAt,
+	})
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":      "Token impersonation diterbitkan. Semua aksi tercatat atas nama Anda.",
+		"access_token": token,
+		"expires_at":   expiresAt,
+		"impersonating": map[string]string{
+			"uid":      target.UID,
+			"username": target.Username,
+			"role":     target.Role,
+		},
+	})
+}
+
+// HandleEndImpersonation menangani POST /impersonation/end: mematikan token impersonation yang dipakai
+func (h *Handler) HandleEndImpersonation(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	if !claims.Impersonated() {
+		respondWithError(w, http.StatusBadRequest, "Token ini bukan token impersonation")
+		return
+	}
+
+	tokenString := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if ttl := time.Until(claims.ExpiresAt.Time); ttl > 0 {
+		if err := h.Tokens.BlacklistToken(r.Context(), tokenString, ttl); err != nil {
+			if respondTimeoutError(w, r, err) {
+				return
+			}
+			log.Printf("ERROR REDIS BLACKLIST: %v", err)
+			respondWithError(w, http.StatusInternalServerError, "Gagal mengakhiri impersonation")
+			return
+		}
+	}
+
+	h.Audit.RecordAuthEvent(r.Context(), models.AuthEvent{
+		EventType: models.AuthEventImpersonationEnded,
+		UID:       claims.UID,
+		Username:  claims.Username,
+		IPAddress: utils.ClientIP(r),
+		ActorUID:  claims.Actor.UID,
+	})
+	h.recorder.Record(r, audit.ActionImpersonationEnd, claims.UID, nil, map[string]string{"token_id": claims.ID})
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Impersonation diakhiri. Gunakan kembali access token admin Anda."})
+}
@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go-sis-be/internal/utils"
+)
+
+// HandleJWKS menangani GET /.well-known/jwks.json: public key untuk verifikasi access token oleh layanan lain
+func (h *Handler) HandleJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(utils.PublicJWKS())
+}
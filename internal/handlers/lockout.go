@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"go-sis-be/internal/audit"
+	"go-sis-be/internal/models"
+	"go-sis-be/internal/utils"
+	"go-sis-be/middleware"
+
+	"github.com/gorilla/mux"
+)
+
+type UnlockLoginRequest struct {
+	IPAddress string `json:"ip_address,omitempty"` // Opsional: ikut buka kunci IP ini
+}
+
+// HandleUnlockLogin menangani POST /users/{uid}/unlock: admin membuka kunci login user
+func (h *Handler) HandleUnlockLogin(w http.ResponseWriter, r *http.Request) {
+	uid := mux.Vars(r)["uid"]
+
+	var req UnlockLoginRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, utils.ErrMsgInvalidPayload)
+			return
+		}
+	}
+
+	user, err := h.Users.GetUserByID(r.Context(), uid)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		respondWithError(w, http.StatusNotFound, "User tidak ditemukan")
+		return
+	}
+
+	ip := strings.TrimSpace(req.IPAddress)
+	unlocked, err := h.LoginAttempts.UnlockLogin(r.Context(), user.Username, ip)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Redis error unlock login %s: %v", uid, err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal membuka kunci login")
+		return
+	}
+
+	if unlocked {
+		actorUID := ""
+		if claims, ok := middleware.ClaimsFromContext(r.Context()); ok {
+			actorUID = claims.UID
+		}
+		h.Audit.RecordAuthEvent(r.Context(), models.AuthEvent{
+			EventType: models.AuthEventLoginUnlocked,
+			UID:       user.UID,
+			Username:  user.Username,
+			IPAddress: ip,
+			ActorUID:  actorUID,
+			Detail:    "kunci login dibuka oleh admin",
+		})
+		h.recorder.Record(r, audit.ActionLoginUnlock, user.UID, nil, map[string]string{"ip_address": ip})
+	}
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":  "Kunci login user berhasil dibuka",
+		"unlocked": unlocked,
+	})
+}
@@ -0,0 +1,395 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"go-sis-be/internal/audit"
+	"go-sis-be/internal/models"
+	"go-sis-be/internal/oidc"
+	"go-sis-be/internal/utils"
+	"go-sis-be/middleware"
+
+	"github.com/gorilla/mux"
+)
+
+// oidcStateTTL: Waktu maksimal user berada di halaman IdP sebelum callback
+const oidcStateTTL = 10 * time.Minute
+
+// oidcStateCookie: Cookie berisi hash state, mengikat callback ke browser yang memulai alur
+// (mencegah login CSRF dan link identitas korban ke akun penyerang)
+const oidcStateCookie = "oidc_state"
+
+func hashOIDCState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
+
+// setOIDCStateCookie menyimpan hash state di browser. SameSite selalu Lax: callback datang
+// lewat navigasi top-level dari domain IdP, cookie Strict tidak akan ikut terkirim.
+func (h *Handler) setOIDCStateCookie(w http.ResponseWriter, state string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    hashOIDCState(state),
+		Expires:  time.Now().Add(oidcStateTTL),
+		MaxAge:   int(oidcStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   h.cfg.Cookie.Secure,
+		Domain:   h.cfg.Cookie.Domain,
+		Path:     "/",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// checkOIDCStateCookie mencocokkan state di callback dengan cookie dari browser yang sama, lalu menghapus cookie-nya
+func (h *Handler) checkOIDCStateCookie(w http.ResponseWriter, r *http.Request, state string) bool {
+	c, err := r.Cookie(oidcStateCookie)
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    "",
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   h.cfg.Cookie.Secure,
+		Domain:   h.cfg.Cookie.Domain,
+		Path:     "/",
+		SameSite: http.SameSiteLaxMode,
+	})
+	return err == nil && state != "" && subtle.ConstantTimeCompare([]byte(c.Value), []byte(hashOIDCState(state))) == 1
+}
+
+// providerFromRequest mengambil provider dari path {provider} dan menulis 404 jika tidak dikenal
+func (h *Handler) providerFromRequest(w http.ResponseWriter, r *http.Request) *oidc.Provider {
+	p, err := h.OIDCProviders.Get(mux.Vars(r)["provider"])
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, err.Error())
+		return nil
+	}
+	return p
+}
+
+// startOIDCFlow menyimpan state/nonce/PKCE verifier baru, memasang cookie state di browser,
+// dan mengembalikan URL authorize IdP
+func (h *Handler) startOIDCFlow(w http.ResponseWriter, r *http.Request, p *oidc.Provider, device, linkUID string) (string, error) {
+	state, err := utils.RandomHex(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := utils.RandomHex(16)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := utils.RandomHex(32)
+	if err != nil {
+		return "", err
+	}
+
+	err = h.OIDC.SaveOIDCState(r.Context(), state, models.OIDCState{
+		Provider: p.Name(),
+		Nonce:    nonce,
+		Verifier: verifier,
+		Device:   device,
+		LinkUID:  linkUID,
+	}, oidcStateTTL)
+	if err != nil {
+		return "", err
+	}
+	authURL, err := p.AuthCodeURL(r.Context(), state, nonce, verifier)
+	if err != nil {
+		return "", err
+	}
+	h.setOIDCStateCookie(w, state)
+	return authURL, nil
+}
+
+// HandleListOIDCProviders menangani GET /auth/oidc/providers: daftar provider untuk tombol login di frontend
+func (h *Handler) HandleListOIDCProviders(w http.ResponseWriter, r *http.Request) {
+	names := []string{}
+	for name := range h.OIDCProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{"providers": names})
+}
+
+// HandleOIDCLogin menangani GET /auth/oidc/{provider}/login: redirect browser ke halaman login IdP
+func (h *Handler) HandleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	p := h.providerFromRequest(w, r)
+	if p == nil {
+		return
+	}
+
+	device := strings.TrimSpace(r.URL.Query().Get("device"))
+	if device == "" {
+		device = utils.DeviceLabel(r.UserAgent())
+	}
+
+	authURL, err := h.startOIDCFlow(w, r, p, device, "")
+	if err != nil {
+		log.Printf("Error memulai login OIDC %s: %v", p.Name(), err)
+		respondWithError(w, http.StatusBadGateway, "Gagal menghubungi identity provider")
+		return
+	}
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// HandleOIDCCallback menangani GET /auth/oidc/{provider}/callback.
+// Alur login menerbitkan token yang sama dengan LoginHandler; alur link menghubungkan identitas ke akun.
+func (h *Handler) HandleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	p := h.providerFromRequest(w, r)
+	if p == nil {
+		return
+	}
+
+	q := r.URL.Query()
+	if idpErr := q.Get("error"); idpErr != "" {
+		respondWithError(w, http.StatusUnauthorized, "Login dibatalkan atau ditolak identity provider: "+idpErr)
+		return
+	}
+
+	// State harus datang dari browser yang memulai alur; state dari browser lain tidak dikonsumsi
+	if !h.checkOIDCStateCookie(w, r, q.Get("state")) {
+		respondWithError(w, http.StatusBadRequest, "State OIDC tidak valid atau kadaluarsa, silakan ulangi login")
+		return
+	}
+
+	st, err := h.OIDC.ConsumeOIDCState(r.Context(), q.Get("state"))
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error ambil state OIDC: %v", err)
+		respondWithError(w, http.StatusInternalServerError, utils.ErrMsgServerError)
+		return
+	}
+	if st == nil || st.Provider != p.Name() {
+		respondWithError(w, http.StatusBadRequest, "State OIDC tidak valid atau kadaluarsa, silakan ulangi login")
+		return
+	}
+
+	ident, err := p.Exchange(r.Context(), q.Get("code"), st.Verifier, st.Nonce)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error verifikasi OIDC %s: %v", p.Name(), err)
+		respondWithError(w, http.StatusUnauthorized, "Verifikasi identitas dari identity provider gagal")
+		return
+	}
+
+	if st.LinkUID != "" {
+		h.linkOIDCIdentity(w, r, st.LinkUID, ident)
+		return
+	}
+
+	uid, err := h.resolveOIDCUser(r, ident)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error mencocokkan identitas OIDC %s/%s: %v", ident.Provider, ident.Subject, err)
+		respondWithError(w, http.StatusInternalServerError, utils.ErrMsgServerError)
+		return
+	}
+	if uid == "" {
+		respondWithError(w, http.StatusForbidden, "Akun "+ident.Provider+" ini belum terhubung ke akun SIS. Login dengan password lalu hubungkan lewat /me/oidc/"+ident.Provider+"/link, atau hubungi admin.")
+		return
+	}
+
+	user, err := h.Users.GetUserIdentityByUID(r.Context(), uid)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		respondWithError(w, http.StatusUnauthorized, "Akun tidak ditemukan")
+		return
+	}
+	h.OIDC.TouchIdentity(r.Context(), ident.Provider, ident.Subject)
+
+	h.completeLogin(w, r, user, st.Device)
+}
+
+// resolveOIDCUser memetakan identitas eksternal ke uid lokal: lewat link eksplisit, atau lewat email
+// terverifikasi yang cocok dengan tepat satu akun (lalu otomatis di-link). "" jika tidak ada yang cocok.
+func (h *Handler) resolveOIDCUser(r *http.Request, ident *oidc.Identity) (string, error) {
+	uid, err := h.OIDC.GetLinkedUID(r.Context(), ident.Provider, ident.Subject)
+	if err != nil || uid != "" {
+		return uid, err
+	}
+	if !ident.EmailVerified {
+		return "", nil
+	}
+
+	uid, err = h.OIDC.FindUIDByEmail(r.Context(), ident.Email)
+	if err != nil || uid == "" {
+		return "", err
+	}
+
+	// Akun sudah punya identitas lain dari provider ini: jangan diambil alih lewat email
+	if err := h.OIDC.LinkIdentity(r.Context(), uid, ident.Provider, ident.Subject, ident.Email); err != nil {
+		if errors.Is(err, models.ErrIdentityLinked) {
+			return "", nil
+		}
+		return "", err
+	}
+	h.Audit.RecordAuthEvent(r.Context(), models.AuthEvent{
+		EventType: models.AuthEventOIDCLinked,
+		UID:       uid,
+		IPAddress: utils.ClientIP(r),
+		Detail:    ident.Provider + " (otomatis lewat email " + ident.Email + ")",
+	})
+	h.recorder.RecordAs(r, "", "", audit.ActionOIDCLink, uid, nil, map[string]string{
+		"provider": ident.Provider,
+		"subject":  ident.Subject,
+		"email":    ident.Email,
+	})
+	return uid, nil
+}
+
+// linkOIDCIdentity menyelesaikan alur link yang dimulai dari POST /me/oidc/{provider}/link
+func (h *Handler) linkOIDCIdentity(w http.ResponseWriter, r *http.Request, uid string, ident *oidc.Identity) {
+	user, err := h.Users.GetUserIdentityByUID(r.Context(), uid)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		respondWithError(w, http.StatusUnauthorized, "Akun tidak ditemukan")
+		return
+	}
+
+	if err := h.OIDC.LinkIdentity(r.Context(), uid, ident.Provider, ident.Subject, ident.Email); err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		if errors.Is(err, models.ErrIdentityLinked) {
+			respondWithError(w, http.StatusConflict, "Akun "+ident.Provider+" ini sudah terhubung ke akun SIS lain, atau akun Anda sudah terhubung ke akun "+ident.Provider+" lain")
+			return
+		}
+		log.Printf("Error link OIDC %s: %v", uid, err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal menghubungkan akun")
+		return
+	}
+
+	h.Audit.RecordAuthEvent(r.Context(), models.AuthEvent{
+		EventType: models.AuthEventOIDCLinked,
+		UID:       uid,
+		Username:  user.Username,
+		IPAddress: utils.ClientIP(r),
+		ActorUID:  uid,
+		Detail:    ident.Provider,
+	})
+	h.recorder.RecordAs(r, uid, user.Role, audit.ActionOIDCLink, uid, nil, map[string]string{
+		"provider": ident.Provider,
+		"subject":  ident.Subject,
+		"email":    ident.Email,
+	})
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Akun " + ident.Provider + " berhasil dihubungkan. Selanjutnya Anda bisa login lewat " + ident.Provider + "."})
+}
+
+// HandleOIDCLinkStart menangani POST /me/oidc/{provider}/link: mengembalikan URL authorize
+// untuk menghubungkan akun yang sedang login dengan identitas di provider. Request harus dikirim
+// dengan credentials agar cookie state tersimpan di browser yang nanti membuka URL tersebut.
+func (h *Handler) HandleOIDCLinkStart(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	p := h.providerFromRequest(w, r)
+	if p == nil {
+		return
+	}
+
+	authURL, err := h.startOIDCFlow(w, r, p, "", claims.UID)
+	if err != nil {
+		log.Printf("Error memulai link OIDC %s: %v", p.Name(), err)
+		respondWithError(w, http.StatusBadGateway, "Gagal menghubungi identity provider")
+		return
+	}
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":           "Buka authorization_url di browser untuk menyelesaikan penghubungan akun",
+		"authorization_url": authURL,
+	})
+}
+
+// HandleListMyIdentities menangani GET /me/oidc: identitas eksternal yang terhubung ke akun sendiri
+func (h *Handler) HandleListMyIdentities(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	links, err := h.OIDC.ListIdentities(r.Context(), claims.UID)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error list identitas OIDC %s: %v", claims.UID, err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal mengambil akun terhubung")
+		return
+	}
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"total_data": len(links),
+		"data":       links,
+	})
+}
+
+// HandleOIDCUnlink menangani DELETE /me/oidc/{provider}: memutus akun eksternal dari akun sendiri
+func (h *Handler) HandleOIDCUnlink(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	provider := mux.Vars(r)["provider"]
+
+	err := h.OIDC.UnlinkIdentity(r.Context(), claims.UID, provider)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Akun "+provider+" tidak terhubung")
+		return
+	}
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error unlink OIDC %s: %v", claims.UID, err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal memutus akun")
+		return
+	}
+
+	h.Audit.RecordAuthEvent(r.Context(), models.AuthEvent{
+		EventType: models.AuthEventOIDCUnlinked,
+		UID:       claims.UID,
+		Username:  claims.Username,
+		IPAddress: utils.ClientIP(r),
+		ActorUID:  claims.UID,
+		Detail:    provider,
+	})
+	h.recorder.Record(r, audit.ActionOIDCUnlink, claims.UID, map[string]string{"provider": provider}, nil)
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Akun " + provider + " berhasil diputus"})
+}
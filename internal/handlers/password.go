@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-sis-be/internal/audit"
+	"go-sis-be/internal/mailer"
+	"go-sis-be/internal/models"
+	"go-sis-be/internal/utils"
+	"go-sis-be/middleware"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	tempPasswordLength = 12
+	resetTokenTTL      = 30 * time.Minute
+)
+
+// forgotPasswordMessage: Respons /password/forgot selalu sama agar tidak membocorkan akun mana yang ada
+const forgotPasswordMessage = "Jika akun terdaftar dan memiliki email, link reset password telah dikirim."
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+type ForgotPasswordRequest struct {
+	Username string `json:"username"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// HandleChangeMyPassword menangani PUT /me/password: user mengganti password sendiri.
+// Semua sesi user dicabut setelahnya sehingga user harus login ulang di semua perangkat.
+func (h *Handler) HandleChangeMyPassword(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, utils.ErrMsgInvalidPayload)
+		return
+	}
+
+	if req.OldPassword == "" || req.NewPassword == "" {
+		respondWithError(w, http.StatusBadRequest, "Password lama dan password baru wajib diisi.")
+		return
+	}
+	if req.NewPassword == req.OldPassword {
+		respondWithError(w, http.StatusBadRequest, "Password baru tidak boleh sama dengan password lama.")
+		return
+	}
+
+	currentHash, err := h.Passwords.GetPasswordHash(r.Context(), claims.UID)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "User tidak ditemukan")
+			return
+		}
+		log.Printf("Error ambil password %s: %v", claims.UID, err)
+		respondWithError(w, http.StatusInternalServerError, utils.ErrMsgServerError)
+		return
+	}
+	if !utils.CheckPasswordHash(req.OldPassword, currentHash) {
+		respondWithError(w, http.StatusUnauthorized, "Password lama salah")
+		return
+	}
+
+	if err := h.Passwords.ChangePassword(r.Context(), claims.UID, req.NewPassword, false); err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		if respondPasswordPolicyError(w, err) {
+			return
+		}
+		log.Printf("Error update password %s: %v", claims.UID, err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal memperbarui password")
+		return
+	}
+
+	h.revokeAllUserSessions(r.Context(), claims.UID)
+	h.recorder.Record(r, audit.ActionPasswordChange, claims.UID, nil, nil)
+
+	// Access token yang sedang dipakai juga langsung tidak berlaku
+	tokenString := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if err := h.Tokens.BlacklistToken(r.Context(), tokenString, utils.AccessTokenTTL); err != nil {
+		log.Printf("ERROR REDIS BLACKLIST: %v", err)
+	}
+	h.clearRefreshCookie(w)
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Password berhasil diganti. Silakan login kembali."})
+}
+
+// HandleResetPassword menangani POST /users/{uid}/reset-password: admin menerbitkan password sementara.
+// User wajib mengganti password sementara tersebut saat login berikutnya.
+func (h *Handler) HandleResetPassword(w http.ResponseWriter, r *http.Request) {
+	uid := mux.Vars(r)["uid"]
+
+	tempPassword, err := utils.RandomPassword(tempPasswordLength)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, utils.ErrMsgServerError)
+		return
+	}
+	hashed, err := h.cfg.PasswordHash.Hash(tempPassword)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, utils.ErrMsgServerError)
+		return
+	}
+
+	if err := h.Passwords.UpdatePassword(r.Context(), uid, hashed, true); err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "User tidak ditemukan")
+			return
+		}
+		log.Printf("Error reset password %s: %v", uid, err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal mereset password")
+		return
+	}
+
+	h.revokeAllUserSessions(r.Context(), uid)
+	h.recorder.Record(r, audit.ActionPasswordReset, uid, nil, map[string]bool{"must_change_password": true})
+
+	if claims, ok := middleware.ClaimsFromContext(r.Context()); ok {
+		log.Printf("Password user %s direset oleh admin %s", uid, claims.UID)
+	}
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":              "Password berhasil direset. Sampaikan password sementara ini ke user.",
+		"temporary_password":   tempPassword,
+		"must_change_password": true,
+	})
+}
+
+// HandleForgotPassword menangani POST /password/forgot: mengirim token reset sekali pakai ke person.email.
+// Respons selalu identik, baik akun ada maupun tidak.
+func (h *Handler) HandleForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, utils.ErrMsgInvalidPayload)
+		return
+	}
+
+	username := strings.TrimSpace(req.Username)
+	if username == "" {
+		respondWithError(w, http.StatusBadRequest, "Username wajib diisi.")
+		return
+	}
+
+	recipient, err := h.ResetTokens.GetResetRecipient(r.Context(), username)
+	if err != nil {
+		log.Printf("Error forgot password %q: %v", username, err)
+	}
+	if recipient != nil {
+		// Dikirim di background supaya waktu respons tidak membedakan akun yang ada,
+		// dengan context yang tidak ikut batal saat request selesai
+		go h.sendPasswordResetEmail(context.WithoutCancel(r.Context()), recipient)
+	}
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": forgotPasswordMessage})
+}
+
+// sendPasswordResetEmail menerbitkan token reset baru untuk recipient dan mengirimkannya lewat email
+func (h *Handler) sendPasswordResetEmail(ctx context.Context, recipient *models.ResetRecipient) {
+	token, err := utils.RandomHex(32)
+	if err != nil {
+		log.Printf("Error generate token reset: %v", err)
+		return
+	}
+	if err := h.ResetTokens.StorePasswordResetToken(ctx, token, recipient.UID, resetTokenTTL); err != nil {
+		log.Printf("Error simpan token reset %s: %v", recipient.UID, err)
+		return
+	}
+
+	link := h.cfg.PasswordResetURL + token
+
+	body := "Halo " + recipient.FullName + ",\n\n" +
+		"Kami menerima permintaan reset password untuk akun Anda.\n" +
+		"Gunakan link/token berikut dalam 30 menit:\n\n" +
+		link + "\n\n" +
+		"Jika Anda tidak meminta reset password, abaikan email ini.\n"
+
+	err = h.Mailer.Send(mailer.Message{
+		To:      recipient.Email,
+		Subject: "Reset Password Akun SIS",
+		Body:    body,
+	})
+	if err != nil {
+		log.Printf("Error kirim email reset ke %s: %v", recipient.UID, err)
+	}
+}
+
+// HandleResetForgottenPassword menangani POST /password/reset: menukar token reset dengan password baru
+func (h *Handler) HandleResetForgottenPassword(w http.ResponseWriter, r *http.Request) {
+	var req ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, utils.ErrMsgInvalidPayload)
+		return
+	}
+
+	if req.Token == "" || req.NewPassword == "" {
+		respondWithError(w, http.StatusBadRequest, "Token dan password baru wajib diisi.")
+		return
+	}
+	// Validasi dulu sebelum token dipakai, supaya password yang ditolak kebijakan tidak menghanguskan token
+	uid, err := h.ResetTokens.PeekPasswordResetToken(r.Context(), req.Token)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		if errors.Is(err, models.ErrResetTokenInvalid) {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("Error cek token reset: %v", err)
+		respondWithError(w, http.StatusInternalServerError, utils.ErrMsgServerError)
+		return
+	}
+	if err := h.Passwords.ValidateNewPassword(r.Context(), uid, req.NewPassword); err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		if respondPasswordPolicyError(w, err) {
+			return
+		}
+		log.Printf("Error validasi password %s: %v", uid, err)
+		respondWithError(w, http.StatusInternalServerError, utils.ErrMsgServerError)
+		return
+	}
+
+	uid, err = h.ResetTokens.ConsumePasswordResetToken(r.Context(), req.Token)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		if errors.Is(err, models.ErrResetTokenInvalid) {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("Error consume token reset: %v", err)
+		respondWithError(w, http.StatusInternalServerError, utils.ErrMsgServerError)
+		return
+	}
+
+	if err := h.Passwords.ChangePassword(r.Context(), uid, req.NewPassword, false); err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusBadRequest, models.ErrResetTokenInvalid.Error())
+			return
+		}
+		if respondPasswordPolicyError(w, err) {
+			return
+		}
+		log.Printf("Error update password %s: %v", uid, err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal memperbarui password")
+		return
+	}
+
+	h.revokeAllUserSessions(r.Context(), uid)
+	h.recorder.Record(r, audit.ActionPasswordResetByMail, uid, nil, nil)
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Password berhasil direset. Silakan login dengan password baru."})
+}
+
+// respondPasswordPolicyError menulis 400 beserta nama aturan jika err adalah pelanggaran kebijakan password.
+// Mengembalikan false (tanpa menulis apa pun) untuk error lain.
+func respondPasswordPolicyError(w http.ResponseWriter, err error) bool {
+	var policyErr *utils.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error": policyErr.Message,
+		"rule":  policyErr.Rule,
+	})
+	return true
+}
+
+// revokeAllUserSessions mencabut semua sesi user setelah password berubah.
+// Access token yang masih beredar ikut ditolak lewat penanda sesi dicabut di Redis.
+func (h *Handler) revokeAllUserSessions(ctx context.Context, uid string) {
+	// Password sudah berganti; pencabutan sesi tetap diselesaikan walau client memutus koneksi
+	count, err := h.Sessions.RevokeAllSessions(context.WithoutCancel(ctx), uid)
+	if err != nil {
+		log.Printf("Error mencabut sesi %s setelah ganti password: %v", uid, err)
+		return
+	}
+	log.Printf("Password user %s berubah, %d sesi dicabut", uid, count)
+}
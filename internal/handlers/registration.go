@@ -1,11 +1,16 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt" // Tambahkan fmt untuk logging dan error message
+	"log"
 	"net/http"
 
 	// Tambahkan strings untuk membuat array ENUM
+	"go-sis-be/internal/audit"
+	"go-sis-be/internal/configs"
 	"go-sis-be/internal/models"
 	"go-sis-be/internal/utils"
 )
@@ -40,10 +45,25 @@ func respondWithError(w http.ResponseWriter, code int, message string) {
 	json.NewEncoder(w).Encode(map[string]string{"error": message})
 }
 
+// respondTimeoutError menulis 504 jika err berasal dari batas waktu query/Redis (DB_QUERY_TIMEOUT_MS),
+// termasuk query_canceled (57014) dari Postgres yang dipicu deadline. Jika client sudah memutus
+// koneksi tidak ada yang ditulis. Mengembalikan false untuk error lain.
+func respondTimeoutError(w http.ResponseWriter, r *http.Request, err error) bool {
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(r.Context().Err(), context.Canceled):
+		return true
+	case configs.IsQueryTimeout(r.Context(), err):
+		log.Printf("Timeout %s %s: %v", r.Method, r.URL.Path, err)
+		respondWithError(w, http.StatusGatewayTimeout, utils.ErrMsgTimeout)
+		return true
+	}
+	return false
+}
+
 // ==========================================
 // 4. REGISTRASI MURID HANDLER
 // ==========================================
-func HandleStudentRegistration(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) HandleStudentRegistration(w http.ResponseWriter, r *http.Request) {
 	var req models.RegisterStudentRequest
 
 	// 1. Decode Request Body
@@ -99,15 +119,23 @@ func HandleStudentRegistration(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 4. Panggil Fungsi Database Transaksi
-	resp, err := models.RegisterStudent(&req)
+	resp, err := h.Profiles.RegisterStudent(r.Context(), &req)
 
 	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		if respondPasswordPolicyError(w, err) {
+			return
+		}
 		fmt.Printf("Error registering student: %v\n", err)
 		// Pesan error umum untuk transaksi yang gagal
 		respondWithError(w, http.StatusInternalServerError, "Gagal mendaftarkan Murid. Data duplikat (NIK/NISN) atau error server.")
 		return
 	}
 
+	h.recorder.Record(r, audit.ActionStudentRegister, resp.UID, nil, resp)
+
 	// 5. Kirim Respons Sukses
 	w.Header().Set(utils.ContentHeader, utils.Mime)
 	w.WriteHeader(http.StatusCreated)
@@ -117,7 +145,7 @@ func HandleStudentRegistration(w http.ResponseWriter, r *http.Request) {
 // ==========================================
 // 5. REGISTRASI GURU HANDLER
 // ==========================================
-func HandleTeacherRegistration(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) HandleTeacherRegistration(w http.ResponseWriter, r *http.Request) {
 	var req models.RegisterTeacherRequest
 
 	// 1. Decode Request Body
@@ -163,15 +191,23 @@ func HandleTeacherRegistration(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 4. Panggil Fungsi Database Transaksi
-	resp, err := models.RegisterTeacher(&req)
+	resp, err := h.Profiles.RegisterTeacher(r.Context(), &req)
 
 	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		if respondPasswordPolicyError(w, err) {
+			return
+		}
 		fmt.Printf("Error registering teacher: %v\n", err)
 		// Pesan error umum untuk transaksi yang gagal
 		respondWithError(w, http.StatusInternalServerError, "Gagal mendaftarkan Guru. Data duplikat (NIK) atau error server.")
 		return
 	}
 
+	h.recorder.Record(r, audit.ActionTeacherRegister, resp.UID, nil, resp)
+
 	// 5. Kirim Respons Sukses
 	w.Header().Set(utils.ContentHeader, utils.Mime)
 	w.WriteHeader(http.StatusCreated)
@@ -181,7 +217,7 @@ func HandleTeacherRegistration(w http.ResponseWriter, r *http.Request) {
 // ==========================================
 // 6. REGISTRASI ADMIN
 // ==========================================
-func HandleAdminRegistration(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) HandleAdminRegistration(w http.ResponseWriter, r *http.Request) {
 	var req models.RegisterBaseRequest
 
 	// 1. Decode Request Body
@@ -208,14 +244,22 @@ func HandleAdminRegistration(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 4. Panggil Fungsi Database Transaksi (RegisterBaseUser)
-	resp, err := models.RegisterBaseUser(&req)
+	resp, err := h.Profiles.RegisterBaseUser(r.Context(), &req)
 
 	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		if respondPasswordPolicyError(w, err) {
+			return
+		}
 		fmt.Printf("Error registering Admin: %v\n", err)
 		respondWithError(w, http.StatusInternalServerError, "Gagal mendaftarkan Admin. Data duplikat (NIK) atau error server.")
 		return
 	}
 
+	h.recorder.Record(r, audit.ActionAdminRegister, resp.UID, nil, resp)
+
 	// 5. Kirim Respons Sukses
 	w.Header().Set(utils.ContentHeader, utils.Mime)
 	w.WriteHeader(http.StatusCreated)
@@ -225,7 +269,7 @@ func HandleAdminRegistration(w http.ResponseWriter, r *http.Request) {
 // ==========================================
 // 7. REGISTRASI ORANG TUA
 // ==========================================
-func HandleParentRegistration(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) HandleParentRegistration(w http.ResponseWriter, r *http.Request) {
 	var req models.RegisterBaseRequest
 
 	// 1. Decode Request Body
@@ -252,14 +296,22 @@ func HandleParentRegistration(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 4. Panggil Fungsi Database Transaksi (RegisterBaseUser)
-	resp, err := models.RegisterBaseUser(&req)
+	resp, err := h.Profiles.RegisterBaseUser(r.Context(), &req)
 
 	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		if respondPasswordPolicyError(w, err) {
+			return
+		}
 		fmt.Printf("Error registering Parent: %v\n", err)
 		respondWithError(w, http.StatusInternalServerError, "Gagal mendaftarkan Wali Murid. Data duplikat (NIK) atau error server.")
 		return
 	}
 
+	h.recorder.Record(r, audit.ActionParentRegister, resp.UID, nil, resp)
+
 	// 5. Kirim Respons Sukses
 	w.Header().Set(utils.ContentHeader, utils.Mime)
 	w.WriteHeader(http.StatusCreated)
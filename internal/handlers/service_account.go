@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-sis-be/internal/audit"
+	"go-sis-be/internal/models"
+	"go-sis-be/internal/utils"
+	"go-sis-be/middleware"
+
+	"github.com/gorilla/mux"
+)
+
+// ServiceAccountRequest: Payload buat/ubah service account
+type ServiceAccountRequest struct {
+	Name          string   `json:"name"`
+	Description   string   `json:"description"`
+	Scopes        []string `json:"scopes"`
+	Disabled      bool     `json:"disabled"`                  // Hanya dipakai saat update
+	ExpiresInDays int      `json:"expires_in_days,omitempty"` // Masa berlaku key pertama, 0 = tanpa kedaluwarsa
+}
+
+// CreateAPIKeyRequest: Payload penerbitan API key baru
+type CreateAPIKeyRequest struct {
+	ExpiresInDays int `json:"expires_in_days,omitempty"`
+}
+
+// validateServiceAccountRequest memeriksa nama dan scope. Mengembalikan pesan error untuk client.
+func validateServiceAccountRequest(req *ServiceAccountRequest) string {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return "Nama service account wajib diisi."
+	}
+	if len(req.Scopes) == 0 {
+		return "Minimal satu scope wajib diisi."
+	}
+	for _, s := range req.Scopes {
+		if !middleware.IsGrantableScope(s) {
+			return "Scope tidak valid: " + s
+		}
+	}
+	if req.ExpiresInDays < 0 {
+		return "expires_in_days tidak boleh negatif."
+	}
+	return ""
+}
+
+// issueAPIKey membuat key baru untuk service account. Nilai key hanya dikembalikan sekali ini.
+func (h *Handler) issueAPIKey(ctx context.Context, serviceAccountID string, expiresInDays int) (string, *models.APIKey, error) {
+	key, prefix, err := utils.GenerateAPIKey()
+	if err != nil {
+		return "", nil, err
+	}
+	var expiresAt *time.Time
+	if expiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, expiresInDays)
+		expiresAt = &t
+	}
+	meta, err := h.ServiceAccounts.CreateAPIKey(ctx, serviceAccountID, key, prefix, expiresAt)
+	if err != nil {
+		return "", nil, err
+	}
+	return key, meta, nil
+}
+
+// HandleCreateServiceAccount menangani POST /service-accounts: membuat service account beserta key pertamanya
+func (h *Handler) HandleCreateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	var req ServiceAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, utils.ErrMsgInvalidPayload)
+		return
+	}
+	if msg := validateServiceAccountRequest(&req); msg != "" {
+		respondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	sa := models.ServiceAccount{Name: req.Name, Description: req.Description, Scopes: req.Scopes}
+	if claims, ok := middleware.ClaimsFromContext(r.Context()); ok {
+		sa.CreatedBy = claims.UID
+	}
+	if err := h.ServiceAccounts.CreateServiceAccount(r.Context(), &sa); err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error membuat service account: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal membuat service account. Nama mungkin sudah dipakai.")
+		return
+	}
+
+	key, meta, err := h.issueAPIKey(r.Context(), sa.ID, req.ExpiresInDays)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error membuat API key %s: %v", sa.ID, err)
+		respondWithError(w, http.StatusInternalServerError, "Service account dibuat tetapi gagal menerbitkan API key")
+		return
+	}
+	sa.Keys = []models.APIKey{*meta}
+
+	h.recorder.Record(r, audit.ActionServiceAccountCreate, sa.ID, nil, sa)
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":         "Service account dibuat. Simpan API key ini, nilainya hanya ditampilkan sekali.",
+		"service_account": sa,
+		"api_key":         key,
+	})
+}
+
+// HandleListServiceAccounts menangani GET /service-accounts
+func (h *Handler) HandleListServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	accounts, err := h.ServiceAccounts.ListServiceAccounts(r.Context())
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error list service account: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal mengambil daftar service account")
+		return
+	}
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"total_data": len(accounts),
+		"data":       accounts,
+	})
+}
+
+// HandleGetServiceAccount menangani GET /service-accounts/{id}
+func (h *Handler) HandleGetServiceAccount(w http.ResponseWriter, r *http.Request) {
+	sa, err := h.ServiceAccounts.GetServiceAccount(r.Context(), mux.Vars(r)["id"])
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Service account tidak ditemukan")
+		return
+	}
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error ambil service account: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal mengambil service account")
+		return
+	}
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(sa)
+}
+
+// HandleUpdateServiceAccount menangani PUT /service-accounts/{id}: ubah nama, scope, atau nonaktifkan
+func (h *Handler) HandleUpdateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var req ServiceAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, utils.ErrMsgInvalidPayload)
+		return
+	}
+	if msg := validateServiceAccountRequest(&req); msg != "" {
+		respondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	before, err := h.ServiceAccounts.GetServiceAccount(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Service account tidak ditemukan")
+		return
+	}
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error ambil service account %s: %v", id, err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal mengambil service account")
+		return
+	}
+
+	err = h.ServiceAccounts.UpdateServiceAccount(r.Context(), id, req.Name, req.Description, req.Scopes, req.Disabled)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Service account tidak ditemukan")
+		return
+	}
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error update service account %s: %v", id, err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal memperbarui service account")
+		return
+	}
+
+	after, err := h.ServiceAccounts.GetServiceAccount(r.Context(), id)
+	if err != nil {
+		log.Printf("AUDIT: gagal snapshot service account %s: %v", id, err)
+	}
+	h.recorder.Record(r, audit.ActionServiceAccountUpdate, id, before, after)
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Service account berhasil diperbarui"})
+}
+
+// HandleDeleteServiceAccount menangani DELETE /service-accounts/{id}; semua key-nya ikut tidak berlaku
+func (h *Handler) HandleDeleteServiceAccount(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	before, err := h.ServiceAccounts.GetServiceAccount(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Service account tidak ditemukan")
+		return
+	}
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error ambil service account %s: %v", id, err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal mengambil service account")
+		return
+	}
+
+	if err := h.ServiceAccounts.DeleteServiceAccount(r.Context(), id); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error hapus service account %s: %v", id, err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal menghapus service account")
+		return
+	}
+
+	h.recorder.Record(r, audit.ActionServiceAccountDelete, id, before, nil)
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Service account berhasil dihapus"})
+}
+
+// HandleCreateAPIKey menangani POST /service-accounts/{id}/keys: menerbitkan key tambahan (rotasi)
+func (h *Handler) HandleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var req CreateAPIKeyRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, utils.ErrMsgInvalidPayload)
+			return
+		}
+	}
+	if req.ExpiresInDays < 0 {
+		respondWithError(w, http.StatusBadRequest, "expires_in_days tidak boleh negatif.")
+		return
+	}
+
+	if _, err := h.ServiceAccounts.GetServiceAccount(r.Context(), id); err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Service account tidak ditemukan")
+			return
+		}
+		log.Printf("Error ambil service account %s: %v", id, err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal mengambil service account")
+		return
+	}
+
+	key, meta, err := h.issueAPIKey(r.Context(), id, req.ExpiresInDays)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error membuat API key %s: %v", id, err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal menerbitkan API key")
+		return
+	}
+
+	h.recorder.Record(r, audit.ActionAPIKeyCreate, id, nil, meta)
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "API key diterbitkan. Simpan nilainya, hanya ditampilkan sekali.",
+		"key":     meta,
+		"api_key": key,
+	})
+}
+
+// HandleRevokeAPIKey menangani DELETE /service-accounts/{id}/keys/{keyId}
+func (h *Handler) HandleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, keyID := vars["id"], vars["keyId"]
+
+	err := h.ServiceAccounts.RevokeAPIKey(r.Context(), id, keyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "API key tidak ditemukan atau sudah dicabut")
+		return
+	}
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error cabut API key %s: %v", keyID, err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal mencabut API key")
+		return
+	}
+
+	h.recorder.Record(r, audit.ActionAPIKeyRevoke, id, map[string]string{"key_id": keyID}, nil)
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "API key berhasil dicabut"})
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"go-sis-be/internal/audit"
+	"go-sis-be/internal/utils"
+	"go-sis-be/middleware"
+
+	"github.com/gorilla/mux"
+)
+
+// HandleListMySessions menangani GET /me/sessions: daftar sesi aktif milik user yang login
+func (h *Handler) HandleListMySessions(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	sessions, err := h.Sessions.ListActiveSessions(r.Context(), claims.UID)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error list sesi %s: %v", claims.UID, err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal mengambil daftar sesi")
+		return
+	}
+
+	// Tandai sesi yang sedang dipakai request ini
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == claims.SessionID
+	}
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"total_data": len(sessions),
+		"data":       sessions,
+	})
+}
+
+// HandleRevokeMySession menangani DELETE /me/sessions/{id}: logout satu perangkat milik sendiri
+func (h *Handler) HandleRevokeMySession(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	sessionID := mux.Vars(r)["id"]
+
+	err := h.Sessions.RevokeSession(r.Context(), claims.UID, sessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Sesi tidak ditemukan")
+		return
+	}
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error mencabut sesi %s: %v", sessionID, err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal mencabut sesi")
+		return
+	}
+
+	h.recorder.Record(r, audit.ActionSessionRevoke, claims.UID, map[string]string{"session_id": sessionID}, nil)
+
+	if sessionID == claims.SessionID {
+		h.clearRefreshCookie(w)
+	}
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Sesi berhasil dicabut"})
+}
+
+// HandleRevokeUserSessions menangani DELETE /users/{uid}/sessions: admin mengeluarkan user dari semua perangkat
+func (h *Handler) HandleRevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	uid := mux.Vars(r)["uid"]
+
+	if _, err := h.Users.GetRoleIDByUID(r.Context(), uid); err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		if err.Error() == "UID tidak ditemukan" {
+			respondWithError(w, http.StatusNotFound, "User tidak ditemukan")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Gagal memverifikasi user")
+		return
+	}
+
+	count, err := h.Sessions.RevokeAllSessions(r.Context(), uid)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error mencabut semua sesi %s: %v", uid, err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal mencabut sesi user")
+		return
+	}
+
+	// Access token yang masih beredar ikut mati seketika
+	if _, err := h.Tokens.BumpTokenVersion(r.Context(), uid); err != nil {
+		log.Printf("Error menaikkan token version %s: %v", uid, err)
+	}
+
+	log.Printf("Semua sesi user %s dicabut (%d sesi)", uid, count)
+	h.recorder.Record(r, audit.ActionSessionRevokeAll, uid, nil, map[string]int64{"revoked_sessions": count})
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":          "Semua sesi user berhasil dicabut",
+		"revoked_sessions": count,
+	})
+}
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"go-sis-be/internal/audit"
+	"go-sis-be/internal/models"
+	"go-sis-be/internal/utils"
+	"go-sis-be/middleware"
+)
+
+const (
+	recoveryCodeCount     = 10
+	maxTwoFactorAttempts  = 5
+	twoFactorInvalidMsg   = "Kode 2FA salah"
+	twoFactorChallengeMsg = "Challenge 2FA tidak valid atau kadaluarsa, silakan login ulang"
+)
+
+type TwoFactorVerifyRequest struct {
+	Code string `json:"code"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code,omitempty"`
+	RecoveryCode   string `json:"recovery_code,omitempty"`
+}
+
+// accessFlagsFor menghitung pembatasan access token untuk user (dipakai saat refresh)
+func (h *Handler) accessFlagsFor(ctx context.Context, ident *models.UserIdentity) (utils.AccessFlags, error) {
+	flags := utils.AccessFlags{MustChangePassword: ident.MustChangePassword}
+	if !h.cfg.TwoFactor.RequiredFor(ident.Role) {
+		return flags, nil
+	}
+
+	enabled, err := h.TwoFactor.IsTwoFactorEnabled(ctx, ident.UID)
+	if err != nil {
+		return flags, err
+	}
+	flags.TwoFactorSetupRequired = !enabled
+	return flags, nil
+}
+
+// HandleTwoFactorSetup menangani POST /me/2fa/setup: membuat secret TOTP baru (belum aktif)
+func (h *Handler) HandleTwoFactorSetup(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, utils.ErrMsgServerError)
+		return
+	}
+
+	if err := h.TwoFactor.SavePendingTwoFactor(r.Context(), claims.UID, secret); err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		if errors.Is(err, models.ErrTwoFactorAlreadyEnabled) {
+			respondWithError(w, http.StatusConflict, "2FA sudah aktif untuk akun ini")
+			return
+		}
+		log.Printf("Error setup 2FA %s: %v", claims.UID, err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal menyiapkan 2FA")
+		return
+	}
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":     "Pindai otpauth_uri di aplikasi authenticator, lalu verifikasi kodenya di /me/2fa/verify",
+		"secret":      secret,
+		"otpauth_uri": utils.TOTPURI(h.cfg.TwoFactor.Issuer, claims.Username, secret),
+	})
+}
+
+// HandleTwoFactorVerify menangani POST /me/2fa/verify: mengaktifkan 2FA dan mengembalikan recovery code
+func (h *Handler) HandleTwoFactorVerify(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req TwoFactorVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, utils.ErrMsgInvalidPayload)
+		return
+	}
+
+	tf, err := h.TwoFactor.GetTwoFactor(r.Context(), claims.UID)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error ambil 2FA %s: %v", claims.UID, err)
+		respondWithError(w, http.StatusInternalServerError, utils.ErrMsgServerError)
+		return
+	}
+	if tf == nil {
+		respondWithError(w, http.StatusBadRequest, "Jalankan /me/2fa/setup terlebih dahulu")
+		return
+	}
+	if tf.Enabled() {
+		respondWithError(w, http.StatusConflict, "2FA sudah aktif untuk akun ini")
+		return
+	}
+
+	if !h.verifyTOTP(r.Context(), tf, req.Code) {
+		respondWithError(w, http.StatusBadRequest, twoFactorInvalidMsg)
+		return
+	}
+
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, utils.ErrMsgServerError)
+		return
+	}
+	if err := h.TwoFactor.EnableTwoFactor(r.Context(), claims.UID, codes); err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusConflict, "2FA sudah aktif untuk akun ini")
+			return
+		}
+		log.Printf("Error aktivasi 2FA %s: %v", claims.UID, err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal mengaktifkan 2FA")
+		return
+	}
+
+	h.Audit.RecordAuthEvent(r.Context(), models.AuthEvent{
+		EventType: models.AuthEventTwoFactorEnabled,
+		UID:       claims.UID,
+		Username:  claims.Username,
+		IPAddress: utils.ClientIP(r),
+	})
+	h.recorder.Record(r, audit.ActionTwoFactorEnable, claims.UID, map[string]bool{"two_factor_enabled": false}, map[string]bool{"two_factor_enabled": true})
+
+	message := "2FA aktif. Simpan recovery code ini di tempat aman, kode hanya ditampilkan sekali."
+	if claims.TwoFactorSetupRequired {
+		message += " Panggil /refresh untuk mendapatkan access token dengan akses penuh."
+	}
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":        message,
+		"recovery_codes": codes,
+	})
+}
+
+// HandleTwoFactorLogin menangani POST /login/2fa: langkah kedua login dengan kode TOTP atau recovery code
+func (h *Handler) HandleTwoFactorLogin(w http.ResponseWriter, r *http.Request) {
+	var req TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, utils.ErrMsgInvalidPayload)
+		return
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		respondWithError(w, http.StatusBadRequest, "Kode 2FA atau recovery code wajib diisi")
+		return
+	}
+
+	challenge, err := utils.ValidateTwoFactorChallenge(req.ChallengeToken)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, twoFactorChallengeMsg)
+		return
+	}
+	if used, err := h.TwoFactor.IsTwoFactorChallengeUsed(r.Context(), challenge.ID); err != nil || used {
+		respondWithError(w, http.StatusUnauthorized, twoFactorChallengeMsg)
+		return
+	}
+
+	ident, err := h.Users.GetUserIdentityByUID(r.Context(), challenge.UID)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		respondWithError(w, http.StatusUnauthorized, twoFactorChallengeMsg)
+		return
+	}
+
+	// Kode 2FA yang salah ikut dihitung di penguncian login per username/IP, supaya
+	// pemegang password tidak bisa menebak kode tanpa batas dengan login ulang
+	clientIP := utils.ClientIP(r)
+	if ttl := h.LoginAttempts.GetLoginLockTTL(r.Context(), ident.Username, clientIP); ttl > 0 {
+		respondLoginLocked(w, ttl)
+		return
+	}
+
+	tf, err := h.TwoFactor.GetTwoFactor(r.Context(), challenge.UID)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		log.Printf("Error ambil 2FA %s: %v", challenge.UID, err)
+		respondWithError(w, http.StatusInternalServerError, utils.ErrMsgServerError)
+		return
+	}
+	if !tf.Enabled() {
+		respondWithError(w, http.StatusUnauthorized, twoFactorChallengeMsg)
+		return
+	}
+
+	var valid, usedRecovery bool
+	if req.Code != "" {
+		valid = h.verifyTOTP(r.Context(), tf, req.Code)
+	} else {
+		valid, err = h.TwoFactor.UseRecoveryCode(r.Context(), challenge.UID, utils.NormalizeRecoveryCode(req.RecoveryCode))
+		if err != nil {
+			if respondTimeoutError(w, r, err) {
+				return
+			}
+			log.Printf("Error recovery code %s: %v", challenge.UID, err)
+			respondWithError(w, http.StatusInternalServerError, utils.ErrMsgServerError)
+			return
+		}
+		usedRecovery = valid
+	}
+
+	ttl := time.Until(challenge.ExpiresAt.Time)
+	if !valid {
+		h.handleLoginFailure(r.Context(), ident.Username, ident.UID, clientIP)
+		fails, err := h.TwoFactor.RegisterTwoFactorFailure(r.Context(), challenge.ID, ttl)
+		if err == nil && fails >= maxTwoFactorAttempts {
+			// Hanguskan challenge supaya kode tidak bisa ditebak terus-menerus
+			h.TwoFactor.ConsumeTwoFactorChallenge(r.Context(), challenge.ID, ttl)
+			respondWithError(w, http.StatusUnauthorized, twoFactorChallengeMsg)
+			return
+		}
+		respondWithError(w, http.StatusUnauthorized, twoFactorInvalidMsg)
+		return
+	}
+
+	if consumed, err := h.TwoFactor.ConsumeTwoFactorChallenge(r.Context(), challenge.ID, ttl); err != nil || !consumed {
+		respondWithError(w, http.StatusUnauthorized, twoFactorChallengeMsg)
+		return
+	}
+
+	if usedRecovery {
+		remaining, _ := h.TwoFactor.CountRemainingRecoveryCodes(r.Context(), ident.UID)
+		h.Audit.RecordAuthEvent(r.Context(), models.AuthEvent{
+			EventType: models.AuthEventRecoveryCodeUsed,
+			UID:       ident.UID,
+			Username:  ident.Username,
+			IPAddress: clientIP,
+			Detail:    fmt.Sprintf("sisa recovery code: %d", remaining),
+		})
+	}
+
+	flags := utils.AccessFlags{MustChangePassword: ident.MustChangePassword}
+	h.issueLoginTokens(w, r, ident.UID, ident.Username, ident.Role, challenge.Device, ident.TokenVersion, flags)
+}
+
+// verifyTOTP memvalidasi kode TOTP sekaligus menolak kode yang sudah pernah dipakai
+func (h *Handler) verifyTOTP(ctx context.Context, tf *models.TwoFactor, code string) bool {
+	step, ok := utils.ValidateTOTP(tf.Secret, code, time.Now())
+	if !ok {
+		return false
+	}
+
+	fresh, err := h.TwoFactor.MarkTOTPStepUsed(ctx, tf.UID, step)
+	if err != nil {
+		log.Printf("Redis error cek replay TOTP: %v", err)
+		return false
+	}
+	return fresh
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"go-sis-be/internal/audit"
 	"go-sis-be/internal/models"
 	"go-sis-be/internal/utils"
 	"log"
@@ -12,7 +13,7 @@ import (
 	"github.com/gorilla/mux"
 )
 
-func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) CreateUserHandler(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateUserRequest
 
 	log.Println("Menerima request POST /users")
@@ -24,8 +25,14 @@ func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userResponse, err := models.CreateUser(&req)
+	userResponse, err := h.Users.CreateUser(r.Context(), &req)
 	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		if respondPasswordPolicyError(w, err) {
+			return
+		}
 		log.Printf("Error creating user: %v\n", err)
 		http.Error(w, "Gagal membuat user: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -33,6 +40,7 @@ func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Log sukses
 	log.Printf("User berhasil dibuat: %s\n", userResponse.Username)
+	h.recorder.Record(r, audit.ActionUserCreate, userResponse.UID, nil, userResponse)
 
 	// Kirim Response Sukses
 	w.Header().Set(utils.ContentHeader, utils.Mime)
@@ -40,7 +48,7 @@ func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(userResponse)
 }
 
-func GetAllUsersHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetAllUsersHandler(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 
 	// Default Pagination
@@ -52,9 +60,12 @@ func GetAllUsersHandler(w http.ResponseWriter, r *http.Request) {
 	roleID := utils.ParseIntQuery(q.Get("role_id"), 0) // role_id=2 untuk Guru, role_id=3 untuk Murid
 
 	// 2. Hit Model Logic
-	results, totalCount, err := models.GetAllUsers(page, limit, search, roleID)
+	results, totalCount, err := h.Users.GetAllUsers(r.Context(), page, limit, search, roleID)
 
 	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{
 			"error": fmt.Sprintf("Gagal mengambil daftar pengguna: %s", err.Error()),
@@ -75,12 +86,66 @@ func GetAllUsersHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
-func DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
+type ChangeRoleRequest struct {
+	RoleID int `json:"role_id"`
+}
+
+// HandleChangeUserRole menangani PUT /users/{uid}/role. Semua token user dengan role lama langsung ditolak.
+func (h *Handler) HandleChangeUserRole(w http.ResponseWriter, r *http.Request) {
+	uid := mux.Vars(r)["uid"]
+
+	var req ChangeRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, utils.ErrMsgInvalidPayload)
+		return
+	}
+	switch req.RoleID {
+	case models.ADMIN_ROLE_ID, models.TEACHER_ROLE_ID, models.STUDENT_ROLE_ID, models.PARENT_ROLE_ID:
+	default:
+		respondWithError(w, http.StatusBadRequest, "role_id tidak valid")
+		return
+	}
+
+	before, err := h.Users.GetRoleIDByUID(r.Context(), uid)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		respondWithError(w, http.StatusNotFound, "User tidak ditemukan")
+		return
+	}
+
+	if err := h.Users.ChangeUserRole(r.Context(), uid, req.RoleID); err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "User tidak ditemukan")
+			return
+		}
+		log.Printf("Error ganti role %s: %v", uid, err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal mengganti role")
+		return
+	}
+
+	h.recorder.Record(r, audit.ActionUserRoleChange, uid, map[string]int{"role_id": before}, map[string]int{"role_id": req.RoleID})
+
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Role user berhasil diganti"})
+}
+
+func (h *Handler) DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	uid := vars["uid"]
 
-	err := models.DeleteUser(uid)
+	before, _ := h.Profiles.GetProfileAndFormat(r.Context(), uid)
+
+	err := h.Users.DeleteUser(r.Context(), uid)
 	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
 		if err == sql.ErrNoRows {
 			http.Error(w, "User tidak ditemukan", http.StatusNotFound)
 			return
@@ -89,6 +154,8 @@ func DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.recorder.Record(r, audit.ActionUserDelete, uid, before, nil)
+
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("User berhasil dihapus"))
 }
@@ -3,15 +3,45 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"go-sis-be/internal/audit"
 	"go-sis-be/internal/models"
+	"go-sis-be/internal/policy"
 	"go-sis-be/internal/utils"
+	"go-sis-be/middleware"
+	"log"
 	"net/http"
 
 	"github.com/gorilla/mux"
 )
 
+// authorizeProfile menjalankan policy kepemilikan profil dan menulis respons 403 jika ditolak.
+// Mengembalikan true jika request boleh dilanjutkan.
+func (h *Handler) authorizeProfile(w http.ResponseWriter, r *http.Request, targetUID string, action policy.Action) bool {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return false
+	}
+
+	actor := policy.Actor{UID: claims.UID, Role: claims.Role}
+	allowed, err := policy.CanAccessProfile(r.Context(), h.Relations, actor, targetUID, action)
+	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return false
+		}
+		log.Printf("Error policy profil (%s -> %s): %v", claims.UID, targetUID, err)
+		respondWithError(w, http.StatusInternalServerError, "Gagal memverifikasi hak akses")
+		return false
+	}
+	if !allowed {
+		respondWithError(w, http.StatusForbidden, "Anda tidak memiliki akses ke profil ini")
+		return false
+	}
+	return true
+}
+
 // HandleGetUserDetail menangani permintaan GET /users/{uid}
-func HandleGetUserDetail(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) HandleGetUserDetail(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	uid := vars["uid"]
 
@@ -21,9 +51,16 @@ func HandleGetUserDetail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	finalData, err := models.GetProfileAndFormat(uid)
+	if !h.authorizeProfile(w, r, uid, policy.ActionRead) {
+		return
+	}
+
+	finalData, err := h.Profiles.GetProfileAndFormat(r.Context(), uid)
 
 	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
 		if err.Error() == "profil tidak ditemukan" {
 			w.WriteHeader(http.StatusNotFound)
 			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
@@ -45,13 +82,20 @@ func HandleGetUserDetail(w http.ResponseWriter, r *http.Request) {
 }
 
 // HandleEditProfile menangani permintaan PUT /users/{uid} untuk Guru dan Murid secara terpadu.
-func HandleEditProfile(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) HandleEditProfile(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	uid := vars["uid"]
 
+	if !h.authorizeProfile(w, r, uid, policy.ActionEdit) {
+		return
+	}
+
 	// 1. Dapatkan Role ID (Menggunakan fungsi yang telah disepakati)
-	roleID, err := models.GetRoleIDByUID(uid)
+	roleID, err := h.Users.GetRoleIDByUID(r.Context(), uid)
 	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
 		if err.Error() == "UID tidak ditemukan" {
 			w.WriteHeader(http.StatusNotFound)
 			json.NewEncoder(w).Encode(map[string]string{"error": "Profil tidak ditemukan"})
@@ -62,6 +106,12 @@ func HandleEditProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Snapshot sebelum diubah untuk audit log
+	before, err := h.Profiles.GetProfileAndFormat(r.Context(), uid)
+	if err != nil {
+		log.Printf("AUDIT: gagal snapshot profil %s: %v", uid, err)
+	}
+
 	// 2. Switch/Case untuk memanggil logic Update yang sesuai
 	var editErr error
 
@@ -73,7 +123,7 @@ func HandleEditProfile(w http.ResponseWriter, r *http.Request) {
 			json.NewEncoder(w).Encode(map[string]string{"error": "Payload Teacher tidak valid"})
 			return
 		}
-		editErr = models.EditTeacherProfile(uid, &req)
+		editErr = h.Profiles.EditTeacherProfile(r.Context(), uid, &req)
 
 	case models.STUDENT_ROLE_ID:
 		var req models.EditStudentRequest
@@ -82,7 +132,7 @@ func HandleEditProfile(w http.ResponseWriter, r *http.Request) {
 			json.NewEncoder(w).Encode(map[string]string{"error": "Payload Student tidak valid"})
 			return
 		}
-		editErr = models.EditStudentProfile(uid, &req)
+		editErr = h.Profiles.EditStudentProfile(r.Context(), uid, &req)
 
 	default:
 		w.WriteHeader(http.StatusForbidden)
@@ -91,24 +141,40 @@ func HandleEditProfile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if editErr != nil {
+		if respondTimeoutError(w, r, editErr) {
+			return
+		}
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("Gagal memperbarui data: %s", editErr.Error())})
 		return
 	}
 
+	after, err := h.Profiles.GetProfileAndFormat(r.Context(), uid)
+	if err != nil {
+		log.Printf("AUDIT: gagal snapshot profil %s: %v", uid, err)
+	}
+	h.recorder.Record(r, audit.ActionProfileUpdate, uid, before, after)
+
 	w.Header().Set(utils.ContentHeader, utils.Mime)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Profil berhasil diperbarui"})
 }
 
 // HandleDeleteProfile menangani permintaan DELETE /users/{uid} untuk Guru dan Murid secara terpadu.
-func HandleDeleteProfile(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) HandleDeleteProfile(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	uid := vars["uid"]
 
+	if !h.authorizeProfile(w, r, uid, policy.ActionDelete) {
+		return
+	}
+
 	// 1. Dapatkan Role ID (Menggunakan fungsi yang telah disepakati)
-	roleID, err := models.GetRoleIDByUID(uid)
+	roleID, err := h.Users.GetRoleIDByUID(r.Context(), uid)
 	if err != nil {
+		if respondTimeoutError(w, r, err) {
+			return
+		}
 		if err.Error() == "UID tidak ditemukan" {
 			w.WriteHeader(http.StatusNotFound)
 			json.NewEncoder(w).Encode(map[string]string{"error": "Profil tidak ditemukan"})
@@ -119,14 +185,20 @@ func HandleDeleteProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Snapshot sebelum dihapus untuk audit log
+	before, err := h.Profiles.GetProfileAndFormat(r.Context(), uid)
+	if err != nil {
+		log.Printf("AUDIT: gagal snapshot profil %s: %v", uid, err)
+	}
+
 	// 2. Switch/Case untuk memanggil logic Delete yang sesuai
 	var deleteErr error
 
 	switch roleID {
 	case models.TEACHER_ROLE_ID:
-		deleteErr = models.DeleteTeacherProfile(uid)
+		deleteErr = h.Profiles.DeleteTeacherProfile(r.Context(), uid)
 	case models.STUDENT_ROLE_ID:
-		deleteErr = models.DeleteStudentProfile(uid)
+		deleteErr = h.Profiles.DeleteStudentProfile(r.Context(), uid)
 	default:
 		w.WriteHeader(http.StatusForbidden)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Peran ini tidak diizinkan untuk dihapus"})
@@ -135,11 +207,16 @@ func HandleDeleteProfile(w http.ResponseWriter, r *http.Request) {
 
 	// 3. Handle hasil Mutasi
 	if deleteErr != nil {
+		if respondTimeoutError(w, r, deleteErr) {
+			return
+		}
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("Gagal menghapus profil: %s", deleteErr.Error())})
 		return
 	}
 
+	h.recorder.Record(r, audit.ActionProfileDelete, uid, before, nil)
+
 	w.Header().Set(utils.ContentHeader, utils.Mime)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Profil berhasil dihapus"})
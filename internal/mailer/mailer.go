@@ -0,0 +1,148 @@
+// Package mailer mengirim email transaksional (reset password, dll) lewat Sender yang bisa diganti.
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message: Satu email teks biasa
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender: Kanal pengiriman email. Implementasi dipilih lewat Config.Driver.
+type Sender interface {
+	Send(msg Message) error
+}
+
+// Driver pengiriman email (MAIL_DRIVER)
+const (
+	DriverLog  = "log"
+	DriverFile = "file"
+	DriverSMTP = "smtp"
+)
+
+// Config: Pengaturan pengiriman email
+type Config struct {
+	Driver       string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	From         string
+	FileDir      string // Folder tujuan untuk driver file
+}
+
+// New memilih Sender berdasarkan cfg.Driver: "smtp", "file", atau "log" (default)
+func New(cfg Config) Sender {
+	switch cfg.Driver {
+	case DriverSMTP:
+		return &SMTPSender{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.From,
+		}
+	case DriverFile:
+		dir := cfg.FileDir
+		if dir == "" {
+			dir = "mail_outbox"
+		}
+		return &FileSender{Dir: dir}
+	default:
+		return LogSender{}
+	}
+}
+
+// LogSender hanya mencatat penerima dan subjek email ke log (untuk development).
+// Isi email tidak ditulis karena bisa memuat token sekali pakai (misalnya link reset
+// password); gunakan driver file untuk membaca isi email secara lokal.
+type LogSender struct{}
+
+func (LogSender) Send(msg Message) error {
+	log.Printf("[MAIL] To: %s | Subject: %s | Body: %d byte (disembunyikan)", msg.To, msg.Subject, len(msg.Body))
+	return nil
+}
+
+// FileSender menyimpan setiap email sebagai file .eml di Dir (untuk development dan test)
+type FileSender struct {
+	Dir string
+
+	mu  sync.Mutex
+	seq int
+}
+
+func (s *FileSender) Send(msg Message) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return fmt.Errorf("gagal membuat folder mail: %w", err)
+	}
+
+	s.mu.Lock()
+	s.seq++
+	name := fmt.Sprintf("%s-%03d.eml", time.Now().Format("20060102-150405"), s.seq)
+	s.mu.Unlock()
+
+	path := filepath.Join(s.Dir, name)
+	if err := os.WriteFile(path, []byte(formatMessage("", msg)), 0o600); err != nil {
+		return fmt.Errorf("gagal menulis file mail: %w", err)
+	}
+	return nil
+}
+
+// SMTPSender mengirim email lewat server SMTP (PLAIN auth jika Username diisi)
+type SMTPSender struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (s *SMTPSender) Send(msg Message) error {
+	if s.Host == "" || s.From == "" {
+		return fmt.Errorf("SMTP_HOST dan MAIL_FROM wajib diisi")
+	}
+	port := s.Port
+	if port == "" {
+		port = "587"
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+
+	addr := s.Host + ":" + port
+	if err := smtp.SendMail(addr, auth, s.From, []string{msg.To}, []byte(formatMessage(s.From, msg))); err != nil {
+		return fmt.Errorf("gagal mengirim email: %w", err)
+	}
+	return nil
+}
+
+// headerSanitizer membuang CR/LF agar nilai header tidak bisa menyisipkan header lain
+var headerSanitizer = strings.NewReplacer("\r", "", "\n", "")
+
+// formatMessage menyusun email RFC 5322 sederhana
+func formatMessage(from string, msg Message) string {
+	var b strings.Builder
+	if from != "" {
+		b.WriteString("From: " + headerSanitizer.Replace(from) + "\r\n")
+	}
+	b.WriteString("To: " + headerSanitizer.Replace(msg.To) + "\r\n")
+	b.WriteString("Subject: " + headerSanitizer.Replace(msg.Subject) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(msg.Body)
+	return b.String()
+}
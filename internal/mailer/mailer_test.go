@@ -0,0 +1,72 @@
+package mailer
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileSenderWritesMessage(t *testing.T) {
+	dir := t.TempDir()
+	s := &FileSender{Dir: dir}
+
+	msg := Message{To: "wali@sis.id", Subject: "Reset Password", Body: "token: abc123"}
+	if err := s.Send(msg); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+	if err := s.Send(msg); err != nil {
+		t.Fatalf("Send() kedua error: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("jumlah file = %d, want 2", len(files))
+	}
+
+	content, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"To: wali@sis.id", "Subject: Reset Password", "token: abc123"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("isi email tidak memuat %q:\n%s", want, content)
+		}
+	}
+}
+
+func TestNewDefaultsToLog(t *testing.T) {
+	if _, ok := New(Config{}).(LogSender); !ok {
+		t.Errorf("New() tanpa driver harus LogSender")
+	}
+
+	if _, ok := New(Config{Driver: DriverFile, FileDir: t.TempDir()}).(*FileSender); !ok {
+		t.Errorf("New() dengan driver file harus *FileSender")
+	}
+}
+
+func TestLogSenderOmitsBody(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	msg := Message{To: "wali@sis.id", Subject: "Reset Password", Body: "https://sis.id/reset?token=abc123"}
+	if err := (LogSender{}).Send(msg); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"wali@sis.id", "Reset Password"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log tidak memuat %q: %s", want, out)
+		}
+	}
+	if strings.Contains(out, "abc123") {
+		t.Errorf("log tidak boleh memuat token: %s", out)
+	}
+}
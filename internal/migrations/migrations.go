@@ -0,0 +1,322 @@
+// Package migrations menjalankan migrasi skema SQL yang di-embed ke binary.
+// Versi yang sudah diterapkan dicatat di tabel schema_migrations.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// SourceDir: Lokasi file migrasi di repo, tujuan default "migrate create"
+const SourceDir = "internal/migrations/sql"
+
+// lockKey: Kunci pg_advisory_lock agar beberapa replica yang start bersamaan tidak migrasi berbarengan
+const lockKey int64 = 0x5349534d4947 // "SISMIG"
+
+// fileName: <versi>_<nama>.<up|down>.sql, mis. 0001_core_schema.up.sql
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration: Satu versi skema beserta SQL naik dan turunnya
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status: Migrasi beserta waktu diterapkan (nil = belum diterapkan)
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	Missing   bool       `json:"missing,omitempty"` // Tercatat di database tapi file-nya tidak ada di binary ini
+}
+
+// Load membaca semua migrasi yang di-embed, terurut dari versi terkecil
+func Load() ([]Migration, error) {
+	return load(files, "sql")
+}
+
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		m := fileName.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("nama file migrasi tidak valid: %s", e.Name())
+		}
+		version, _ := strconv.ParseInt(m[1], 10, 64)
+		body, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != m[2] {
+			return nil, fmt.Errorf("versi %d dipakai dua nama: %s dan %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if strings.TrimSpace(mig.Up) == "" || strings.TrimSpace(mig.Down) == "" {
+			return nil, fmt.Errorf("migrasi %04d_%s wajib punya file up dan down", mig.Version, mig.Name)
+		}
+		list = append(list, *mig)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// Up menerapkan semua migrasi yang belum diterapkan. Mengembalikan jumlah migrasi yang dijalankan.
+func Up(ctx context.Context, db *sql.DB) (int, error) {
+	migrations, err := Load()
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	err = withLock(ctx, db, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			if _, ok := done[m.Version]; ok {
+				continue
+			}
+			if err := run(ctx, conn, m, m.Up, true); err != nil {
+				return err
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down membatalkan steps migrasi terakhir yang sudah diterapkan (terbaru lebih dulu)
+func Down(ctx context.Context, db *sql.DB, steps int) (int, error) {
+	migrations, err := Load()
+	if err != nil {
+		return 0, err
+	}
+	byVersion := map[int64]Migration{}
+	for _, m := range migrations {
+		byVersion[m.Version] = m
+	}
+
+	reverted := 0
+	err = withLock(ctx, db, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		versions := make([]int64, 0, len(done))
+		for v := range done {
+			versions = append(versions, v)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		for _, v := range versions {
+			if reverted >= steps {
+				break
+			}
+			m, ok := byVersion[v]
+			if !ok {
+				return fmt.Errorf("migrasi versi %d tidak ada di binary ini, tidak bisa di-rollback", v)
+			}
+			if err := run(ctx, conn, m, m.Down, false); err != nil {
+				return err
+			}
+			reverted++
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// List mengembalikan status semua migrasi: yang di-embed dan yang tercatat di database
+func List(ctx context.Context, db *sql.DB) ([]Status, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	done, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Status, 0, len(migrations))
+	for _, m := range migrations {
+		st := Status{Version: m.Version, Name: m.Name}
+		if at, ok := done[m.Version]; ok {
+			at := at
+			st.AppliedAt = &at
+			delete(done, m.Version)
+		}
+		list = append(list, st)
+	}
+	for v, at := range done {
+		at := at
+		list = append(list, Status{Version: v, AppliedAt: &at, Missing: true})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// Create membuat pasangan file up/down kosong dengan versi berikutnya di dir.
+// Hanya berguna saat development: file baru ikut ter-embed setelah build ulang.
+func Create(dir, name string) (string, string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(name, "_")
+	name = strings.Trim(name, "_")
+	if name == "" {
+		return "", "", fmt.Errorf("nama migrasi wajib diisi")
+	}
+
+	existing, err := load(os.DirFS(dir), ".")
+	if err != nil {
+		return "", "", err
+	}
+	next := int64(1)
+	if len(existing) > 0 {
+		next = existing[len(existing)-1].Version + 1
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%04d_%s", next, name))
+	upPath, downPath := base+".up.sql", base+".down.sql"
+	header := fmt.Sprintf("-- %04d_%s\n", next, name)
+	if err := os.WriteFile(upPath, []byte(header), 0o644); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(downPath, []byte(header), 0o644); err != nil {
+		return "", "", err
+	}
+	return upPath, downPath, nil
+}
+
+// withLock menjalankan fn di satu koneksi yang memegang advisory lock migrasi
+func withLock(ctx context.Context, db *sql.DB, fn func(conn *sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("gagal mengambil lock migrasi: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockKey); err != nil {
+			log.Printf("Gagal melepas lock migrasi: %v", err)
+		}
+	}()
+
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func ensureVersionTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    BIGINT PRIMARY KEY,
+			name       VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`)
+	if err != nil {
+		return fmt.Errorf("gagal membuat tabel schema_migrations: %w", err)
+	}
+	return nil
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	done := map[int64]time.Time{}
+	for rows.Next() {
+		var v int64
+		var at time.Time
+		if err := rows.Scan(&v, &at); err != nil {
+			return nil, err
+		}
+		done[v] = at
+	}
+	return done, rows.Err()
+}
+
+// run menjalankan satu file migrasi dan mencatat/menghapus versinya dalam satu transaksi
+func run(ctx context.Context, conn *sql.Conn, m Migration, body string, up bool) error {
+	direction := "up"
+	if !up {
+		direction = "down"
+	}
+	start := time.Now()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, body); err != nil {
+		return fmt.Errorf("migrasi %04d_%s (%s) gagal: %w", m.Version, m.Name, direction, err)
+	}
+	if up {
+		_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name)
+	} else {
+		_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("gagal mencatat versi %d: %w", m.Version, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Printf("Migrasi %04d_%s (%s) selesai dalam %s", m.Version, m.Name, direction, time.Since(start).Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,79 @@
+package migrations
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmbeddedMigrationsComplete(t *testing.T) {
+	list, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(list) == 0 {
+		t.Fatal("tidak ada migrasi yang di-embed")
+	}
+	for i, m := range list {
+		if m.Version != int64(i+1) {
+			t.Errorf("versi harus berurutan tanpa celah: posisi %d berisi versi %d", i, m.Version)
+		}
+	}
+
+	// Tabel yang dipakai aplikasi harus dibuat oleh migrasi
+	var all strings.Builder
+	for _, m := range list {
+		all.WriteString(m.Up)
+	}
+	for _, table := range []string{
+		"roles", "login_users", "person", "student_details", "teacher_details",
+		"class_students", "class_teachers", "sessions", "refresh_tokens", "auth_events",
+		"user_two_factor", "two_factor_recovery_codes", "password_history",
+		"audit_logs", "service_accounts", "api_keys", "user_identities",
+	} {
+		if !strings.Contains(all.String(), "CREATE TABLE IF NOT EXISTS "+table+" (") {
+			t.Errorf("tabel %s tidak dibuat oleh migrasi mana pun", table)
+		}
+	}
+	for _, obj := range []string{"SEQUENCE IF NOT EXISTS nis_seq", "FUNCTION calculate_service"} {
+		if !strings.Contains(all.String(), obj) {
+			t.Errorf("%s tidak dibuat oleh migrasi mana pun", obj)
+		}
+	}
+}
+
+func TestLoadRejectsIncompletePair(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/0001_a.up.sql":   {Data: []byte("SELECT 1;")},
+		"sql/0001_a.down.sql": {Data: []byte("SELECT 1;")},
+		"sql/0002_b.up.sql":   {Data: []byte("SELECT 1;")},
+	}
+	if _, err := load(fsys, "sql"); err == nil {
+		t.Fatal("migrasi tanpa file down harus ditolak")
+	}
+
+	fsys["sql/0002_b.down.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	fsys["sql/README.md"] = &fstest.MapFile{Data: []byte("x")}
+	if _, err := load(fsys, "sql"); err == nil {
+		t.Fatal("file dengan nama tidak valid harus ditolak")
+	}
+}
+
+func TestCreateUsesNextVersion(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"0001_init.up.sql", "0001_init.down.sql"} {
+		if err := os.WriteFile(filepath.Join(dir, f), []byte("SELECT 1;"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	up, down, err := Create(dir, "Tambah Kolom NISN")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if filepath.Base(up) != "0002_tambah_kolom_nisn.up.sql" || filepath.Base(down) != "0002_tambah_kolom_nisn.down.sql" {
+		t.Fatalf("nama file salah: %s %s", up, down)
+	}
+}
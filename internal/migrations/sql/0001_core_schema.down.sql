@@ -0,0 +1,19 @@
+DROP TABLE IF EXISTS class_teachers;
+DROP TABLE IF EXISTS class_students;
+DROP TABLE IF EXISTS classes;
+DROP FUNCTION IF EXISTS calculate_service(DATE);
+DROP TABLE IF EXISTS teacher_details;
+DROP TABLE IF EXISTS student_details;
+DROP SEQUENCE IF EXISTS nis_seq;
+DROP TABLE IF EXISTS person;
+DROP TABLE IF EXISTS login_users;
+DROP TABLE IF EXISTS roles;
+
+DROP TYPE IF EXISTS education_type;
+DROP TYPE IF EXISTS functional_position_type;
+DROP TYPE IF EXISTS employment_status_type;
+DROP TYPE IF EXISTS job_type;
+DROP TYPE IF EXISTS family_status_type;
+DROP TYPE IF EXISTS marital_status_type;
+DROP TYPE IF EXISTS religion_type;
+DROP TYPE IF EXISTS gender_type;
@@ -0,0 +1,158 @@
+-- Skema inti SIS: role, akun login, data pribadi, detail murid/guru dan relasi kelas.
+-- Memakai IF NOT EXISTS supaya database lama yang dibuat manual bisa langsung diadopsi.
+-- Butuh PostgreSQL 13+ (gen_random_uuid bawaan).
+
+DO $$ BEGIN
+	CREATE TYPE gender_type AS ENUM ('Laki-laki', 'Perempuan');
+EXCEPTION WHEN duplicate_object THEN NULL; END $$;
+
+DO $$ BEGIN
+	CREATE TYPE religion_type AS ENUM ('Islam', 'Kristen', 'Katolik', 'Hindu', 'Buddha', 'Konghucu');
+EXCEPTION WHEN duplicate_object THEN NULL; END $$;
+
+DO $$ BEGIN
+	CREATE TYPE marital_status_type AS ENUM ('Belum menikah', 'Menikah', 'Single Parent');
+EXCEPTION WHEN duplicate_object THEN NULL; END $$;
+
+DO $$ BEGIN
+	CREATE TYPE family_status_type AS ENUM ('Anak Kandung', 'Anak Tiri', 'Anak Angkat', 'Lainnya');
+EXCEPTION WHEN duplicate_object THEN NULL; END $$;
+
+DO $$ BEGIN
+	CREATE TYPE job_type AS ENUM (
+		'Ibu Rumah Tangga', 'PNS', 'TNI/Polri', 'BUMN/BUMD', 'Karyawan Swasta',
+		'Petani/Pekebun', 'Nelayan', 'Wiraswasta', 'Tidak Bekerja', 'Lainnya'
+	);
+EXCEPTION WHEN duplicate_object THEN NULL; END $$;
+
+DO $$ BEGIN
+	CREATE TYPE employment_status_type AS ENUM ('PNS', 'PPPK', 'Kontrak Yayasan', 'Guru Tamu', 'Honorer Sekolah', 'Lainnya');
+EXCEPTION WHEN duplicate_object THEN NULL; END $$;
+
+DO $$ BEGIN
+	CREATE TYPE functional_position_type AS ENUM ('Guru Kelas', 'Guru Mata Pelajaran', 'Kepala Sekolah', 'Lainnya');
+EXCEPTION WHEN duplicate_object THEN NULL; END $$;
+
+DO $$ BEGIN
+	CREATE TYPE education_type AS ENUM ('SMA/SMK/MA', 'D1', 'D2', 'D3', 'S1', 'S2');
+EXCEPTION WHEN duplicate_object THEN NULL; END $$;
+
+CREATE TABLE IF NOT EXISTS roles (
+	id         INT PRIMARY KEY,
+	name       VARCHAR(50) NOT NULL UNIQUE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Role adalah data referensi (dipakai RBAC lewat id & nama), bukan data seeder
+INSERT INTO roles (id, name) VALUES
+	(1, 'admin'),
+	(2, 'guru'),
+	(3, 'murid'),
+	(4, 'wali')
+ON CONFLICT (id) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS login_users (
+	uid        UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	username   VARCHAR(100) NOT NULL UNIQUE,
+	pass       TEXT NOT NULL,
+	role_id    INT NOT NULL REFERENCES roles (id),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_users_role_id ON login_users (role_id);
+
+CREATE TABLE IF NOT EXISTS person (
+	uid            UUID PRIMARY KEY REFERENCES login_users (uid) ON DELETE CASCADE,
+	full_name      VARCHAR(255) NOT NULL,
+	birth_date     DATE NOT NULL,
+	nik            CHAR(16) NOT NULL UNIQUE,
+	gender         gender_type NOT NULL,
+	religion       religion_type NOT NULL,
+	marital_status marital_status_type NOT NULL,
+	address        TEXT NOT NULL,
+	phone_number   VARCHAR(20),
+	email          VARCHAR(255)
+);
+
+CREATE INDEX IF NOT EXISTS idx_person_email_lower ON person (LOWER(email));
+
+-- Nomor induk siswa dibuat dari sequence, diformat 10 digit oleh aplikasi
+CREATE SEQUENCE IF NOT EXISTS nis_seq START 1;
+
+CREATE TABLE IF NOT EXISTS student_details (
+	uid              UUID PRIMARY KEY REFERENCES login_users (uid) ON DELETE CASCADE,
+	nis              VARCHAR(20) NOT NULL UNIQUE,
+	nisn             VARCHAR(10) NOT NULL,
+	family_status    family_status_type NOT NULL,
+	child_order      SMALLINT NOT NULL,
+	origin_school    VARCHAR(255) NOT NULL,
+	received_class   VARCHAR(50) NOT NULL,
+	received_date    DATE,
+	father_name      VARCHAR(255) NOT NULL,
+	mother_name      VARCHAR(255) NOT NULL,
+	parent_address   TEXT NOT NULL,
+	father_job       job_type NOT NULL,
+	mother_job       job_type NOT NULL,
+	guardian_name    VARCHAR(255),
+	guardian_address TEXT,
+	guardian_phone   VARCHAR(20),
+	guardian_job     VARCHAR(100)
+);
+
+-- Wali murid (akun role wali) yang boleh melihat data murid ini
+ALTER TABLE student_details ADD COLUMN IF NOT EXISTS parent_uid UUID REFERENCES login_users (uid) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_student_details_parent_uid ON student_details (parent_uid);
+
+CREATE TABLE IF NOT EXISTS teacher_details (
+	uid                   UUID PRIMARY KEY REFERENCES login_users (uid) ON DELETE CASCADE,
+	nip                   VARCHAR(18) UNIQUE,
+	nuptk                 VARCHAR(16),
+	nrg                   VARCHAR(20),
+	functional_position   functional_position_type NOT NULL,
+	employment_status     employment_status_type NOT NULL,
+	rank_class            VARCHAR(50),
+	hire_date             DATE,
+	sk_appointment_number VARCHAR(100),
+	educator_cert_number  VARCHAR(100),
+	last_education        education_type NOT NULL,
+	university            VARCHAR(255) NOT NULL,
+	major                 VARCHAR(255) NOT NULL,
+	graduation_year       VARCHAR(4) NOT NULL,
+	diploma_number        VARCHAR(100)
+);
+
+-- Masa kerja guru (tahun & bulan) sejak hire_date, dipakai di GET profil
+CREATE OR REPLACE FUNCTION calculate_service(start_date DATE)
+RETURNS TABLE (years INT, months INT)
+LANGUAGE sql STABLE AS $$
+	SELECT
+		EXTRACT(YEAR FROM age(CURRENT_DATE, start_date))::INT,
+		EXTRACT(MONTH FROM age(CURRENT_DATE, start_date))::INT
+$$;
+
+-- Kelas & anggotanya, dipakai untuk cek relasi guru-murid (ABAC)
+CREATE TABLE IF NOT EXISTS classes (
+	id            SERIAL PRIMARY KEY,
+	name          VARCHAR(50) NOT NULL,
+	academic_year VARCHAR(9) NOT NULL,
+	created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	UNIQUE (name, academic_year)
+);
+
+CREATE TABLE IF NOT EXISTS class_students (
+	class_id    INT NOT NULL REFERENCES classes (id) ON DELETE CASCADE,
+	student_uid UUID NOT NULL REFERENCES login_users (uid) ON DELETE CASCADE,
+	PRIMARY KEY (class_id, student_uid)
+);
+
+CREATE INDEX IF NOT EXISTS idx_class_students_student ON class_students (student_uid);
+
+CREATE TABLE IF NOT EXISTS class_teachers (
+	class_id    INT NOT NULL REFERENCES classes (id) ON DELETE CASCADE,
+	teacher_uid UUID NOT NULL REFERENCES login_users (uid) ON DELETE CASCADE,
+	PRIMARY KEY (class_id, teacher_uid)
+);
+
+CREATE INDEX IF NOT EXISTS idx_class_teachers_teacher ON class_teachers (teacher_uid);
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS password_history;
+DROP TABLE IF EXISTS two_factor_recovery_codes;
+DROP TABLE IF EXISTS user_two_factor;
+DROP TABLE IF EXISTS auth_events;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS sessions;
+
+DROP TRIGGER IF EXISTS trg_login_users_role_change ON login_users;
+DROP FUNCTION IF EXISTS bump_token_version_on_role_change();
+
+ALTER TABLE login_users DROP COLUMN IF EXISTS token_version;
+ALTER TABLE login_users DROP COLUMN IF EXISTS must_change_password;
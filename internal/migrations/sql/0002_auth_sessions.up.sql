@@ -0,0 +1,93 @@
+-- Keamanan akun: wajib ganti password, pencabutan token, sesi per perangkat,
+-- rotasi refresh token, 2FA, riwayat password dan catatan event keamanan.
+
+ALTER TABLE login_users ADD COLUMN IF NOT EXISTS must_change_password BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE login_users ADD COLUMN IF NOT EXISTS token_version INT NOT NULL DEFAULT 0;
+
+-- Ganti role = hak akses berubah: token lama harus ditolak walau role diubah langsung di database.
+-- Trigger hanya menaikkan token_version di Postgres; cache Redis (token_version:<uid>) tidak ikut
+-- terhapus, jadi token lama baru ditolak setelah cache kadaluarsa (tokenVersionCacheTTL, 1 menit).
+CREATE OR REPLACE FUNCTION bump_token_version_on_role_change()
+RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+	IF NEW.role_id IS DISTINCT FROM OLD.role_id THEN
+		NEW.token_version := OLD.token_version + 1;
+		NEW.updated_at := NOW();
+	END IF;
+	RETURN NEW;
+END
+$$;
+
+DROP TRIGGER IF EXISTS trg_login_users_role_change ON login_users;
+CREATE TRIGGER trg_login_users_role_change
+	BEFORE UPDATE OF role_id ON login_users
+	FOR EACH ROW EXECUTE FUNCTION bump_token_version_on_role_change();
+
+CREATE TABLE IF NOT EXISTS sessions (
+	id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	uid          UUID NOT NULL REFERENCES login_users (uid) ON DELETE CASCADE,
+	device       TEXT NOT NULL DEFAULT '',
+	ip_address   TEXT NOT NULL DEFAULT '',
+	user_agent   TEXT NOT NULL DEFAULT '',
+	created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	last_used_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	revoked_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_uid_active ON sessions (uid) WHERE revoked_at IS NULL;
+
+-- Satu family = satu sesi; token lama yang dipakai ulang mencabut seluruh family
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+	jti         UUID PRIMARY KEY,
+	uid         UUID NOT NULL REFERENCES login_users (uid) ON DELETE CASCADE,
+	family_id   UUID NOT NULL,
+	expires_at  TIMESTAMPTZ NOT NULL,
+	revoked_at  TIMESTAMPTZ,
+	replaced_by UUID,
+	created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens (family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_uid_active ON refresh_tokens (uid) WHERE revoked_at IS NULL;
+
+-- Event keamanan (lockout, 2FA, OIDC, impersonation). uid tidak memakai FK supaya riwayat tetap ada setelah user dihapus.
+CREATE TABLE IF NOT EXISTS auth_events (
+	id         BIGSERIAL PRIMARY KEY,
+	event_type VARCHAR(50) NOT NULL,
+	uid        UUID,
+	username   TEXT NOT NULL DEFAULT '',
+	ip_address TEXT NOT NULL DEFAULT '',
+	actor_uid  UUID,
+	detail     TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_events_uid ON auth_events (uid, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_auth_events_type ON auth_events (event_type, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS user_two_factor (
+	uid        UUID PRIMARY KEY REFERENCES login_users (uid) ON DELETE CASCADE,
+	secret     TEXT NOT NULL,
+	enabled_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS two_factor_recovery_codes (
+	id         BIGSERIAL PRIMARY KEY,
+	uid        UUID NOT NULL REFERENCES login_users (uid) ON DELETE CASCADE,
+	code_hash  CHAR(64) NOT NULL,
+	used_at    TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_uid ON two_factor_recovery_codes (uid, code_hash);
+
+CREATE TABLE IF NOT EXISTS password_history (
+	id            BIGSERIAL PRIMARY KEY,
+	uid           UUID NOT NULL REFERENCES login_users (uid) ON DELETE CASCADE,
+	password_hash TEXT NOT NULL,
+	created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_history_uid ON password_history (uid, created_at DESC);
@@ -0,0 +1,42 @@
+-- Audit log semua aksi yang mengubah data. Actor/target disimpan sebagai teks tanpa FK:
+-- actor bisa service account, dan catatan harus tetap ada setelah user dihapus.
+CREATE TABLE IF NOT EXISTS audit_logs (
+	id               BIGSERIAL PRIMARY KEY,
+	actor_uid        TEXT,
+	actor_role       VARCHAR(50),
+	impersonator_uid TEXT,
+	action           VARCHAR(100) NOT NULL,
+	target_uid       TEXT,
+	before           JSONB,
+	after            JSONB,
+	changes          JSONB,
+	ip_address       TEXT NOT NULL DEFAULT '',
+	user_agent       TEXT NOT NULL DEFAULT '',
+	request_id       VARCHAR(64) NOT NULL DEFAULT '',
+	created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created ON audit_logs (created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor ON audit_logs (actor_uid, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_target ON audit_logs (target_uid, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_impersonator ON audit_logs (impersonator_uid, created_at DESC) WHERE impersonator_uid IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs (action, created_at DESC);
+
+-- Append-only: UPDATE, DELETE dan TRUNCATE ditolak di level database, bukan hanya oleh aplikasi
+CREATE OR REPLACE FUNCTION audit_logs_append_only()
+RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_logs bersifat append-only (% ditolak)', TG_OP;
+END
+$$;
+
+DROP TRIGGER IF EXISTS trg_audit_logs_no_update ON audit_logs;
+CREATE TRIGGER trg_audit_logs_no_update
+	BEFORE UPDATE OR DELETE ON audit_logs
+	FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
+
+DROP TRIGGER IF EXISTS trg_audit_logs_no_truncate ON audit_logs;
+CREATE TRIGGER trg_audit_logs_no_truncate
+	BEFORE TRUNCATE ON audit_logs
+	FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only();
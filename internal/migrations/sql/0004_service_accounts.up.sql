@@ -0,0 +1,24 @@
+-- Service account untuk integrasi mesin-ke-mesin, diautentikasi dengan API key (hanya hash yang disimpan)
+CREATE TABLE IF NOT EXISTS service_accounts (
+	id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	name        VARCHAR(100) NOT NULL UNIQUE,
+	description TEXT NOT NULL DEFAULT '',
+	scopes      TEXT[] NOT NULL DEFAULT '{}',
+	created_by  UUID REFERENCES login_users (uid) ON DELETE SET NULL,
+	created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	disabled_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+	id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	service_account_id UUID NOT NULL REFERENCES service_accounts (id) ON DELETE CASCADE,
+	key_hash           CHAR(64) NOT NULL UNIQUE,
+	prefix             VARCHAR(16) NOT NULL,
+	expires_at         TIMESTAMPTZ,
+	last_used_at       TIMESTAMPTZ,
+	last_used_ip       TEXT,
+	revoked_at         TIMESTAMPTZ,
+	created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_service_account ON api_keys (service_account_id);
@@ -0,0 +1,12 @@
+-- Identitas eksternal (OpenID Connect) yang terhubung ke akun lokal.
+-- Satu subject hanya milik satu akun, dan satu akun hanya punya satu identitas per provider.
+CREATE TABLE IF NOT EXISTS user_identities (
+	uid           UUID NOT NULL REFERENCES login_users (uid) ON DELETE CASCADE,
+	provider      VARCHAR(50) NOT NULL,
+	subject       VARCHAR(255) NOT NULL,
+	email         VARCHAR(255),
+	created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	last_login_at TIMESTAMPTZ,
+	PRIMARY KEY (provider, subject),
+	UNIQUE (uid, provider)
+);
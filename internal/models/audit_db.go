@@ -0,0 +1,156 @@
+// models/audit_db.go
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-sis-be/internal/configs"
+)
+
+// AuditLog: Satu baris tabel audit_logs (append-only, tidak pernah di-UPDATE/DELETE oleh aplikasi)
+type AuditLog struct {
+	ID              int64           `json:"id"`
+	ActorUID        string          `json:"actor_uid,omitempty"`
+	ActorRole       string          `json:"actor_role,omitempty"`
+	ImpersonatorUID string          `json:"impersonator_uid,omitempty"` // Admin asli jika aksi lewat token impersonation
+	Action          string          `json:"action"`
+	TargetUID       string          `json:"target_uid,omitempty"`
+	Before          json.RawMessage `json:"before,omitempty"`
+	After           json.RawMessage `json:"after,omitempty"`
+	Changes         json.RawMessage `json:"changes,omitempty"` // Diff per field: {"field": {"before": x, "after": y}}
+	IPAddress       string          `json:"ip_address"`
+	UserAgent       string          `json:"user_agent"`
+	RequestID       string          `json:"request_id"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
+
+// AuditFilter: Parameter pencarian GET /audit. Field kosong/nil berarti tidak difilter.
+type AuditFilter struct {
+	ActorUID        string
+	ImpersonatorUID string
+	TargetUID       string
+	Action          string
+	From            *time.Time
+	To              *time.Time
+	Page            int
+	Limit           int
+}
+
+// InsertAuditLog menambahkan satu catatan audit
+func InsertAuditLog(ctx context.Context, entry *AuditLog) error {
+	query := `
+		INSERT INTO audit_logs (
+			actor_uid, actor_role, impersonator_uid, action, target_uid,
+			before, after, changes,
+			ip_address, user_agent, request_id, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW())
+		RETURNING id, created_at`
+
+	err := configs.DB.QueryRowContext(ctx, query,
+		nullString(entry.ActorUID), nullString(entry.ActorRole), nullString(entry.ImpersonatorUID),
+		entry.Action, nullString(entry.TargetUID),
+		nullJSON(entry.Before), nullJSON(entry.After), nullJSON(entry.Changes),
+		entry.IPAddress, entry.UserAgent, entry.RequestID,
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("gagal menyimpan audit log: %w", err)
+	}
+	return nil
+}
+
+// ListAuditLogs mengambil catatan audit sesuai filter, terbaru di atas, beserta total datanya
+func ListAuditLogs(ctx context.Context, f AuditFilter) ([]AuditLog, int, error) {
+	offset := (f.Page - 1) * f.Limit
+
+	// 1. Dynamic WHERE Clause Builder
+	var whereClause []string
+	var args []interface{}
+	argCount := 1
+
+	addFilter := func(cond string, val interface{}) {
+		whereClause = append(whereClause, fmt.Sprintf(cond, argCount))
+		args = append(args, val)
+		argCount++
+	}
+
+	if f.ActorUID != "" {
+		addFilter("actor_uid = $%d", f.ActorUID)
+	}
+	if f.ImpersonatorUID != "" {
+		addFilter("impersonator_uid = $%d", f.ImpersonatorUID)
+	}
+	if f.TargetUID != "" {
+		addFilter("target_uid = $%d", f.TargetUID)
+	}
+	if f.Action != "" {
+		addFilter("action = $%d", f.Action)
+	}
+	if f.From != nil {
+		addFilter("created_at >= $%d", *f.From)
+	}
+	if f.To != nil {
+		addFilter("created_at < $%d", *f.To)
+	}
+
+	finalWhere := ""
+	if len(whereClause) > 0 {
+		finalWhere = " WHERE " + strings.Join(whereClause, " AND ")
+	}
+
+	// 2. Total Data
+	var totalCount int
+	err := configs.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_logs"+finalWhere, args...).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, fmt.Errorf("gagal menghitung audit log: %w", err)
+	}
+	if totalCount == 0 {
+		return []AuditLog{}, 0, nil
+	}
+
+	// 3. Data Aktual
+	dataQuery := fmt.Sprintf(`
+		SELECT id, COALESCE(actor_uid::text, ''), COALESCE(actor_role, ''), COALESCE(impersonator_uid::text, ''),
+			action, COALESCE(target_uid::text, ''),
+			before, after, changes, ip_address, user_agent, request_id, created_at
+		FROM audit_logs
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d`, finalWhere, argCount, argCount+1)
+	args = append(args, f.Limit, offset)
+
+	rows, err := configs.DB.QueryContext(ctx, dataQuery, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("gagal mengambil audit log: %w", err)
+	}
+	defer rows.Close()
+
+	logs := []AuditLog{}
+	for rows.Next() {
+		var a AuditLog
+		var before, after, changes []byte
+		err := rows.Scan(&a.ID, &a.ActorUID, &a.ActorRole, &a.ImpersonatorUID, &a.Action, &a.TargetUID,
+			&before, &after, &changes, &a.IPAddress, &a.UserAgent, &a.RequestID, &a.CreatedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("gagal scan audit log: %w", err)
+		}
+		a.Before, a.After, a.Changes = before, after, changes
+		logs = append(logs, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return logs, totalCount, nil
+}
+
+// nullJSON mengubah JSON kosong menjadi NULL untuk kolom JSONB
+func nullJSON(raw json.RawMessage) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
@@ -0,0 +1,63 @@
+// models/auth_event_db.go
+package models
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"go-sis-be/internal/configs"
+)
+
+// Jenis event keamanan otentikasi yang dicatat di tabel auth_events
+const (
+	AuthEventLoginLocked   = "login_locked"
+	AuthEventLoginUnlocked = "login_unlocked"
+
+	AuthEventTwoFactorEnabled = "2fa_enabled"
+	AuthEventRecoveryCodeUsed = "2fa_recovery_code_used"
+
+	AuthEventOIDCLinked   = "oidc_linked"
+	AuthEventOIDCUnlinked = "oidc_unlinked"
+
+	AuthEventImpersonationStarted = "impersonation_started"
+	AuthEventImpersonationEnded   = "impersonation_ended"
+)
+
+// AuthEvent: Satu catatan event keamanan otentikasi
+type AuthEvent struct {
+	EventType string
+	UID       string // Kosong jika username tidak dikenal
+	Username  string
+	IPAddress string
+	ActorUID  string // Admin yang melakukan aksi (untuk unlock), kosong jika oleh sistem
+	Detail    string
+}
+
+// RecordAuthEvent menyimpan event ke tabel auth_events. Kegagalan hanya di-log
+// agar alur login tidak ikut gagal karena pencatatan. Pencatatan tetap jalan
+// walau client sudah memutus koneksi; batas waktunya timeout (0 = tanpa batas).
+func RecordAuthEvent(ctx context.Context, timeout time.Duration, ev AuthEvent) {
+	ctx, cancel := configs.WithQueryTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	log.Printf("[SECURITY] %s username=%q uid=%s ip=%s actor=%s detail=%q",
+		ev.EventType, ev.Username, ev.UID, ev.IPAddress, ev.ActorUID, ev.Detail)
+
+	query := `
+		INSERT INTO auth_events (event_type, uid, username, ip_address, actor_uid, detail, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())`
+
+	_, err := configs.DB.ExecContext(ctx, query,
+		ev.EventType, nullString(ev.UID), ev.Username, ev.IPAddress, nullString(ev.ActorUID), ev.Detail,
+	)
+	if err != nil {
+		log.Printf("Error mencatat auth event %s: %v", ev.EventType, err)
+	}
+}
+
+// nullString mengubah string kosong menjadi NULL untuk kolom nullable
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
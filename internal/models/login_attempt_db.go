@@ -0,0 +1,123 @@
+// models/login_attempt_db.go
+package models
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"go-sis-be/internal/configs"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Penghitung gagal login & penguncian sementara disimpan di Redis:
+//
+//	login_fail:user:<username>, login_fail:ip:<ip>  -> jumlah gagal dalam jendela waktu
+//	login_lock:user:<username>, login_lock:ip:<ip>  -> ada selama masa kunci
+const (
+	loginFailUserPrefix = "login_fail:user:"
+	loginFailIPPrefix   = "login_fail:ip:"
+	loginLockUserPrefix = "login_lock:user:"
+	loginLockIPPrefix   = "login_lock:ip:"
+)
+
+// LoginFailure: Hasil pencatatan satu percobaan login gagal
+type LoginFailure struct {
+	UserFailures int64
+	IPFailures   int64
+	UserLocked   bool // Username baru saja dikunci oleh kegagalan ini
+	IPLocked     bool // IP baru saja dikunci oleh kegagalan ini
+}
+
+// normalizeLoginKey menyamakan username agar variasi huruf besar/kecil dihitung bersama
+func normalizeLoginKey(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// GetLoginLockTTL mengembalikan sisa masa kunci untuk username atau IP (yang terlama).
+// Nol berarti tidak terkunci. Error Redis dianggap tidak terkunci (fail-open) dan hanya di-log.
+func GetLoginLockTTL(ctx context.Context, username, ip string) time.Duration {
+	pipe := configs.RedisClient.Pipeline()
+	userTTL := pipe.TTL(ctx, loginLockUserPrefix+normalizeLoginKey(username))
+	ipTTL := pipe.TTL(ctx, loginLockIPPrefix+ip)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		log.Printf("Redis error cek login lock: %v", err)
+		return 0
+	}
+
+	ttl := userTTL.Val()
+	if ipTTL.Val() > ttl {
+		ttl = ipTTL.Val()
+	}
+	if ttl < 0 {
+		return 0
+	}
+	return ttl
+}
+
+// RegisterLoginFailure menambah penghitung gagal login untuk username dan IP,
+// lalu mengunci sementara jika ambang batas di cfg terlampaui.
+func RegisterLoginFailure(ctx context.Context, username, ip string, cfg configs.LoginLockoutConfig) (LoginFailure, error) {
+	var res LoginFailure
+	user := normalizeLoginKey(username)
+
+	pipe := configs.RedisClient.TxPipeline()
+	userCount := pipe.Incr(ctx, loginFailUserPrefix+user)
+	pipe.ExpireNX(ctx, loginFailUserPrefix+user, cfg.Window)
+	ipCount := pipe.Incr(ctx, loginFailIPPrefix+ip)
+	pipe.ExpireNX(ctx, loginFailIPPrefix+ip, cfg.Window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return res, err
+	}
+
+	res.UserFailures = userCount.Val()
+	res.IPFailures = ipCount.Val()
+
+	if res.UserFailures >= int64(cfg.MaxUserAttempts) {
+		ok, err := configs.RedisClient.SetNX(ctx, loginLockUserPrefix+user, "locked", cfg.LockDuration).Result()
+		if err != nil {
+			return res, err
+		}
+		res.UserLocked = ok
+		configs.RedisClient.Del(ctx, loginFailUserPrefix+user)
+	}
+	if res.IPFailures >= int64(cfg.MaxIPAttempts) {
+		ok, err := configs.RedisClient.SetNX(ctx, loginLockIPPrefix+ip, "locked", cfg.LockDuration).Result()
+		if err != nil {
+			return res, err
+		}
+		res.IPLocked = ok
+		configs.RedisClient.Del(ctx, loginFailIPPrefix+ip)
+	}
+
+	return res, nil
+}
+
+// ResetLoginFailures menghapus penghitung gagal username setelah login berhasil.
+// Penghitung IP sengaja tidak direset agar satu akun valid tidak bisa "mencuci" IP penyerang.
+func ResetLoginFailures(ctx context.Context, username string) {
+	if err := configs.RedisClient.Del(ctx, loginFailUserPrefix+normalizeLoginKey(username)).Err(); err != nil {
+		log.Printf("Redis error reset login failure: %v", err)
+	}
+}
+
+// UnlockLogin membuka kunci username (dan IP jika diisi) beserta penghitung gagalnya.
+// Mengembalikan true jika ada kunci atau penghitung yang dihapus.
+func UnlockLogin(ctx context.Context, username, ip string) (bool, error) {
+	keys := []string{
+		loginLockUserPrefix + normalizeLoginKey(username),
+		loginFailUserPrefix + normalizeLoginKey(username),
+	}
+	if ip != "" {
+		keys = append(keys, loginLockIPPrefix+ip, loginFailIPPrefix+ip)
+	}
+
+	n, err := configs.RedisClient.Del(ctx, keys...).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
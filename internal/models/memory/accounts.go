@@ -0,0 +1,278 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"go-sis-be/internal/models"
+)
+
+// identity: Baris tabel user_identities
+type identity struct {
+	uid  string
+	link models.UserIdentityLink
+}
+
+// apiKey: Baris tabel api_keys
+type apiKey struct {
+	serviceAccountID string
+	meta             models.APIKey
+}
+
+// ==========================================
+// OIDCStore
+// ==========================================
+
+func (s *Store) SaveOIDCState(_ context.Context, state string, data models.OIDCState, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.oidcStates[state] = data
+	return nil
+}
+
+func (s *Store) ConsumeOIDCState(_ context.Context, state string) (*models.OIDCState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, ok := s.oidcStates[state]
+	if !ok {
+		return nil, nil
+	}
+	delete(s.oidcStates, state)
+	return &data, nil
+}
+
+func (s *Store) GetLinkedUID(_ context.Context, provider, subject string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range s.identities {
+		if id.link.Provider == provider && id.link.Subject == subject {
+			return id.uid, nil
+		}
+	}
+	return "", nil
+}
+
+func (s *Store) FindUIDByEmail(_ context.Context, email string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var uids []string
+	for _, u := range s.users {
+		if u.Person.Email != "" && strings.EqualFold(u.Person.Email, email) {
+			uids = append(uids, u.UID)
+		}
+	}
+	if len(uids) != 1 {
+		return "", nil
+	}
+	return uids[0], nil
+}
+
+func (s *Store) LinkIdentity(_ context.Context, uid, provider, subject, email string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range s.identities {
+		sameSubject := id.link.Provider == provider && id.link.Subject == subject
+		sameProvider := id.uid == uid && id.link.Provider == provider
+		if sameSubject || sameProvider {
+			return models.ErrIdentityLinked
+		}
+	}
+	s.identities = append(s.identities, identity{
+		uid: uid,
+		link: models.UserIdentityLink{
+			Provider:  provider,
+			Subject:   subject,
+			Email:     email,
+			CreatedAt: time.Now(),
+		},
+	})
+	return nil
+}
+
+func (s *Store) TouchIdentity(_ context.Context, provider, subject string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.identities {
+		if s.identities[i].link.Provider == provider && s.identities[i].link.Subject == subject {
+			now := time.Now()
+			s.identities[i].link.LastLoginAt = &now
+		}
+	}
+}
+
+func (s *Store) ListIdentities(_ context.Context, uid string) ([]models.UserIdentityLink, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	links := []models.UserIdentityLink{}
+	for _, id := range s.identities {
+		if id.uid == uid {
+			links = append(links, id.link)
+		}
+	}
+	return links, nil
+}
+
+func (s *Store) UnlinkIdentity(_ context.Context, uid, provider string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, id := range s.identities {
+		if id.uid == uid && id.link.Provider == provider {
+			s.identities = append(s.identities[:i], s.identities[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+// ==========================================
+// ServiceAccountStore
+// ==========================================
+
+func (s *Store) CreateServiceAccount(_ context.Context, sa *models.ServiceAccount) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sa.ID = s.newID()
+	sa.CreatedAt = time.Now()
+	stored := *sa
+	stored.Keys = nil
+	s.serviceAccounts[sa.ID] = &stored
+	return nil
+}
+
+func (s *Store) ListServiceAccounts(_ context.Context) ([]models.ServiceAccount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	accounts := []models.ServiceAccount{}
+	for id := range s.serviceAccounts {
+		accounts = append(accounts, s.serviceAccountWithKeys(id))
+	}
+	return accounts, nil
+}
+
+func (s *Store) GetServiceAccount(_ context.Context, id string) (*models.ServiceAccount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.serviceAccounts[id]; !ok {
+		return nil, sql.ErrNoRows
+	}
+	sa := s.serviceAccountWithKeys(id)
+	return &sa, nil
+}
+
+func (s *Store) UpdateServiceAccount(_ context.Context, id, name, description string, scopes []string, disabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sa, ok := s.serviceAccounts[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	sa.Name = name
+	sa.Description = description
+	sa.Scopes = append([]string{}, scopes...)
+	switch {
+	case !disabled:
+		sa.DisabledAt = nil
+	case sa.DisabledAt == nil:
+		now := time.Now()
+		sa.DisabledAt = &now
+	}
+	return nil
+}
+
+func (s *Store) DeleteServiceAccount(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.serviceAccounts[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(s.serviceAccounts, id)
+	for hash, k := range s.apiKeys {
+		if k.serviceAccountID == id {
+			delete(s.apiKeys, hash)
+		}
+	}
+	return nil
+}
+
+func (s *Store) CreateAPIKey(_ context.Context, serviceAccountID, key, prefix string, expiresAt *time.Time) (*models.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.serviceAccounts[serviceAccountID]; !ok {
+		return nil, sql.ErrNoRows
+	}
+	k := &apiKey{
+		serviceAccountID: serviceAccountID,
+		meta:             models.APIKey{ID: s.newID(), Prefix: prefix, ExpiresAt: expiresAt, CreatedAt: time.Now()},
+	}
+	s.apiKeys[models.HashAPIKey(key)] = k
+	meta := k.meta
+	return &meta, nil
+}
+
+func (s *Store) RevokeAPIKey(_ context.Context, serviceAccountID, keyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, k := range s.apiKeys {
+		if k.meta.ID == keyID && k.serviceAccountID == serviceAccountID && k.meta.RevokedAt == nil {
+			now := time.Now()
+			k.meta.RevokedAt = &now
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (s *Store) AuthenticateAPIKey(_ context.Context, key, ip string) (*models.APIKeyPrincipal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k, ok := s.apiKeys[models.HashAPIKey(key)]
+	if !ok || k.meta.RevokedAt != nil {
+		return nil, nil
+	}
+	now := time.Now()
+	if k.meta.ExpiresAt != nil && !k.meta.ExpiresAt.After(now) {
+		return nil, nil
+	}
+	sa, ok := s.serviceAccounts[k.serviceAccountID]
+	if !ok || sa.DisabledAt != nil {
+		return nil, nil
+	}
+
+	k.meta.LastUsedAt = &now
+	k.meta.LastUsedIP = ip
+	return &models.APIKeyPrincipal{
+		KeyID:            k.meta.ID,
+		ServiceAccountID: sa.ID,
+		Name:             sa.Name,
+		Scopes:           append([]string{}, sa.Scopes...),
+	}, nil
+}
+
+// serviceAccountWithKeys menyalin service account beserta key-nya. Pemanggil harus memegang s.mu.
+func (s *Store) serviceAccountWithKeys(id string) models.ServiceAccount {
+	sa := *s.serviceAccounts[id]
+	sa.Keys = []models.APIKey{}
+	for _, k := range s.apiKeys {
+		if k.serviceAccountID == id {
+			sa.Keys = append(sa.Keys, k.meta)
+		}
+	}
+	return sa
+}
@@ -0,0 +1,296 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-sis-be/internal/models"
+	"go-sis-be/internal/utils"
+)
+
+// expiring: Nilai dengan masa berlaku, padanan key Redis ber-TTL
+type expiring struct {
+	value   string
+	expires time.Time
+}
+
+func (e expiring) valid() bool {
+	return time.Now().Before(e.expires)
+}
+
+// ==========================================
+// TwoFactorStore
+// ==========================================
+
+// SetTwoFactorEnabled mengaktifkan/menonaktifkan 2FA user
+func (s *Store) SetTwoFactorEnabled(uid string, enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !enabled {
+		delete(s.twoFactor, uid)
+		return
+	}
+	now := time.Now()
+	tf, ok := s.twoFactor[uid]
+	if !ok {
+		tf = &models.TwoFactor{UID: uid, CreatedAt: now}
+		s.twoFactor[uid] = tf
+	}
+	tf.EnabledAt = &now
+}
+
+func (s *Store) IsTwoFactorEnabled(_ context.Context, uid string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.twoFactor[uid].Enabled(), nil
+}
+
+func (s *Store) GetTwoFactor(_ context.Context, uid string) (*models.TwoFactor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tf, ok := s.twoFactor[uid]
+	if !ok {
+		return nil, nil
+	}
+	copied := *tf
+	return &copied, nil
+}
+
+func (s *Store) SavePendingTwoFactor(_ context.Context, uid, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.twoFactor[uid].Enabled() {
+		return models.ErrTwoFactorAlreadyEnabled
+	}
+	s.twoFactor[uid] = &models.TwoFactor{UID: uid, Secret: secret, CreatedAt: time.Now()}
+	return nil
+}
+
+func (s *Store) EnableTwoFactor(_ context.Context, uid string, recoveryCodes []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tf, ok := s.twoFactor[uid]
+	if !ok || tf.Enabled() {
+		return sql.ErrNoRows
+	}
+	now := time.Now()
+	tf.EnabledAt = &now
+
+	codes := map[string]bool{}
+	for _, code := range recoveryCodes {
+		codes[code] = false
+	}
+	s.recoveryCodes[uid] = codes
+	return nil
+}
+
+func (s *Store) UseRecoveryCode(_ context.Context, uid, code string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	used, ok := s.recoveryCodes[uid][code]
+	if !ok || used {
+		return false, nil
+	}
+	s.recoveryCodes[uid][code] = true
+	return true, nil
+}
+
+func (s *Store) CountRemainingRecoveryCodes(_ context.Context, uid string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, used := range s.recoveryCodes[uid] {
+		if !used {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *Store) MarkTOTPStepUsed(_ context.Context, uid string, step int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := uid + ":" + strconv.FormatInt(step, 10)
+	if s.totpUsed[key] {
+		return false, nil
+	}
+	s.totpUsed[key] = true
+	return true, nil
+}
+
+func (s *Store) RegisterTwoFactorFailure(_ context.Context, challengeID string, _ time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.challengeFails[challengeID]++
+	return s.challengeFails[challengeID], nil
+}
+
+func (s *Store) IsTwoFactorChallengeUsed(_ context.Context, challengeID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.challengeUsed[challengeID], nil
+}
+
+func (s *Store) ConsumeTwoFactorChallenge(_ context.Context, challengeID string, _ time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.challengeUsed[challengeID] {
+		return false, nil
+	}
+	s.challengeUsed[challengeID] = true
+	return true, nil
+}
+
+// ==========================================
+// PasswordStore
+// ==========================================
+
+func (s *Store) GetPasswordHash(_ context.Context, uid string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[uid]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return u.Pass, nil
+}
+
+func (s *Store) ValidateNewPassword(_ context.Context, uid, password string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.validateNewPassword(uid, password)
+}
+
+// validateNewPassword meniru models.ValidateNewPassword: kebijakan lalu riwayat
+// (password saat ini + HistorySize password sebelumnya). Pemanggil harus memegang s.mu.
+func (s *Store) validateNewPassword(uid, password string) error {
+	u, ok := s.users[uid]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if err := s.Passwords.Policy.Validate(password, u.Username); err != nil {
+		return err
+	}
+
+	size := s.Passwords.Policy.HistorySize
+	if size <= 0 {
+		return nil
+	}
+	hashes := append([]string{u.Pass}, s.passwordHistory[uid]...)
+	for _, h := range hashes {
+		if utils.CheckPasswordHash(password, h) {
+			return utils.PasswordReusedError(size)
+		}
+	}
+	return nil
+}
+
+func (s *Store) ChangePassword(_ context.Context, uid, password string, mustChange bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.validateNewPassword(uid, password); err != nil {
+		return err
+	}
+	hashed, err := s.Passwords.Hash.Hash(password)
+	if err != nil {
+		return err
+	}
+	return s.updatePassword(uid, hashed, mustChange)
+}
+
+func (s *Store) UpdatePassword(_ context.Context, uid, hashedPassword string, mustChange bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.updatePassword(uid, hashedPassword, mustChange)
+}
+
+// updatePassword mengganti hash, menaikkan token version dan mencatat riwayat. Pemanggil harus memegang s.mu.
+func (s *Store) updatePassword(uid, hashedPassword string, mustChange bool) error {
+	u, ok := s.users[uid]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	u.Pass = hashedPassword
+	u.MustChangePassword = mustChange
+	u.TokenVersion++
+
+	if size := s.Passwords.Policy.HistorySize; size > 0 {
+		history := append([]string{hashedPassword}, s.passwordHistory[uid]...)
+		if len(history) > size {
+			history = history[:size]
+		}
+		s.passwordHistory[uid] = history
+	}
+	return nil
+}
+
+// ==========================================
+// ResetTokenStore
+// ==========================================
+
+func (s *Store) GetResetRecipient(_ context.Context, username string) (*models.ResetRecipient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.findByUsername(username)
+	if u == nil || u.Person.Email == "" {
+		return nil, nil
+	}
+	return &models.ResetRecipient{UID: u.UID, FullName: u.Person.FullName, Email: u.Person.Email}, nil
+}
+
+func (s *Store) StorePasswordResetToken(_ context.Context, token, uid string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if prev, ok := s.resetByUser[uid]; ok {
+		delete(s.resetTokens, prev)
+	}
+	s.resetTokens[token] = expiring{value: uid, expires: time.Now().Add(ttl)}
+	s.resetByUser[uid] = token
+	return nil
+}
+
+func (s *Store) PeekPasswordResetToken(_ context.Context, token string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.resetTokens[token]
+	if !ok || !rec.valid() {
+		return "", models.ErrResetTokenInvalid
+	}
+	return rec.value, nil
+}
+
+func (s *Store) ConsumePasswordResetToken(_ context.Context, token string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.resetTokens[token]
+	delete(s.resetTokens, token)
+	if !ok || !rec.valid() {
+		return "", models.ErrResetTokenInvalid
+	}
+	delete(s.resetByUser, rec.value)
+	return rec.value, nil
+}
+
+// ResetTokenFor mengembalikan token reset aktif milik uid (token aslinya hanya ada di email)
+func (s *Store) ResetTokenFor(uid string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.resetByUser[strings.TrimSpace(uid)]
+}
@@ -0,0 +1,577 @@
+// Package memory berisi fake in-memory untuk interface penyimpanan di package models
+// (UserRepository, ProfileRepository, SessionStore, dst). Dipakai test handler agar
+// bisa berjalan tanpa Postgres dan Redis. Semua data hilang saat proses selesai.
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-sis-be/internal/models"
+)
+
+var (
+	_ models.UserRepository    = (*Store)(nil)
+	_ models.ProfileRepository = (*Store)(nil)
+	_ models.SessionStore      = (*Store)(nil)
+	_ models.TokenStore        = (*Store)(nil)
+	_ models.LoginAttemptStore = (*Store)(nil)
+	_ models.TwoFactorStore    = (*Store)(nil)
+	_ models.AuditStore        = (*Store)(nil)
+
+	_ models.PasswordStore       = (*Store)(nil)
+	_ models.ResetTokenStore     = (*Store)(nil)
+	_ models.OIDCStore           = (*Store)(nil)
+	_ models.ServiceAccountStore = (*Store)(nil)
+	_ models.AuthStore           = (*Store)(nil)
+)
+
+// roleNames: Isi tabel roles
+var roleNames = map[int]string{
+	models.ADMIN_ROLE_ID:   models.ADMIN_ROLE_NAME,
+	models.TEACHER_ROLE_ID: models.TEACHER_ROLE_NAME,
+	models.STUDENT_ROLE_ID: models.STUDENT_ROLE_NAME,
+	models.PARENT_ROLE_ID:  models.PARENT_ROLE_NAME,
+}
+
+// user: Gabungan login_users, person, student_details dan teacher_details
+type user struct {
+	models.User
+	Person  models.Person
+	Student *models.StudentDetails
+	Teacher *models.TeacherDetails
+}
+
+// Store: Satu penyimpanan in-memory yang memenuhi semua interface penyimpanan models,
+// sehingga data antar interface konsisten (mis. user hasil registrasi bisa langsung login).
+type Store struct {
+	mu sync.Mutex
+
+	// Passwords: Kebijakan & hash password, bawaan models.DefaultPasswordConfig
+	Passwords models.PasswordConfig
+
+	seq      int
+	nisSeq   int
+	users    map[string]*user // uid -> user
+	sessions map[string]*models.Session
+	refresh  map[string]*models.RefreshTokenRecord
+
+	blacklist      map[string]time.Time       // token -> kadaluarsa
+	loginFailures  map[string]int64           // "user:<username>" / "ip:<ip>" -> jumlah gagal
+	loginLocks     map[string]time.Time       // "user:<username>" / "ip:<ip>" -> terkunci sampai
+	parents        map[string]string          // uid murid -> uid wali
+	teaches        map[string]map[string]bool // uid guru -> uid murid
+	auditLogs      []models.AuditLog
+	authEvents     []models.AuthEvent
+	revokedSession map[string]bool
+
+	twoFactor       map[string]*models.TwoFactor
+	recoveryCodes   map[string]map[string]bool // uid -> recovery code -> sudah dipakai
+	totpUsed        map[string]bool            // "<uid>:<step>"
+	challengeFails  map[string]int64
+	challengeUsed   map[string]bool
+	passwordHistory map[string][]string // uid -> hash password lama, terbaru di depan
+	resetTokens     map[string]expiring // token -> uid
+	resetByUser     map[string]string   // uid -> token terakhir
+	oidcStates      map[string]models.OIDCState
+	identities      []identity
+	serviceAccounts map[string]*models.ServiceAccount
+	apiKeys         map[string]*apiKey // hash key -> key
+}
+
+// NewStore membuat Store kosong
+func NewStore() *Store {
+	return &Store{
+		Passwords: models.DefaultPasswordConfig(),
+
+		users:          map[string]*user{},
+		sessions:       map[string]*models.Session{},
+		refresh:        map[string]*models.RefreshTokenRecord{},
+		blacklist:      map[string]time.Time{},
+		loginFailures:  map[string]int64{},
+		loginLocks:     map[string]time.Time{},
+		parents:        map[string]string{},
+		teaches:        map[string]map[string]bool{},
+		revokedSession: map[string]bool{},
+
+		twoFactor:       map[string]*models.TwoFactor{},
+		recoveryCodes:   map[string]map[string]bool{},
+		totpUsed:        map[string]bool{},
+		challengeFails:  map[string]int64{},
+		challengeUsed:   map[string]bool{},
+		passwordHistory: map[string][]string{},
+		resetTokens:     map[string]expiring{},
+		resetByUser:     map[string]string{},
+		oidcStates:      map[string]models.OIDCState{},
+		serviceAccounts: map[string]*models.ServiceAccount{},
+		apiKeys:         map[string]*apiKey{},
+	}
+}
+
+// newID menghasilkan ID berformat UUID yang berurutan. Pemanggil harus memegang s.mu.
+func (s *Store) newID() string {
+	s.seq++
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", s.seq)
+}
+
+// ==========================================
+// UserRepository
+// ==========================================
+
+func (s *Store) GetUserForLogin(_ context.Context, username string) (*models.User, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.findByUsername(username)
+	if u == nil {
+		return nil, "", nil
+	}
+	copied := u.User
+	return &copied, roleNames[u.RoleID], nil
+}
+
+func (s *Store) GetUserByID(_ context.Context, uid string) (*models.UserResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[uid]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	resp := userResponse(u)
+	return &resp, nil
+}
+
+func (s *Store) GetUserIdentityByUID(_ context.Context, uid string) (*models.UserIdentity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[uid]
+	if !ok {
+		return nil, fmt.Errorf("user tidak ditemukan")
+	}
+	return &models.UserIdentity{
+		UID:                u.UID,
+		Username:           u.Username,
+		Role:               roleNames[u.RoleID],
+		MustChangePassword: u.MustChangePassword,
+		TokenVersion:       u.TokenVersion,
+	}, nil
+}
+
+func (s *Store) GetRoleIDByUID(_ context.Context, uid string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[uid]
+	if !ok {
+		return 0, errors.New("UID tidak ditemukan")
+	}
+	return u.RoleID, nil
+}
+
+func (s *Store) GetAllUsers(_ context.Context, page int, limit int, search string, roleID int) ([]models.UserResponse, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	search = strings.ToLower(search)
+	var all []models.UserResponse
+	for _, u := range s.users {
+		if roleID > 0 && u.RoleID != roleID {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(u.Username), search) &&
+			!strings.Contains(strings.ToLower(u.Person.FullName), search) {
+			continue
+		}
+		all = append(all, userResponse(u))
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Username < all[j].Username })
+
+	total := len(all)
+	start := (page - 1) * limit
+	if start < 0 || start >= total {
+		return []models.UserResponse{}, total, nil
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+	return all[start:end], total, nil
+}
+
+func (s *Store) CreateUser(_ context.Context, req *models.CreateUserRequest) (*models.UserResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, err := s.insertUser(req.Username, req.Password, req.RoleID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.UserResponse{UID: u.UID, Username: u.Username, RoleName: roleNames[u.RoleID]}, nil
+}
+
+func (s *Store) DeleteUser(_ context.Context, uid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[uid]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(s.users, uid)
+	return nil
+}
+
+func (s *Store) ChangeUserRole(_ context.Context, uid string, roleID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[uid]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	// Meniru trigger trg_login_users_role_change
+	if u.RoleID != roleID {
+		u.RoleID = roleID
+		u.TokenVersion++
+	}
+	return nil
+}
+
+func (s *Store) UpgradePasswordHash(_ context.Context, uid, oldHash, newHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Sama seperti versi Postgres: hanya diganti jika hash belum berubah sejak dibaca
+	if u, ok := s.users[uid]; ok && u.Pass == oldHash {
+		u.Pass = newHash
+	}
+	return nil
+}
+
+// SetMustChangePassword menandai user wajib ganti password (untuk menyiapkan skenario test)
+func (s *Store) SetMustChangePassword(uid string, mustChange bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u, ok := s.users[uid]; ok {
+		u.MustChangePassword = mustChange
+	}
+}
+
+// findByUsername mencari user berdasarkan username. Pemanggil harus memegang s.mu.
+func (s *Store) findByUsername(username string) *user {
+	for _, u := range s.users {
+		if u.Username == username {
+			return u
+		}
+	}
+	return nil
+}
+
+// insertUser meniru insert ke login_users: cek kebijakan password, username unik, lalu hash.
+// Pemanggil harus memegang s.mu.
+func (s *Store) insertUser(username, password string, roleID int) (*user, error) {
+	if err := s.Passwords.Policy.Validate(password, username); err != nil {
+		return nil, err
+	}
+	if s.findByUsername(username) != nil {
+		return nil, fmt.Errorf("gagal insert login: username %s sudah dipakai", username)
+	}
+	if _, ok := roleNames[roleID]; !ok {
+		return nil, fmt.Errorf("gagal insert login: role %d tidak ada", roleID)
+	}
+	hash, err := s.Passwords.Hash.Hash(password)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	u := &user{User: models.User{
+		UID:       s.newID(),
+		Username:  username,
+		Pass:      hash,
+		RoleID:    roleID,
+		RoleName:  roleNames[roleID],
+		CreatedAt: now,
+		UpdatedAt: now,
+	}}
+	s.users[u.UID] = u
+	return u, nil
+}
+
+func userResponse(u *user) models.UserResponse {
+	return models.UserResponse{
+		UID:      u.UID,
+		Username: u.Username,
+		RoleID:   u.RoleID,
+		RoleName: roleNames[u.RoleID],
+		FullName: u.Person.FullName,
+	}
+}
+
+// ==========================================
+// ProfileRepository
+// ==========================================
+
+func (s *Store) RegisterStudent(_ context.Context, req *models.RegisterStudentRequest) (*models.UserProfileResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.Student != nil && u.Student.NISN == req.NISN {
+			return nil, fmt.Errorf("gagal insert student details: NISN %s sudah terdaftar", req.NISN)
+		}
+	}
+	u, err := s.insertPerson(&req.RegisterBaseRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	s.nisSeq++
+	details := req.StudentDetails
+	details.UID = u.UID
+	details.NIS = fmt.Sprintf("%010d", s.nisSeq)
+	u.Student = &details
+
+	resp := profileResponse(u)
+	resp.RoleName = "Murid"
+	return resp, nil
+}
+
+func (s *Store) RegisterTeacher(_ context.Context, req *models.RegisterTeacherRequest) (*models.UserProfileResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, err := s.insertPerson(&req.RegisterBaseRequest)
+	if err != nil {
+		return nil, err
+	}
+	details := req.TeacherDetails
+	details.UID = u.UID
+	u.Teacher = &details
+
+	resp := profileResponse(u)
+	resp.RoleName = "Guru"
+	return resp, nil
+}
+
+func (s *Store) RegisterBaseUser(_ context.Context, req *models.RegisterBaseRequest) (*models.UserProfileResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, err := s.insertPerson(req)
+	if err != nil {
+		return nil, err
+	}
+	return profileResponse(u), nil
+}
+
+// insertPerson meniru insert login_users + person dengan NIK unik. Pemanggil harus memegang s.mu.
+func (s *Store) insertPerson(req *models.RegisterBaseRequest) (*user, error) {
+	for _, u := range s.users {
+		if u.Person.NIK == req.NIK {
+			return nil, fmt.Errorf("gagal insert person: NIK %s sudah terdaftar", req.NIK)
+		}
+	}
+	u, err := s.insertUser(req.Username, req.Password, req.RoleID)
+	if err != nil {
+		return nil, err
+	}
+	u.Person = models.Person{
+		UID:           u.UID,
+		FullName:      req.FullName,
+		BirthDate:     req.BirthDate,
+		NIK:           req.NIK,
+		Gender:        req.Gender,
+		Religion:      req.Religion,
+		MaritalStatus: req.MaritalStatus,
+		Address:       req.Address,
+		PhoneNumber:   req.PhoneNumber,
+		Email:         req.Email,
+	}
+	return u, nil
+}
+
+func profileResponse(u *user) *models.UserProfileResponse {
+	return &models.UserProfileResponse{
+		UID:        u.UID,
+		Username:   u.Username,
+		RoleName:   roleNames[u.RoleID],
+		PersonData: models.Person{UID: u.UID, FullName: u.Person.FullName, NIK: u.Person.NIK},
+	}
+}
+
+func (s *Store) GetProfileAndFormat(_ context.Context, uid string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[uid]
+	if !ok || u.Person.UID == "" {
+		return nil, errors.New("profil tidak ditemukan")
+	}
+	p := u.Person
+
+	switch {
+	case u.RoleID == models.TEACHER_ROLE_ID && u.Teacher != nil:
+		t := u.Teacher
+		return models.TeacherProfileResponse{
+			UID: u.UID, Username: u.Username, RoleName: roleNames[u.RoleID],
+			FullName: p.FullName, BirthDate: p.BirthDate, NIK: p.NIK, Gender: p.Gender,
+			Religion: p.Religion, MaritalStatus: p.MaritalStatus, Address: p.Address,
+			PhoneNumber: p.PhoneNumber, Email: p.Email,
+
+			NIP: t.NIP, NUPTK: t.NUPTK, NRG: t.NRG,
+			FunctionalPosition: t.FunctionalPosition, EmploymentStatus: t.EmploymentStatus,
+			RankClass: t.RankClass, HireDate: t.HireDate, SKAppointmentNumber: t.SKAppointmentNumber,
+			EducatorCertNumber: t.EducatorCertNumber, LastEducation: t.LastEducation,
+			University: t.University, Major: t.Major, GraduationYear: t.GraduationYear,
+			DiplomaNumber: t.DiplomaNumber,
+		}, nil
+
+	case u.RoleID == models.STUDENT_ROLE_ID && u.Student != nil:
+		st := u.Student
+		entryYear := 0
+		if len(st.ReceivedDate) >= 4 {
+			entryYear, _ = strconv.Atoi(st.ReceivedDate[:4])
+		}
+		return models.StudentProfileResponse{
+			UID: u.UID, Username: u.Username, RoleName: roleNames[u.RoleID],
+			FullName: p.FullName, BirthDate: p.BirthDate, NIK: p.NIK, Gender: p.Gender,
+			Religion: p.Religion, MaritalStatus: p.MaritalStatus, Address: p.Address,
+			PhoneNumber: p.PhoneNumber, Email: p.Email,
+
+			NISN: st.NISN, NIS: st.NIS,
+			ReceivedDate: st.ReceivedDate,
+			EntryYear:    entryYear,
+		}, nil
+
+	default:
+		return struct {
+			UID       string `json:"uid"`
+			Username  string `json:"username"`
+			RoleName  string `json:"role_name"`
+			FullName  string `json:"full_name"`
+			BirthDate string `json:"birth_date"`
+			NIK       string `json:"nik"`
+		}{
+			UID: u.UID, Username: u.Username,
+			FullName: p.FullName, BirthDate: p.BirthDate, NIK: p.NIK,
+		}, nil
+	}
+}
+
+func (s *Store) EditStudentProfile(_ context.Context, uid string, req *models.EditStudentRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[uid]
+	if !ok || u.Student == nil {
+		return errors.New("data murid tidak ditemukan (person)")
+	}
+	u.Person.FullName = req.FullName
+	u.Person.BirthDate = req.BirthDate
+	u.Person.Religion = req.Religion
+	u.Person.MaritalStatus = req.MaritalStatus
+	u.Person.Address = req.Address
+	u.Person.PhoneNumber = req.PhoneNumber
+	u.Person.Email = req.Email
+
+	u.Student.NISN = req.NISN
+	u.Student.NIS = req.NIS
+	u.Student.ReceivedDate = req.ReceivedDate
+	if req.ParentUID != "" {
+		s.parents[uid] = req.ParentUID
+	}
+	return nil
+}
+
+func (s *Store) EditTeacherProfile(_ context.Context, uid string, req *models.EditTeacherRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[uid]
+	if !ok || u.Teacher == nil {
+		return errors.New("data guru tidak ditemukan (person)")
+	}
+	u.Person.FullName = req.FullName
+	u.Person.Religion = req.Religion
+	u.Person.MaritalStatus = req.MaritalStatus
+	u.Person.Address = req.Address
+	u.Person.PhoneNumber = req.PhoneNumber
+	u.Person.Email = req.Email
+
+	t := u.Teacher
+	t.NIP, t.NUPTK, t.NRG = req.NIP, req.NUPTK, req.NRG
+	t.FunctionalPosition, t.EmploymentStatus = req.FunctionalPosition, req.EmploymentStatus
+	t.RankClass, t.HireDate = req.RankClass, req.HireDate
+	t.SKAppointmentNumber, t.EducatorCertNumber = req.SKAppointmentNumber, req.EducatorCertNumber
+	t.LastEducation, t.University, t.Major = req.LastEducation, req.University, req.Major
+	t.GraduationYear, t.DiplomaNumber = req.GraduationYear, req.DiplomaNumber
+	return nil
+}
+
+func (s *Store) DeleteStudentProfile(_ context.Context, uid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[uid]
+	if !ok || u.Student == nil {
+		return errors.New("data murid tidak ditemukan")
+	}
+	delete(s.users, uid)
+	delete(s.parents, uid)
+	return nil
+}
+
+func (s *Store) DeleteTeacherProfile(_ context.Context, uid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[uid]
+	if !ok || u.Teacher == nil {
+		return errors.New("data guru tidak ditemukan")
+	}
+	delete(s.users, uid)
+	delete(s.teaches, uid)
+	return nil
+}
+
+// ==========================================
+// policy.Relations
+// ==========================================
+
+// LinkParent menghubungkan murid dengan walinya
+func (s *Store) LinkParent(parentUID, studentUID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.parents[studentUID] = parentUID
+}
+
+// AssignTeacher mencatat guru yang mengajar murid
+func (s *Store) AssignTeacher(teacherUID, studentUID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.teaches[teacherUID] == nil {
+		s.teaches[teacherUID] = map[string]bool{}
+	}
+	s.teaches[teacherUID][studentUID] = true
+}
+
+func (s *Store) IsParentOf(_ context.Context, parentUID, studentUID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.parents[studentUID] == parentUID, nil
+}
+
+func (s *Store) TeachesStudent(_ context.Context, teacherUID, studentUID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.teaches[teacherUID][studentUID], nil
+}
@@ -0,0 +1,360 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+
+	"go-sis-be/internal/configs"
+	"go-sis-be/internal/models"
+)
+
+// ==========================================
+// SessionStore
+// ==========================================
+
+func (s *Store) CreateSession(_ context.Context, uid, device, ip, userAgent string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	sess := &models.Session{
+		ID:         s.newID(),
+		UID:        uid,
+		Device:     device,
+		IPAddress:  ip,
+		UserAgent:  userAgent,
+		CreatedAt:  now,
+		LastUsedAt: now,
+	}
+	s.sessions[sess.ID] = sess
+	return sess.ID, nil
+}
+
+func (s *Store) GetSession(_ context.Context, id string) (*models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *sess
+	return &copied, nil
+}
+
+func (s *Store) TouchSession(_ context.Context, id, ip string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sess, ok := s.sessions[id]; ok {
+		sess.LastUsedAt = time.Now()
+		sess.IPAddress = ip
+	}
+	return nil
+}
+
+func (s *Store) ListActiveSessions(_ context.Context, uid string) ([]models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions := []models.Session{}
+	for _, sess := range s.sessions {
+		if sess.UID == uid && sess.RevokedAt == nil {
+			sessions = append(sessions, *sess)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt) })
+	return sessions, nil
+}
+
+func (s *Store) RevokeSession(_ context.Context, uid, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[sessionID]
+	if !ok || sess.UID != uid || sess.RevokedAt != nil {
+		return sql.ErrNoRows
+	}
+	s.revokeSession(sess)
+	return nil
+}
+
+func (s *Store) RevokeAllSessions(_ context.Context, uid string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	for _, sess := range s.sessions {
+		if sess.UID == uid && sess.RevokedAt == nil {
+			s.revokeSession(sess)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// revokeSession mencabut sesi beserta refresh token family-nya. Pemanggil harus memegang s.mu.
+func (s *Store) revokeSession(sess *models.Session) {
+	now := time.Now()
+	sess.RevokedAt = &now
+	s.revokedSession[sess.ID] = true
+	s.revokeFamily(sess.ID)
+}
+
+// IsSessionRevoked mengecek penanda sesi dicabut (padanan kunci session_revoked:<id> di Redis)
+func (s *Store) IsSessionRevoked(_ context.Context, sessionID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.revokedSession[sessionID]
+}
+
+func (s *Store) CreateRefreshToken(_ context.Context, jti, uid, familyID string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refresh[jti] = &models.RefreshTokenRecord{
+		JTI:       jti,
+		UID:       uid,
+		FamilyID:  familyID,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+	return nil
+}
+
+func (s *Store) GetRefreshTokenRecord(_ context.Context, jti string) (*models.RefreshTokenRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.refresh[jti]
+	if !ok {
+		return nil, nil
+	}
+	copied := *rec
+	return &copied, nil
+}
+
+func (s *Store) RotateRefreshToken(_ context.Context, oldJTI, newJTI string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.refresh[oldJTI]
+	if !ok || old.RevokedAt.Valid {
+		return models.ErrRefreshTokenReused
+	}
+	now := time.Now()
+	old.RevokedAt = sql.NullTime{Time: now, Valid: true}
+	old.ReplacedBy = sql.NullString{String: newJTI, Valid: true}
+
+	s.refresh[newJTI] = &models.RefreshTokenRecord{
+		JTI:       newJTI,
+		UID:       old.UID,
+		FamilyID:  old.FamilyID,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}
+	return nil
+}
+
+func (s *Store) RevokeTokenFamily(_ context.Context, familyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revokeFamily(familyID)
+	return nil
+}
+
+// revokeFamily mencabut semua refresh token aktif dalam family. Pemanggil harus memegang s.mu.
+func (s *Store) revokeFamily(familyID string) {
+	now := time.Now()
+	for _, rec := range s.refresh {
+		if rec.FamilyID == familyID && !rec.RevokedAt.Valid {
+			rec.RevokedAt = sql.NullTime{Time: now, Valid: true}
+		}
+	}
+}
+
+// ==========================================
+// TokenStore
+// ==========================================
+
+func (s *Store) BlacklistToken(_ context.Context, token string, expiry time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blacklist[token] = time.Now().Add(expiry)
+	return nil
+}
+
+// IsTokenBlacklisted mengecek apakah token sudah di-blacklist dan belum kadaluarsa
+func (s *Store) IsTokenBlacklisted(_ context.Context, token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	exp, ok := s.blacklist[token]
+	return ok && time.Now().Before(exp)
+}
+
+func (s *Store) GetTokenVersion(_ context.Context, uid string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[uid]
+	if !ok {
+		return 0, models.ErrUserGone
+	}
+	return u.TokenVersion, nil
+}
+
+func (s *Store) BumpTokenVersion(_ context.Context, uid string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[uid]
+	if !ok {
+		return 0, models.ErrUserGone
+	}
+	u.TokenVersion++
+	return u.TokenVersion, nil
+}
+
+func (s *Store) TokenBlacklistStatus() models.TokenBlacklistHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return models.TokenBlacklistHealth{Mode: "fail_open", FallbackEntries: len(s.blacklist)}
+}
+
+// ==========================================
+// LoginAttemptStore
+// ==========================================
+
+func (s *Store) GetLoginLockTTL(_ context.Context, username, ip string) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ttl time.Duration
+	for _, key := range []string{"user:" + loginKey(username), "ip:" + ip} {
+		if left := time.Until(s.loginLocks[key]); left > ttl {
+			ttl = left
+		}
+	}
+	return ttl
+}
+
+func (s *Store) RegisterLoginFailure(_ context.Context, username, ip string, cfg configs.LoginLockoutConfig) (models.LoginFailure, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var res models.LoginFailure
+	userKey, ipKey := "user:"+loginKey(username), "ip:"+ip
+	s.loginFailures[userKey]++
+	s.loginFailures[ipKey]++
+	res.UserFailures = s.loginFailures[userKey]
+	res.IPFailures = s.loginFailures[ipKey]
+
+	lock := func(key string) bool {
+		delete(s.loginFailures, key)
+		if time.Now().Before(s.loginLocks[key]) {
+			return false
+		}
+		s.loginLocks[key] = time.Now().Add(cfg.LockDuration)
+		return true
+	}
+	if res.UserFailures >= int64(cfg.MaxUserAttempts) {
+		res.UserLocked = lock(userKey)
+	}
+	if res.IPFailures >= int64(cfg.MaxIPAttempts) {
+		res.IPLocked = lock(ipKey)
+	}
+	return res, nil
+}
+
+func (s *Store) ResetLoginFailures(_ context.Context, username string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.loginFailures, "user:"+loginKey(username))
+}
+
+func (s *Store) UnlockLogin(_ context.Context, username, ip string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := []string{"user:" + loginKey(username)}
+	if ip != "" {
+		keys = append(keys, "ip:"+ip)
+	}
+	unlocked := false
+	for _, key := range keys {
+		if _, ok := s.loginLocks[key]; ok {
+			unlocked = true
+		}
+		if _, ok := s.loginFailures[key]; ok {
+			unlocked = true
+		}
+		delete(s.loginLocks, key)
+		delete(s.loginFailures, key)
+	}
+	return unlocked, nil
+}
+
+// loginKey menyamakan username seperti normalizeLoginKey di models
+func loginKey(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// ==========================================
+// AuditStore
+// ==========================================
+
+func (s *Store) InsertAuditLog(_ context.Context, entry *models.AuditLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry.ID = int64(len(s.auditLogs) + 1)
+	entry.CreatedAt = time.Now()
+	s.auditLogs = append(s.auditLogs, *entry)
+	return nil
+}
+
+func (s *Store) ListAuditLogs(_ context.Context, f models.AuditFilter) ([]models.AuditLog, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	logs := []models.AuditLog{}
+	for i := len(s.auditLogs) - 1; i >= 0; i-- {
+		e := s.auditLogs[i]
+		if (f.ActorUID != "" && e.ActorUID != f.ActorUID) ||
+			(f.ImpersonatorUID != "" && e.ImpersonatorUID != f.ImpersonatorUID) ||
+			(f.TargetUID != "" && e.TargetUID != f.TargetUID) ||
+			(f.Action != "" && e.Action != f.Action) ||
+			(f.From != nil && e.CreatedAt.Before(*f.From)) ||
+			(f.To != nil && !e.CreatedAt.Before(*f.To)) {
+			continue
+		}
+		logs = append(logs, e)
+	}
+
+	total := len(logs)
+	start := (f.Page - 1) * f.Limit
+	if start < 0 || start >= total {
+		return []models.AuditLog{}, total, nil
+	}
+	end := start + f.Limit
+	if end > total {
+		end = total
+	}
+	return logs[start:end], total, nil
+}
+
+func (s *Store) RecordAuthEvent(_ context.Context, ev models.AuthEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.authEvents = append(s.authEvents, ev)
+}
+
+// AuthEvents mengembalikan salinan semua event keamanan yang tercatat
+func (s *Store) AuthEvents() []models.AuthEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.AuthEvent(nil), s.authEvents...)
+}
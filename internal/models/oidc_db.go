@@ -0,0 +1,175 @@
+// models/oidc_db.go
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go-sis-be/internal/configs"
+
+	"github.com/lib/pq"
+	"github.com/redis/go-redis/v9"
+)
+
+const oidcStatePrefix = "oidc_state:"
+
+// ErrIdentityLinked: Identitas eksternal sudah terhubung ke akun lain,
+// atau akun sudah punya identitas lain dari provider yang sama
+var ErrIdentityLinked = errors.New("identitas sudah terhubung ke akun lain")
+
+// OIDCState: Data alur login OIDC yang disimpan di Redis selama user berada di halaman IdP
+type OIDCState struct {
+	Provider string `json:"provider"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"` // PKCE code_verifier
+	Device   string `json:"device,omitempty"`
+	LinkUID  string `json:"link_uid,omitempty"` // Diisi jika alur untuk menghubungkan akun, bukan login
+}
+
+// UserIdentityLink: Identitas eksternal yang terhubung ke akun lokal (tabel user_identities)
+type UserIdentityLink struct {
+	Provider    string     `json:"provider"`
+	Subject     string     `json:"subject"`
+	Email       string     `json:"email,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+// SaveOIDCState menyimpan state alur OIDC dengan masa berlaku ttl
+func SaveOIDCState(ctx context.Context, state string, data OIDCState, ttl time.Duration) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if err := configs.RedisClient.Set(ctx, oidcStatePrefix+state, raw, ttl).Err(); err != nil {
+		return fmt.Errorf("gagal menyimpan state OIDC: %w", err)
+	}
+	return nil
+}
+
+// ConsumeOIDCState mengambil sekaligus menghapus state (sekali pakai). Mengembalikan nil jika tidak ada.
+func ConsumeOIDCState(ctx context.Context, state string) (*OIDCState, error) {
+	raw, err := configs.RedisClient.GetDel(ctx, oidcStatePrefix+state).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil state OIDC: %w", err)
+	}
+
+	var data OIDCState
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("state OIDC rusak: %w", err)
+	}
+	return &data, nil
+}
+
+// GetLinkedUID mencari akun lokal yang terhubung dengan identitas eksternal. Mengembalikan "" jika belum ada.
+func GetLinkedUID(ctx context.Context, provider, subject string) (string, error) {
+	var uid string
+	err := configs.DB.QueryRowContext(ctx, `SELECT uid FROM user_identities WHERE provider = $1 AND subject = $2`, provider, subject).Scan(&uid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("gagal mencari identitas OIDC: %w", err)
+	}
+	return uid, nil
+}
+
+// FindUIDByEmail mencari akun lokal berdasarkan person.email (tanpa membedakan huruf besar/kecil).
+// Mengembalikan "" jika tidak ada atau jika email dipakai lebih dari satu akun (ambigu).
+func FindUIDByEmail(ctx context.Context, email string) (string, error) {
+	rows, err := configs.DB.QueryContext(ctx, `
+		SELECT u.uid
+		FROM login_users u
+		JOIN person p ON p.uid = u.uid
+		WHERE LOWER(p.email) = LOWER($1)
+		LIMIT 2`, email)
+	if err != nil {
+		return "", fmt.Errorf("gagal mencari akun berdasarkan email: %w", err)
+	}
+	defer rows.Close()
+
+	var uids []string
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return "", err
+		}
+		uids = append(uids, uid)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	if len(uids) != 1 {
+		return "", nil
+	}
+	return uids[0], nil
+}
+
+// LinkIdentity menghubungkan identitas eksternal ke akun lokal.
+// Mengembalikan ErrIdentityLinked jika identitas atau provider tersebut sudah terhubung di akun lain.
+func LinkIdentity(ctx context.Context, uid, provider, subject, email string) error {
+	_, err := configs.DB.ExecContext(ctx, `
+		INSERT INTO user_identities (uid, provider, subject, email, created_at)
+		VALUES ($1, $2, $3, $4, NOW())`, uid, provider, subject, nullString(email))
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" { // unique_violation
+		return ErrIdentityLinked
+	}
+	if err != nil {
+		return fmt.Errorf("gagal menghubungkan identitas OIDC: %w", err)
+	}
+	return nil
+}
+
+// TouchIdentity mencatat waktu login terakhir lewat identitas eksternal
+func TouchIdentity(ctx context.Context, provider, subject string) {
+	_, err := configs.DB.ExecContext(ctx, `
+		UPDATE user_identities SET last_login_at = NOW()
+		WHERE provider = $1 AND subject = $2`, provider, subject)
+	if err != nil {
+		log.Printf("Error update last_login_at identitas OIDC: %v", err)
+	}
+}
+
+// ListIdentities mengambil semua identitas eksternal milik user
+func ListIdentities(ctx context.Context, uid string) ([]UserIdentityLink, error) {
+	rows, err := configs.DB.QueryContext(ctx, `
+		SELECT provider, subject, COALESCE(email, ''), created_at, last_login_at
+		FROM user_identities
+		WHERE uid = $1
+		ORDER BY created_at`, uid)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil identitas OIDC: %w", err)
+	}
+	defer rows.Close()
+
+	links := []UserIdentityLink{}
+	for rows.Next() {
+		var l UserIdentityLink
+		var lastLogin sql.NullTime
+		if err := rows.Scan(&l.Provider, &l.Subject, &l.Email, &l.CreatedAt, &lastLogin); err != nil {
+			return nil, fmt.Errorf("gagal scan identitas OIDC: %w", err)
+		}
+		l.LastLoginAt = nullTimePtr(lastLogin)
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// UnlinkIdentity memutus identitas provider dari akun. Mengembalikan sql.ErrNoRows jika tidak ada.
+func UnlinkIdentity(ctx context.Context, uid, provider string) error {
+	res, err := configs.DB.ExecContext(ctx, `DELETE FROM user_identities WHERE uid = $1 AND provider = $2`, uid, provider)
+	if err != nil {
+		return fmt.Errorf("gagal memutus identitas OIDC: %w", err)
+	}
+	return requireAffected(res)
+}
@@ -0,0 +1,72 @@
+// models/password_db.go
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go-sis-be/internal/configs"
+)
+
+// GetPasswordHash mengambil hash password user. Mengembalikan sql.ErrNoRows jika uid tidak ada.
+func GetPasswordHash(ctx context.Context, uid string) (string, error) {
+	var hash string
+	err := configs.DB.QueryRowContext(ctx, `SELECT pass FROM login_users WHERE uid = $1`, uid).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", sql.ErrNoRows
+	}
+	if err != nil {
+		return "", fmt.Errorf("gagal mengambil password: %w", err)
+	}
+	return hash, nil
+}
+
+// UpdatePassword mengganti hash password user dan menaikkan token_version-nya,
+// sehingga semua access token lama langsung tidak berlaku. Hash baru ikut dicatat di riwayat password.
+// mustChange=true memaksa user mengganti password lagi saat login berikutnya (password sementara).
+// Tidak menjalankan kebijakan password; password pilihan user harus lewat ChangePassword.
+func UpdatePassword(ctx context.Context, pc PasswordConfig, uid, hashedPassword string, mustChange bool) error {
+	tx, err := configs.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var version int
+	query := `
+		UPDATE login_users
+		SET pass = $2, must_change_password = $3, token_version = token_version + 1, updated_at = NOW()
+		WHERE uid = $1
+		RETURNING token_version`
+
+	err = tx.QueryRowContext(ctx, query, uid, hashedPassword, mustChange).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return sql.ErrNoRows
+	}
+	if err != nil {
+		return fmt.Errorf("gagal memperbarui password: %w", err)
+	}
+
+	if err := recordPasswordHistory(ctx, tx, pc.Policy.HistorySize, uid, hashedPassword); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("gagal commit password: %w", err)
+	}
+
+	cacheTokenVersion(context.WithoutCancel(ctx), uid, version)
+	return nil
+}
+
+// UpgradePasswordHash mengganti hash lama dengan hash baru dari password yang sama (rehash saat login).
+// Hanya diterapkan jika hash di database masih oldHash, supaya tidak menimpa password yang baru diganti.
+// token_version dan riwayat password tidak diubah karena passwordnya tetap sama.
+func UpgradePasswordHash(ctx context.Context, uid, oldHash, newHash string) error {
+	_, err := configs.DB.ExecContext(ctx, `UPDATE login_users SET pass = $3 WHERE uid = $1 AND pass = $2`, uid, oldHash, newHash)
+	if err != nil {
+		return fmt.Errorf("gagal memperbarui hash password: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+// models/password_history_db.go
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"go-sis-be/internal/configs"
+	"go-sis-be/internal/utils"
+)
+
+// execer: *sql.DB maupun *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// PasswordConfig: Kebijakan dan parameter hash untuk semua jalur yang menyetel password
+type PasswordConfig struct {
+	Policy utils.PasswordPolicy
+	Hash   utils.PasswordHashConfig
+}
+
+// DefaultPasswordConfig: Kebijakan dan hash bawaan (dipakai test dan fake in-memory)
+func DefaultPasswordConfig() PasswordConfig {
+	return PasswordConfig{Policy: utils.DefaultPasswordPolicy(), Hash: utils.DefaultPasswordHashConfig()}
+}
+
+// hashNewPassword menjalankan kebijakan password lalu meng-hash password.
+// Dipakai oleh semua jalur pembuatan user supaya kebijakan tidak bisa dilewati.
+func hashNewPassword(pc PasswordConfig, password, username string) (string, error) {
+	if err := pc.Policy.Validate(password, username); err != nil {
+		return "", err
+	}
+	return pc.Hash.Hash(password)
+}
+
+// ValidateNewPassword menjalankan kebijakan password dan cek riwayat untuk password baru user.
+// Mengembalikan *utils.PasswordPolicyError jika melanggar, sql.ErrNoRows jika user tidak ada.
+func ValidateNewPassword(ctx context.Context, pc PasswordConfig, uid, password string) error {
+	var username string
+	err := configs.DB.QueryRowContext(ctx, `SELECT username FROM login_users WHERE uid = $1`, uid).Scan(&username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return sql.ErrNoRows
+		}
+		return fmt.Errorf("gagal mengambil user: %w", err)
+	}
+
+	if err := pc.Policy.Validate(password, username); err != nil {
+		return err
+	}
+	return CheckPasswordHistory(ctx, pc.Policy.HistorySize, uid, password)
+}
+
+// ChangePassword mengganti password user setelah lolos ValidateNewPassword.
+// Mengembalikan *utils.PasswordPolicyError jika melanggar, sql.ErrNoRows jika user tidak ada.
+func ChangePassword(ctx context.Context, pc PasswordConfig, uid, password string, mustChange bool) error {
+	if err := ValidateNewPassword(ctx, pc, uid, password); err != nil {
+		return err
+	}
+
+	hashed, err := pc.Hash.Hash(password)
+	if err != nil {
+		return err
+	}
+	return UpdatePassword(ctx, pc, uid, hashed, mustChange)
+}
+
+// CheckPasswordHistory menolak password yang sama dengan password saat ini
+// atau salah satu dari size password sebelumnya.
+func CheckPasswordHistory(ctx context.Context, size int, uid, password string) error {
+	if size <= 0 {
+		return nil
+	}
+
+	query := `
+		(SELECT pass FROM login_users WHERE uid = $1)
+		UNION ALL
+		(SELECT password_hash FROM password_history WHERE uid = $1 ORDER BY created_at DESC, id DESC LIMIT $2)`
+
+	rows, err := configs.DB.QueryContext(ctx, query, uid, size)
+	if err != nil {
+		return fmt.Errorf("gagal mengambil riwayat password: %w", err)
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var h string
+		if err := rows.Scan(&h); err != nil {
+			return fmt.Errorf("gagal scan riwayat password: %w", err)
+		}
+		hashes = append(hashes, h)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, h := range hashes {
+		if utils.CheckPasswordHash(password, h) {
+			return utils.PasswordReusedError(size)
+		}
+	}
+	return nil
+}
+
+// recordPasswordHistory menyimpan hash password baru ke riwayat dan membuang entri
+// yang sudah di luar jangkauan size
+func recordPasswordHistory(ctx context.Context, db execer, size int, uid, hash string) error {
+	if size <= 0 {
+		return nil
+	}
+
+	_, err := db.ExecContext(ctx, `INSERT INTO password_history (uid, password_hash, created_at) VALUES ($1, $2, NOW())`, uid, hash)
+	if err != nil {
+		return fmt.Errorf("gagal menyimpan riwayat password: %w", err)
+	}
+
+	_, err = db.ExecContext(ctx, `
+		DELETE FROM password_history
+		WHERE uid = $1 AND id NOT IN (
+			SELECT id FROM password_history WHERE uid = $1 ORDER BY created_at DESC, id DESC LIMIT $2
+		)`, uid, size)
+	if err != nil {
+		return fmt.Errorf("gagal merapikan riwayat password: %w", err)
+	}
+	return nil
+}
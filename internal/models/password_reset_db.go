@@ -0,0 +1,117 @@
+// models/password_reset_db.go
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-sis-be/internal/configs"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Token reset disimpan di Redis dalam bentuk hash SHA-256, bukan token aslinya.
+// Key "password_reset:<hash>" -> uid, dan "password_reset_user:<uid>" -> hash (token terakhir user).
+const (
+	passwordResetPrefix     = "password_reset:"
+	passwordResetUserPrefix = "password_reset_user:"
+)
+
+// ErrResetTokenInvalid: Token reset tidak ada, kadaluarsa, atau sudah dipakai
+var ErrResetTokenInvalid = errors.New("token reset tidak valid atau sudah kadaluarsa")
+
+// ResetRecipient: Data user yang dibutuhkan untuk mengirim email reset password
+type ResetRecipient struct {
+	UID      string
+	FullName string
+	Email    string
+}
+
+// GetResetRecipient mencari user berdasarkan username beserta email di tabel person.
+// Mengembalikan nil jika user tidak ada atau tidak punya email.
+func GetResetRecipient(ctx context.Context, username string) (*ResetRecipient, error) {
+	var rec ResetRecipient
+	var fullName, email sql.NullString
+
+	query := `
+		SELECT u.uid, p.full_name, p.email
+		FROM login_users u
+		LEFT JOIN person p ON p.uid = u.uid
+		WHERE u.username = $1`
+
+	err := configs.DB.QueryRowContext(ctx, query, username).Scan(&rec.UID, &fullName, &email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil data reset password: %w", err)
+	}
+	if !email.Valid || email.String == "" {
+		return nil, nil
+	}
+
+	rec.FullName = fullName.String
+	rec.Email = email.String
+	return &rec, nil
+}
+
+// StorePasswordResetToken menyimpan token reset untuk uid dengan masa berlaku ttl.
+// Token reset sebelumnya milik uid yang sama otomatis tidak berlaku.
+func StorePasswordResetToken(ctx context.Context, token, uid string, ttl time.Duration) error {
+	hash := hashResetToken(token)
+
+	prev, err := configs.RedisClient.Get(ctx, passwordResetUserPrefix+uid).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("gagal cek token reset lama: %w", err)
+	}
+
+	pipe := configs.RedisClient.TxPipeline()
+	if prev != "" {
+		pipe.Del(ctx, passwordResetPrefix+prev)
+	}
+	pipe.Set(ctx, passwordResetPrefix+hash, uid, ttl)
+	pipe.Set(ctx, passwordResetUserPrefix+uid, hash, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("gagal menyimpan token reset: %w", err)
+	}
+	return nil
+}
+
+// PeekPasswordResetToken mengambil uid pemilik token reset tanpa memakainya
+func PeekPasswordResetToken(ctx context.Context, token string) (string, error) {
+	uid, err := configs.RedisClient.Get(ctx, passwordResetPrefix+hashResetToken(token)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrResetTokenInvalid
+	}
+	if err != nil {
+		return "", fmt.Errorf("gagal mengambil token reset: %w", err)
+	}
+	return uid, nil
+}
+
+// ConsumePasswordResetToken menukar token reset dengan uid pemiliknya. Token langsung
+// dihapus (GETDEL) sehingga hanya bisa dipakai sekali.
+func ConsumePasswordResetToken(ctx context.Context, token string) (string, error) {
+	hash := hashResetToken(token)
+
+	uid, err := configs.RedisClient.GetDel(ctx, passwordResetPrefix+hash).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrResetTokenInvalid
+	}
+	if err != nil {
+		return "", fmt.Errorf("gagal mengambil token reset: %w", err)
+	}
+
+	configs.RedisClient.Del(ctx, passwordResetUserPrefix+uid)
+	return uid, nil
+}
+
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
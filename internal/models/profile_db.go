@@ -1,6 +1,7 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -15,6 +16,14 @@ const (
 	PARENT_ROLE_ID  = 4
 )
 
+// Nama role sesuai kolom roles.name, dipakai di klaim JWT (JWTClaims.Role)
+const (
+	ADMIN_ROLE_NAME   = "admin"
+	TEACHER_ROLE_NAME = "guru"
+	STUDENT_ROLE_NAME = "murid"
+	PARENT_ROLE_NAME  = "wali"
+)
+
 type InternalUnifiedProfile struct {
 	UID           string
 	RoleID        int
@@ -56,7 +65,7 @@ type InternalUnifiedProfile struct {
 	ReceivedDate sql.NullTime
 }
 
-func GetProfileAndFormat(uid string) (interface{}, error) {
+func GetProfileAndFormat(ctx context.Context, uid string) (interface{}, error) {
 	// 1. Definisikan Query LEFT JOIN Besar (Menggunakan LEFT JOIN LATERAL untuk function)
 	query := `
         SELECT
@@ -95,7 +104,7 @@ func GetProfileAndFormat(uid string) (interface{}, error) {
 	var nPhone, nEmail sql.NullString
 
 	// 2. Eksekusi Query dan Scan Hasil
-	err := configs.DB.QueryRow(query, uid).Scan(
+	err := configs.DB.QueryRowContext(ctx, query, uid).Scan(
 		// Base fields (1-12)
 		&raw.RoleID, &raw.Username, &raw.RoleName, // <<< Ditambahkan kembali RoleName
 		&raw.FullName, &raw.BirthDate, &raw.NIK, &raw.Gender, &raw.Religion,
@@ -187,8 +196,8 @@ func GetProfileAndFormat(uid string) (interface{}, error) {
 	}
 }
 
-func EditStudentProfile(uid string, req *EditStudentRequest) error {
-	tx, err := configs.DB.Begin()
+func EditStudentProfile(ctx context.Context, uid string, req *EditStudentRequest) error {
+	tx, err := configs.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -205,7 +214,7 @@ func EditStudentProfile(uid string, req *EditStudentRequest) error {
 	nPhone := sql.NullString{String: req.PhoneNumber, Valid: req.PhoneNumber != ""}
 	nEmail := sql.NullString{String: req.Email, Valid: req.Email != ""}
 
-	resPerson, err := tx.Exec(queryPerson,
+	resPerson, err := tx.ExecContext(ctx, queryPerson,
 		uid, req.FullName, req.BirthDate,
 		req.Religion, req.MaritalStatus, req.Address, nPhone, nEmail,
 	)
@@ -225,7 +234,7 @@ func EditStudentProfile(uid string, req *EditStudentRequest) error {
 
 	nReceivedDate := sql.NullString{String: req.ReceivedDate, Valid: req.ReceivedDate != ""}
 
-	_, err = tx.Exec(queryStudent,
+	_, err = tx.ExecContext(ctx, queryStudent,
 		uid, req.NISN, req.NIS, nReceivedDate,
 	)
 	if err != nil {
@@ -240,24 +249,24 @@ func EditStudentProfile(uid string, req *EditStudentRequest) error {
 	return nil
 }
 
-func DeleteStudentProfile(uid string) error {
-	tx, err := configs.DB.Begin()
+func DeleteStudentProfile(ctx context.Context, uid string) error {
+	tx, err := configs.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	_, err = tx.Exec("DELETE FROM student_details WHERE uid = $1", uid)
+	_, err = tx.ExecContext(ctx, "DELETE FROM student_details WHERE uid = $1", uid)
 	if err != nil {
 		return fmt.Errorf("gagal delete data murid: %w", err)
 	}
 
-	_, err = tx.Exec("DELETE FROM person WHERE uid = $1", uid)
+	_, err = tx.ExecContext(ctx, "DELETE FROM person WHERE uid = $1", uid)
 	if err != nil {
 		return fmt.Errorf("gagal delete person (student): %w", err)
 	}
 
-	res, err := tx.Exec("DELETE FROM login_users WHERE uid = $1", uid)
+	res, err := tx.ExecContext(ctx, "DELETE FROM login_users WHERE uid = $1", uid)
 	if err != nil {
 		return fmt.Errorf("gagal delete login user (student): %w", err)
 	}
@@ -270,11 +279,13 @@ func DeleteStudentProfile(uid string) error {
 		return err
 	}
 
+	// Token user yang dihapus langsung ditolak (lookup token version tidak menemukan user)
+	markTokenVersionGone(context.WithoutCancel(ctx), uid)
 	return nil
 }
 
-func EditTeacherProfile(uid string, req *EditTeacherRequest) error {
-	tx, err := configs.DB.Begin()
+func EditTeacherProfile(ctx context.Context, uid string, req *EditTeacherRequest) error {
+	tx, err := configs.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -293,7 +304,7 @@ func EditTeacherProfile(uid string, req *EditTeacherRequest) error {
 	nPhone := sql.NullString{String: req.PhoneNumber, Valid: req.PhoneNumber != ""}
 	nEmail := sql.NullString{String: req.Email, Valid: req.Email != ""}
 
-	resPerson, err := tx.Exec(queryPerson,
+	resPerson, err := tx.ExecContext(ctx, queryPerson,
 		uid, req.FullName,
 		req.Religion, req.MaritalStatus, req.Address, nPhone, nEmail,
 	)
@@ -324,7 +335,7 @@ func EditTeacherProfile(uid string, req *EditTeacherRequest) error {
 	nCert := sql.NullString{String: req.EducatorCertNumber, Valid: req.EducatorCertNumber != ""}
 	nDiploma := sql.NullString{String: req.DiplomaNumber, Valid: req.DiplomaNumber != ""}
 
-	_, err = tx.Exec(queryTeacher,
+	_, err = tx.ExecContext(ctx, queryTeacher,
 		uid, req.NIP, nNuptk, nNrg, req.FunctionalPosition, req.EmploymentStatus,
 		nRank, req.HireDate, nSK, nCert,
 		req.LastEducation, req.University, req.Major, req.GraduationYear, nDiploma,
@@ -340,24 +351,24 @@ func EditTeacherProfile(uid string, req *EditTeacherRequest) error {
 	return nil
 }
 
-func DeleteTeacherProfile(uid string) error {
-	tx, err := configs.DB.Begin()
+func DeleteTeacherProfile(ctx context.Context, uid string) error {
+	tx, err := configs.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	_, err = tx.Exec("DELETE FROM teacher_details WHERE uid = $1", uid)
+	_, err = tx.ExecContext(ctx, "DELETE FROM teacher_details WHERE uid = $1", uid)
 	if err != nil {
 		return fmt.Errorf("gagal delete data guru: %w", err)
 	}
 
-	_, err = tx.Exec("DELETE FROM person WHERE uid = $1", uid)
+	_, err = tx.ExecContext(ctx, "DELETE FROM person WHERE uid = $1", uid)
 	if err != nil {
 		return fmt.Errorf("gagal delete person (teacher): %w", err)
 	}
 
-	res, err := tx.Exec("DELETE FROM login_users WHERE uid = $1", uid)
+	res, err := tx.ExecContext(ctx, "DELETE FROM login_users WHERE uid = $1", uid)
 	if err != nil {
 		return fmt.Errorf("gagal delete login user (teacher): %w", err)
 	}
@@ -370,5 +381,6 @@ func DeleteTeacherProfile(uid string) error {
 		return err
 	}
 
+	markTokenVersionGone(context.WithoutCancel(ctx), uid)
 	return nil
 }
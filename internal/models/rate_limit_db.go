@@ -0,0 +1,83 @@
+// models/rate_limit_db.go
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"go-sis-be/internal/configs"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// rateLimitPrefix: Sliding window per grup dan subjek disimpan sebagai sorted set
+//
+//	ratelimit:<grup>:<ip:... | uid:...> -> member per request, score = waktu request (ms)
+const rateLimitPrefix = "ratelimit:"
+
+// RateLimitResult: Hasil pengecekan satu request terhadap batasnya
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	Reset     time.Duration // Sampai satu slot kosong lagi (request tertua keluar dari jendela)
+}
+
+// slidingWindowScript membuang request di luar jendela, lalu mencatat request baru jika masih ada slot.
+// Dijalankan sebagai satu script supaya atomik walau ada banyak instance API.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', key, window)
+
+local reset = window
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, count, reset}
+`)
+
+// HitRateLimit mencatat satu request untuk subject (mis. "ip:1.2.3.4" atau "uid:...") di grup name
+// dan mengembalikan apakah request masih dalam batas.
+func HitRateLimit(ctx context.Context, name, subject string, limit int, window time.Duration) (RateLimitResult, error) {
+	member := make([]byte, 8)
+	if _, err := rand.Read(member); err != nil {
+		return RateLimitResult{}, err
+	}
+	now := time.Now().UnixMilli()
+
+	res, err := slidingWindowScript.Run(ctx, configs.RedisClient,
+		[]string{rateLimitPrefix + name + ":" + subject},
+		now, window.Milliseconds(), limit, fmt.Sprintf("%d-%s", now, hex.EncodeToString(member)),
+	).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("gagal cek rate limit: %w", err)
+	}
+	if len(res) != 3 {
+		return RateLimitResult{}, fmt.Errorf("hasil rate limit tidak dikenal: %v", res)
+	}
+
+	remaining := limit - int(res[1])
+	if remaining < 0 {
+		remaining = 0
+	}
+	return RateLimitResult{
+		Allowed:   res[0] == 1,
+		Remaining: remaining,
+		Reset:     time.Duration(res[2]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,116 @@
+// models/refresh_token_db.go
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go-sis-be/internal/configs"
+)
+
+// ErrRefreshTokenReused: Refresh token yang sudah dirotasi/dicabut dipakai lagi
+var ErrRefreshTokenReused = errors.New("refresh token sudah pernah dipakai")
+
+// RefreshTokenRecord: Satu baris di tabel refresh_tokens.
+// Satu login menghasilkan satu family; setiap refresh menambah token baru di family yang sama
+// dan mencabut token sebelumnya (replaced_by menunjuk ke penggantinya).
+type RefreshTokenRecord struct {
+	JTI        string
+	UID        string
+	FamilyID   string
+	ExpiresAt  time.Time
+	RevokedAt  sql.NullTime
+	ReplacedBy sql.NullString
+	CreatedAt  time.Time
+}
+
+// CreateRefreshToken mencatat refresh token baru (token pertama dari sebuah family saat login)
+func CreateRefreshToken(ctx context.Context, jti, uid, familyID string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO refresh_tokens (jti, uid, family_id, expires_at)
+		VALUES ($1, $2, $3, $4)`
+
+	_, err := configs.DB.ExecContext(ctx, query, jti, uid, familyID, expiresAt)
+	if err != nil {
+		return fmt.Errorf("gagal menyimpan refresh token: %w", err)
+	}
+	return nil
+}
+
+// GetRefreshTokenRecord mengambil data refresh token berdasarkan jti. Mengembalikan nil jika tidak ada.
+func GetRefreshTokenRecord(ctx context.Context, jti string) (*RefreshTokenRecord, error) {
+	var rec RefreshTokenRecord
+
+	query := `
+		SELECT jti, uid, family_id, expires_at, revoked_at, replaced_by, created_at
+		FROM refresh_tokens
+		WHERE jti = $1`
+
+	err := configs.DB.QueryRowContext(ctx, query, jti).Scan(
+		&rec.JTI, &rec.UID, &rec.FamilyID, &rec.ExpiresAt,
+		&rec.RevokedAt, &rec.ReplacedBy, &rec.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil refresh token: %w", err)
+	}
+	return &rec, nil
+}
+
+// RotateRefreshToken mencabut token oldJTI dan mencatat penggantinya newJTI dalam family yang sama.
+// Jika oldJTI ternyata sudah dicabut (misal dipakai dua kali bersamaan), mengembalikan ErrRefreshTokenReused.
+func RotateRefreshToken(ctx context.Context, oldJTI, newJTI string, expiresAt time.Time) error {
+	tx, err := configs.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var uid, familyID string
+	queryRevoke := `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW(), replaced_by = $2
+		WHERE jti = $1 AND revoked_at IS NULL
+		RETURNING uid, family_id`
+
+	err = tx.QueryRowContext(ctx, queryRevoke, oldJTI, newJTI).Scan(&uid, &familyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrRefreshTokenReused
+	}
+	if err != nil {
+		return fmt.Errorf("gagal mencabut refresh token lama: %w", err)
+	}
+
+	queryInsert := `
+		INSERT INTO refresh_tokens (jti, uid, family_id, expires_at)
+		VALUES ($1, $2, $3, $4)`
+
+	if _, err = tx.ExecContext(ctx, queryInsert, newJTI, uid, familyID, expiresAt); err != nil {
+		return fmt.Errorf("gagal menyimpan refresh token baru: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// RevokeTokenFamily mencabut semua token yang masih aktif dalam satu family
+func RevokeTokenFamily(ctx context.Context, familyID string) error {
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE family_id = $1 AND revoked_at IS NULL`
+
+	res, err := configs.DB.ExecContext(ctx, query, familyID)
+	if err != nil {
+		return fmt.Errorf("gagal mencabut token family: %w", err)
+	}
+
+	rows, _ := res.RowsAffected()
+	log.Printf("[SECURITY] Token family %s dicabut (%d token aktif)", familyID, rows)
+	return nil
+}
@@ -2,17 +2,17 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
 	"go-sis-be/internal/configs"
-	"go-sis-be/internal/utils"
 )
 
 // RegisterStudent melakukan insert ke 3 tabel (login_users, person, student_details) dalam satu transaksi
-func RegisterStudent(req *RegisterStudentRequest) (*UserProfileResponse, error) {
+func RegisterStudent(ctx context.Context, pc PasswordConfig, req *RegisterStudentRequest) (*UserProfileResponse, error) {
 	// 1. Mulai Transaksi
-	tx, err := configs.DB.Begin()
+	tx, err := configs.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -21,7 +21,7 @@ func RegisterStudent(req *RegisterStudentRequest) (*UserProfileResponse, error)
 	// ==========================================
 	// STEP 1: Insert ke login_users
 	// ==========================================
-	hashedPassword, err := utils.HashPassword(req.Password)
+	hashedPassword, err := hashNewPassword(pc, req.Password, req.Username)
 	if err != nil {
 		return nil, err
 	}
@@ -32,10 +32,13 @@ func RegisterStudent(req *RegisterStudentRequest) (*UserProfileResponse, error)
 		VALUES ($1, $2, $3) 
 		RETURNING uid`
 
-	err = tx.QueryRow(queryLogin, req.Username, hashedPassword, req.RoleID).Scan(&uid)
+	err = tx.QueryRowContext(ctx, queryLogin, req.Username, hashedPassword, req.RoleID).Scan(&uid)
 	if err != nil {
 		return nil, fmt.Errorf("gagal insert login: %w", err)
 	}
+	if err := recordPasswordHistory(ctx, tx, pc.Policy.HistorySize, uid, hashedPassword); err != nil {
+		return nil, err
+	}
 
 	// ==========================================
 	// STEP 2: Insert ke person
@@ -46,7 +49,7 @@ func RegisterStudent(req *RegisterStudentRequest) (*UserProfileResponse, error)
 			marital_status, address, phone_number, email
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
 
-	_, err = tx.Exec(queryPerson,
+	_, err = tx.ExecContext(ctx, queryPerson,
 		uid, req.FullName, req.BirthDate, req.NIK, req.Gender, req.Religion,
 		req.MaritalStatus, req.Address, req.PhoneNumber, req.Email,
 	)
@@ -60,7 +63,7 @@ func RegisterStudent(req *RegisterStudentRequest) (*UserProfileResponse, error)
 
 	// A. Generate NIS
 	var nisSeq int64
-	err = tx.QueryRow("SELECT nextval('nis_seq')").Scan(&nisSeq)
+	err = tx.QueryRowContext(ctx, "SELECT nextval('nis_seq')").Scan(&nisSeq)
 	if err != nil {
 		return nil, fmt.Errorf("gagal generate nis sequence: %w", err)
 	}
@@ -86,7 +89,7 @@ func RegisterStudent(req *RegisterStudentRequest) (*UserProfileResponse, error)
 	gPhone := sql.NullString{String: req.GuardianPhone, Valid: req.GuardianPhone != ""}
 	gJob := sql.NullString{String: req.GuardianJob, Valid: req.GuardianJob != ""}
 
-	_, err = tx.Exec(queryStudent,
+	_, err = tx.ExecContext(ctx, queryStudent,
 		uid, generatedNIS, req.NISN, req.FamilyStatus, req.ChildOrder,
 		req.OriginSchool, req.ReceivedClass, req.ReceivedDate,
 		req.FatherName, req.MotherName, req.ParentAddress, req.FatherJob, req.MotherJob,
@@ -115,8 +118,8 @@ func RegisterStudent(req *RegisterStudentRequest) (*UserProfileResponse, error)
 		},
 	}, nil
 }
-func RegisterTeacher(req *RegisterTeacherRequest) (*UserProfileResponse, error) {
-	tx, err := configs.DB.Begin()
+func RegisterTeacher(ctx context.Context, pc PasswordConfig, req *RegisterTeacherRequest) (*UserProfileResponse, error) {
+	tx, err := configs.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -125,7 +128,7 @@ func RegisterTeacher(req *RegisterTeacherRequest) (*UserProfileResponse, error)
 	// ==========================================
 	// STEP 1: Insert ke login_users
 	// ==========================================
-	hashedPassword, err := utils.HashPassword(req.Password)
+	hashedPassword, err := hashNewPassword(pc, req.Password, req.Username)
 	if err != nil {
 		return nil, err
 	}
@@ -136,10 +139,13 @@ func RegisterTeacher(req *RegisterTeacherRequest) (*UserProfileResponse, error)
 		VALUES ($1, $2, $3) 
 		RETURNING uid`
 
-	err = tx.QueryRow(queryLogin, req.Username, hashedPassword, req.RoleID).Scan(&uid)
+	err = tx.QueryRowContext(ctx, queryLogin, req.Username, hashedPassword, req.RoleID).Scan(&uid)
 	if err != nil {
 		return nil, fmt.Errorf("gagal insert login: %w", err)
 	}
+	if err := recordPasswordHistory(ctx, tx, pc.Policy.HistorySize, uid, hashedPassword); err != nil {
+		return nil, err
+	}
 
 	// ==========================================
 	// STEP 2: Insert ke person
@@ -150,7 +156,7 @@ func RegisterTeacher(req *RegisterTeacherRequest) (*UserProfileResponse, error)
 			marital_status, address, phone_number, email
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
 
-	_, err = tx.Exec(queryPerson,
+	_, err = tx.ExecContext(ctx, queryPerson,
 		uid, req.FullName, req.BirthDate, req.NIK, req.Gender, req.Religion,
 		req.MaritalStatus, req.Address, req.PhoneNumber, req.Email,
 	)
@@ -180,7 +186,7 @@ func RegisterTeacher(req *RegisterTeacherRequest) (*UserProfileResponse, error)
 	nSK := sql.NullString{String: req.SKAppointmentNumber, Valid: req.SKAppointmentNumber != ""}
 	nCert := sql.NullString{String: req.EducatorCertNumber, Valid: req.EducatorCertNumber != ""}
 
-	_, err = tx.Exec(queryTeacher,
+	_, err = tx.ExecContext(ctx, queryTeacher,
 		uid, nNip, nNuptk, nNrg, req.FunctionalPosition, req.EmploymentStatus,
 		nRank, req.HireDate,
 		nSK, nCert,
@@ -218,8 +224,8 @@ func RegisterTeacher(req *RegisterTeacherRequest) (*UserProfileResponse, error)
 		},
 	}, nil
 }
-func RegisterBaseUser(req *RegisterBaseRequest) (*UserProfileResponse, error) {
-	tx, err := configs.DB.Begin()
+func RegisterBaseUser(ctx context.Context, pc PasswordConfig, req *RegisterBaseRequest) (*UserProfileResponse, error) {
+	tx, err := configs.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -228,7 +234,7 @@ func RegisterBaseUser(req *RegisterBaseRequest) (*UserProfileResponse, error) {
 	// ==========================================
 	// STEP 1: Insert ke login_users
 	// ==========================================
-	hashedPassword, err := utils.HashPassword(req.Password)
+	hashedPassword, err := hashNewPassword(pc, req.Password, req.Username)
 	if err != nil {
 		return nil, err
 	}
@@ -239,10 +245,13 @@ func RegisterBaseUser(req *RegisterBaseRequest) (*UserProfileResponse, error) {
 		VALUES ($1, $2, $3) 
 		RETURNING uid`
 
-	err = tx.QueryRow(queryLogin, req.Username, hashedPassword, req.RoleID).Scan(&uid)
+	err = tx.QueryRowContext(ctx, queryLogin, req.Username, hashedPassword, req.RoleID).Scan(&uid)
 	if err != nil {
 		return nil, fmt.Errorf("gagal insert login untuk %s: %w", req.Username, err)
 	}
+	if err := recordPasswordHistory(ctx, tx, pc.Policy.HistorySize, uid, hashedPassword); err != nil {
+		return nil, err
+	}
 
 	// ==========================================
 	// STEP 2: Insert ke person
@@ -253,7 +262,7 @@ func RegisterBaseUser(req *RegisterBaseRequest) (*UserProfileResponse, error) {
 			marital_status, address, phone_number, email
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
 
-	_, err = tx.Exec(queryPerson,
+	_, err = tx.ExecContext(ctx, queryPerson,
 		uid, req.FullName, req.BirthDate, req.NIK, req.Gender, req.Religion,
 		req.MaritalStatus, req.Address, req.PhoneNumber, req.Email,
 	)
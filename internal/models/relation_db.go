@@ -0,0 +1,42 @@
+// models/relation_db.go
+package models
+
+import (
+	"context"
+	"fmt"
+
+	"go-sis-be/internal/configs"
+)
+
+// IsParentOfStudent mengecek apakah parentUID tercatat sebagai wali dari studentUID
+func IsParentOfStudent(ctx context.Context, parentUID, studentUID string) (bool, error) {
+	var exists bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM student_details
+			WHERE uid = $1 AND parent_uid = $2
+		)`
+
+	err := configs.DB.QueryRowContext(ctx, query, studentUID, parentUID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("gagal cek relasi wali murid: %w", err)
+	}
+	return exists, nil
+}
+
+// IsTeacherOfStudent mengecek apakah teacherUID mengajar di salah satu kelas milik studentUID
+func IsTeacherOfStudent(ctx context.Context, teacherUID, studentUID string) (bool, error) {
+	var exists bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM class_students cs
+			JOIN class_teachers ct ON ct.class_id = cs.class_id
+			WHERE cs.student_uid = $1 AND ct.teacher_uid = $2
+		)`
+
+	err := configs.DB.QueryRowContext(ctx, query, studentUID, teacherUID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("gagal cek relasi guru-murid: %w", err)
+	}
+	return exists, nil
+}
@@ -0,0 +1,643 @@
+// models/repository.go
+package models
+
+import (
+	"context"
+	"time"
+
+	"go-sis-be/internal/configs"
+)
+
+// Interface penyimpanan yang dipakai handler. Implementasi Postgres/Redis di bawah memakai
+// fungsi package ini (configs.DB & configs.RedisClient); test memakai fake in-memory
+// dari package models/memory sehingga handler bisa dites tanpa database.
+
+// UserRepository: Akun login (tabel login_users)
+type UserRepository interface {
+	GetUserForLogin(ctx context.Context, username string) (*User, string, error)
+	GetUserByID(ctx context.Context, uid string) (*UserResponse, error)
+	GetUserIdentityByUID(ctx context.Context, uid string) (*UserIdentity, error)
+	GetRoleIDByUID(ctx context.Context, uid string) (int, error)
+	GetAllUsers(ctx context.Context, page int, limit int, search string, roleID int) ([]UserResponse, int, error)
+	CreateUser(ctx context.Context, req *CreateUserRequest) (*UserResponse, error)
+	DeleteUser(ctx context.Context, uid string) error
+	ChangeUserRole(ctx context.Context, uid string, roleID int) error
+	UpgradePasswordHash(ctx context.Context, uid, oldHash, newHash string) error
+}
+
+// ProfileRepository: Registrasi dan profil lengkap per role (person, student_details, teacher_details)
+type ProfileRepository interface {
+	RegisterStudent(ctx context.Context, req *RegisterStudentRequest) (*UserProfileResponse, error)
+	RegisterTeacher(ctx context.Context, req *RegisterTeacherRequest) (*UserProfileResponse, error)
+	RegisterBaseUser(ctx context.Context, req *RegisterBaseRequest) (*UserProfileResponse, error)
+	GetProfileAndFormat(ctx context.Context, uid string) (interface{}, error)
+	EditStudentProfile(ctx context.Context, uid string, req *EditStudentRequest) error
+	EditTeacherProfile(ctx context.Context, uid string, req *EditTeacherRequest) error
+	DeleteStudentProfile(ctx context.Context, uid string) error
+	DeleteTeacherProfile(ctx context.Context, uid string) error
+}
+
+// SessionStore: Sesi login per perangkat beserta refresh token family-nya
+type SessionStore interface {
+	CreateSession(ctx context.Context, uid, device, ip, userAgent string) (string, error)
+	GetSession(ctx context.Context, id string) (*Session, error)
+	TouchSession(ctx context.Context, id, ip string) error
+	ListActiveSessions(ctx context.Context, uid string) ([]Session, error)
+	RevokeSession(ctx context.Context, uid, sessionID string) error
+	RevokeAllSessions(ctx context.Context, uid string) (int64, error)
+
+	CreateRefreshToken(ctx context.Context, jti, uid, familyID string, expiresAt time.Time) error
+	GetRefreshTokenRecord(ctx context.Context, jti string) (*RefreshTokenRecord, error)
+	RotateRefreshToken(ctx context.Context, oldJTI, newJTI string, expiresAt time.Time) error
+	RevokeTokenFamily(ctx context.Context, familyID string) error
+}
+
+// TokenStore: Pencabutan access token (blacklist & token version)
+type TokenStore interface {
+	BlacklistToken(ctx context.Context, token string, expiry time.Duration) error
+	BumpTokenVersion(ctx context.Context, uid string) (int, error)
+	TokenBlacklistStatus() TokenBlacklistHealth
+}
+
+// LoginAttemptStore: Penghitung gagal login dan penguncian sementara
+type LoginAttemptStore interface {
+	GetLoginLockTTL(ctx context.Context, username, ip string) time.Duration
+	RegisterLoginFailure(ctx context.Context, username, ip string, cfg configs.LoginLockoutConfig) (LoginFailure, error)
+	ResetLoginFailures(ctx context.Context, username string)
+	UnlockLogin(ctx context.Context, username, ip string) (bool, error)
+}
+
+// TwoFactorStore: Enrollment dan verifikasi 2FA (TOTP, recovery code, challenge login)
+type TwoFactorStore interface {
+	IsTwoFactorEnabled(ctx context.Context, uid string) (bool, error)
+	GetTwoFactor(ctx context.Context, uid string) (*TwoFactor, error)
+	SavePendingTwoFactor(ctx context.Context, uid, secret string) error
+	EnableTwoFactor(ctx context.Context, uid string, recoveryCodes []string) error
+	UseRecoveryCode(ctx context.Context, uid, code string) (bool, error)
+	CountRemainingRecoveryCodes(ctx context.Context, uid string) (int, error)
+	MarkTOTPStepUsed(ctx context.Context, uid string, step int64) (bool, error)
+
+	RegisterTwoFactorFailure(ctx context.Context, challengeID string, ttl time.Duration) (int64, error)
+	IsTwoFactorChallengeUsed(ctx context.Context, challengeID string) (bool, error)
+	ConsumeTwoFactorChallenge(ctx context.Context, challengeID string, ttl time.Duration) (bool, error)
+}
+
+// PasswordStore: Ganti password beserta kebijakan dan riwayatnya
+type PasswordStore interface {
+	GetPasswordHash(ctx context.Context, uid string) (string, error)
+	ValidateNewPassword(ctx context.Context, uid, password string) error
+	ChangePassword(ctx context.Context, uid, password string, mustChange bool) error
+	UpdatePassword(ctx context.Context, uid, hashedPassword string, mustChange bool) error
+}
+
+// ResetTokenStore: Token reset password sekali pakai yang dikirim lewat email
+type ResetTokenStore interface {
+	GetResetRecipient(ctx context.Context, username string) (*ResetRecipient, error)
+	StorePasswordResetToken(ctx context.Context, token, uid string, ttl time.Duration) error
+	PeekPasswordResetToken(ctx context.Context, token string) (string, error)
+	ConsumePasswordResetToken(ctx context.Context, token string) (string, error)
+}
+
+// OIDCStore: State alur login OIDC dan identitas eksternal yang terhubung ke akun
+type OIDCStore interface {
+	SaveOIDCState(ctx context.Context, state string, data OIDCState, ttl time.Duration) error
+	ConsumeOIDCState(ctx context.Context, state string) (*OIDCState, error)
+	GetLinkedUID(ctx context.Context, provider, subject string) (string, error)
+	FindUIDByEmail(ctx context.Context, email string) (string, error)
+	LinkIdentity(ctx context.Context, uid, provider, subject, email string) error
+	TouchIdentity(ctx context.Context, provider, subject string)
+	ListIdentities(ctx context.Context, uid string) ([]UserIdentityLink, error)
+	UnlinkIdentity(ctx context.Context, uid, provider string) error
+}
+
+// ServiceAccountStore: Service account dan API key-nya
+type ServiceAccountStore interface {
+	CreateServiceAccount(ctx context.Context, sa *ServiceAccount) error
+	ListServiceAccounts(ctx context.Context) ([]ServiceAccount, error)
+	GetServiceAccount(ctx context.Context, id string) (*ServiceAccount, error)
+	UpdateServiceAccount(ctx context.Context, id, name, description string, scopes []string, disabled bool) error
+	DeleteServiceAccount(ctx context.Context, id string) error
+	CreateAPIKey(ctx context.Context, serviceAccountID, key, prefix string, expiresAt *time.Time) (*APIKey, error)
+	RevokeAPIKey(ctx context.Context, serviceAccountID, keyID string) error
+}
+
+// AuthStore: Pengecekan yang dijalankan AuthMiddleware di setiap request
+type AuthStore interface {
+	IsTokenBlacklisted(ctx context.Context, token string) bool
+	GetTokenVersion(ctx context.Context, uid string) (int, error)
+	IsSessionRevoked(ctx context.Context, sessionID string) bool
+	AuthenticateAPIKey(ctx context.Context, key, ip string) (*APIKeyPrincipal, error)
+}
+
+// AuditStore: Catatan audit perubahan data dan event keamanan otentikasi
+type AuditStore interface {
+	InsertAuditLog(ctx context.Context, entry *AuditLog) error
+	ListAuditLogs(ctx context.Context, f AuditFilter) ([]AuditLog, int, error)
+	RecordAuthEvent(ctx context.Context, ev AuthEvent)
+}
+
+var (
+	_ UserRepository    = PostgresUsers{}
+	_ ProfileRepository = PostgresProfiles{}
+	_ SessionStore      = PostgresSessions{}
+	_ TokenStore        = RedisTokens{}
+	_ LoginAttemptStore = RedisLoginAttempts{}
+	_ TwoFactorStore    = PostgresTwoFactor{}
+	_ AuditStore        = PostgresAudit{}
+
+	_ PasswordStore       = PostgresPasswords{}
+	_ ResetTokenStore     = RedisResetTokens{}
+	_ OIDCStore           = PostgresOIDC{}
+	_ ServiceAccountStore = PostgresServiceAccounts{}
+	_ AuthStore           = RedisAuth{}
+)
+
+// QueryTimeout: Batas waktu per operasi database (DB_QUERY_TIMEOUT_MS, 0 = tanpa batas),
+// disematkan di setiap implementasi yang menyentuh Postgres
+type QueryTimeout struct {
+	Timeout time.Duration
+}
+
+// withTimeout menurunkan ctx dengan batas waktu query. done wajib di-defer dengan alamat error hasil
+// (nil bila tidak ada) agar 57014 akibat deadline ini terbaca sebagai context.DeadlineExceeded.
+func (q QueryTimeout) withTimeout(ctx context.Context) (context.Context, func(*error)) {
+	ctx, cancel := configs.WithQueryTimeout(ctx, q.Timeout)
+	return ctx, func(err *error) {
+		if err != nil {
+			*err = configs.QueryError(ctx, *err)
+		}
+		cancel()
+	}
+}
+
+// PostgresUsers: Implementasi UserRepository berbasis Postgres
+type PostgresUsers struct {
+	QueryTimeout
+	Passwords PasswordConfig // Kebijakan & hash untuk password user baru
+}
+
+func (u PostgresUsers) GetUserForLogin(ctx context.Context, username string) (_ *User, _ string, err error) {
+	ctx, done := u.withTimeout(ctx)
+	defer done(&err)
+	return GetUserForLogin(ctx, username)
+}
+
+func (u PostgresUsers) GetUserByID(ctx context.Context, uid string) (_ *UserResponse, err error) {
+	ctx, done := u.withTimeout(ctx)
+	defer done(&err)
+	return GetUserByID(ctx, uid)
+}
+
+func (u PostgresUsers) GetUserIdentityByUID(ctx context.Context, uid string) (_ *UserIdentity, err error) {
+	ctx, done := u.withTimeout(ctx)
+	defer done(&err)
+	return GetUserIdentityByUID(ctx, uid)
+}
+
+func (u PostgresUsers) GetRoleIDByUID(ctx context.Context, uid string) (_ int, err error) {
+	ctx, done := u.withTimeout(ctx)
+	defer done(&err)
+	return GetRoleIDByUID(ctx, uid)
+}
+
+func (u PostgresUsers) GetAllUsers(ctx context.Context, page int, limit int, search string, roleID int) (_ []UserResponse, _ int, err error) {
+	ctx, done := u.withTimeout(ctx)
+	defer done(&err)
+	return GetAllUsers(ctx, page, limit, search, roleID)
+}
+
+func (u PostgresUsers) CreateUser(ctx context.Context, req *CreateUserRequest) (_ *UserResponse, err error) {
+	ctx, done := u.withTimeout(ctx)
+	defer done(&err)
+	return CreateUser(ctx, u.Passwords, req)
+}
+
+func (u PostgresUsers) DeleteUser(ctx context.Context, uid string) (err error) {
+	ctx, done := u.withTimeout(ctx)
+	defer done(&err)
+	return DeleteUser(ctx, uid)
+}
+
+func (u PostgresUsers) ChangeUserRole(ctx context.Context, uid string, roleID int) (err error) {
+	ctx, done := u.withTimeout(ctx)
+	defer done(&err)
+	return ChangeUserRole(ctx, uid, roleID)
+}
+
+func (u PostgresUsers) UpgradePasswordHash(ctx context.Context, uid, oldHash, newHash string) (err error) {
+	ctx, done := u.withTimeout(ctx)
+	defer done(&err)
+	return UpgradePasswordHash(ctx, uid, oldHash, newHash)
+}
+
+// PostgresProfiles: Implementasi ProfileRepository berbasis Postgres
+type PostgresProfiles struct {
+	QueryTimeout
+	Passwords PasswordConfig // Kebijakan & hash untuk password user baru
+}
+
+func (p PostgresProfiles) RegisterStudent(ctx context.Context, req *RegisterStudentRequest) (_ *UserProfileResponse, err error) {
+	ctx, done := p.withTimeout(ctx)
+	defer done(&err)
+	return RegisterStudent(ctx, p.Passwords, req)
+}
+
+func (p PostgresProfiles) RegisterTeacher(ctx context.Context, req *RegisterTeacherRequest) (_ *UserProfileResponse, err error) {
+	ctx, done := p.withTimeout(ctx)
+	defer done(&err)
+	return RegisterTeacher(ctx, p.Passwords, req)
+}
+
+func (p PostgresProfiles) RegisterBaseUser(ctx context.Context, req *RegisterBaseRequest) (_ *UserProfileResponse, err error) {
+	ctx, done := p.withTimeout(ctx)
+	defer done(&err)
+	return RegisterBaseUser(ctx, p.Passwords, req)
+}
+
+func (p PostgresProfiles) GetProfileAndFormat(ctx context.Context, uid string) (_ interface{}, err error) {
+	ctx, done := p.withTimeout(ctx)
+	defer done(&err)
+	return GetProfileAndFormat(ctx, uid)
+}
+
+func (p PostgresProfiles) EditStudentProfile(ctx context.Context, uid string, req *EditStudentRequest) (err error) {
+	ctx, done := p.withTimeout(ctx)
+	defer done(&err)
+	return EditStudentProfile(ctx, uid, req)
+}
+
+func (p PostgresProfiles) EditTeacherProfile(ctx context.Context, uid string, req *EditTeacherRequest) (err error) {
+	ctx, done := p.withTimeout(ctx)
+	defer done(&err)
+	return EditTeacherProfile(ctx, uid, req)
+}
+
+func (p PostgresProfiles) DeleteStudentProfile(ctx context.Context, uid string) (err error) {
+	ctx, done := p.withTimeout(ctx)
+	defer done(&err)
+	return DeleteStudentProfile(ctx, uid)
+}
+
+func (p PostgresProfiles) DeleteTeacherProfile(ctx context.Context, uid string) (err error) {
+	ctx, done := p.withTimeout(ctx)
+	defer done(&err)
+	return DeleteTeacherProfile(ctx, uid)
+}
+
+// PostgresSessions: Implementasi SessionStore berbasis Postgres (penanda sesi dicabut tetap di Redis)
+type PostgresSessions struct {
+	QueryTimeout
+}
+
+func (s PostgresSessions) CreateSession(ctx context.Context, uid, device, ip, userAgent string) (_ string, err error) {
+	ctx, done := s.withTimeout(ctx)
+	defer done(&err)
+	return CreateSession(ctx, uid, device, ip, userAgent)
+}
+
+func (s PostgresSessions) GetSession(ctx context.Context, id string) (_ *Session, err error) {
+	ctx, done := s.withTimeout(ctx)
+	defer done(&err)
+	return GetSession(ctx, id)
+}
+
+func (s PostgresSessions) TouchSession(ctx context.Context, id, ip string) (err error) {
+	ctx, done := s.withTimeout(ctx)
+	defer done(&err)
+	return TouchSession(ctx, id, ip)
+}
+
+func (s PostgresSessions) ListActiveSessions(ctx context.Context, uid string) (_ []Session, err error) {
+	ctx, done := s.withTimeout(ctx)
+	defer done(&err)
+	return ListActiveSessions(ctx, uid)
+}
+
+func (s PostgresSessions) RevokeSession(ctx context.Context, uid, sessionID string) (err error) {
+	ctx, done := s.withTimeout(ctx)
+	defer done(&err)
+	return RevokeSession(ctx, uid, sessionID)
+}
+
+func (s PostgresSessions) RevokeAllSessions(ctx context.Context, uid string) (_ int64, err error) {
+	ctx, done := s.withTimeout(ctx)
+	defer done(&err)
+	return RevokeAllSessions(ctx, uid)
+}
+
+func (s PostgresSessions) CreateRefreshToken(ctx context.Context, jti, uid, familyID string, expiresAt time.Time) (err error) {
+	ctx, done := s.withTimeout(ctx)
+	defer done(&err)
+	return CreateRefreshToken(ctx, jti, uid, familyID, expiresAt)
+}
+
+func (s PostgresSessions) GetRefreshTokenRecord(ctx context.Context, jti string) (_ *RefreshTokenRecord, err error) {
+	ctx, done := s.withTimeout(ctx)
+	defer done(&err)
+	return GetRefreshTokenRecord(ctx, jti)
+}
+
+func (s PostgresSessions) RotateRefreshToken(ctx context.Context, oldJTI, newJTI string, expiresAt time.Time) (err error) {
+	ctx, done := s.withTimeout(ctx)
+	defer done(&err)
+	return RotateRefreshToken(ctx, oldJTI, newJTI, expiresAt)
+}
+
+func (s PostgresSessions) RevokeTokenFamily(ctx context.Context, familyID string) (err error) {
+	ctx, done := s.withTimeout(ctx)
+	defer done(&err)
+	return RevokeTokenFamily(ctx, familyID)
+}
+
+// RedisTokens: Implementasi TokenStore berbasis Redis (token version tetap bersumber dari Postgres)
+type RedisTokens struct {
+	QueryTimeout
+	Blacklist configs.TokenBlacklistConfig
+}
+
+func (t RedisTokens) BlacklistToken(ctx context.Context, token string, expiry time.Duration) error {
+	return BlacklistToken(ctx, t.Blacklist, token, expiry)
+}
+
+func (t RedisTokens) BumpTokenVersion(ctx context.Context, uid string) (_ int, err error) {
+	ctx, done := t.withTimeout(ctx)
+	defer done(&err)
+	return BumpTokenVersion(ctx, uid)
+}
+
+func (t RedisTokens) TokenBlacklistStatus() TokenBlacklistHealth {
+	return TokenBlacklistStatus(t.Blacklist)
+}
+
+// RedisLoginAttempts: Implementasi LoginAttemptStore berbasis Redis
+type RedisLoginAttempts struct{}
+
+func (RedisLoginAttempts) GetLoginLockTTL(ctx context.Context, username, ip string) time.Duration {
+	return GetLoginLockTTL(ctx, username, ip)
+}
+
+func (RedisLoginAttempts) RegisterLoginFailure(ctx context.Context, username, ip string, cfg configs.LoginLockoutConfig) (LoginFailure, error) {
+	return RegisterLoginFailure(ctx, username, ip, cfg)
+}
+
+func (RedisLoginAttempts) ResetLoginFailures(ctx context.Context, username string) {
+	ResetLoginFailures(ctx, username)
+}
+
+func (RedisLoginAttempts) UnlockLogin(ctx context.Context, username, ip string) (bool, error) {
+	return UnlockLogin(ctx, username, ip)
+}
+
+// PostgresTwoFactor: Implementasi TwoFactorStore berbasis Postgres (penanda replay & challenge di Redis)
+type PostgresTwoFactor struct {
+	QueryTimeout
+}
+
+func (f PostgresTwoFactor) IsTwoFactorEnabled(ctx context.Context, uid string) (_ bool, err error) {
+	ctx, done := f.withTimeout(ctx)
+	defer done(&err)
+	return IsTwoFactorEnabled(ctx, uid)
+}
+
+func (f PostgresTwoFactor) GetTwoFactor(ctx context.Context, uid string) (_ *TwoFactor, err error) {
+	ctx, done := f.withTimeout(ctx)
+	defer done(&err)
+	return GetTwoFactor(ctx, uid)
+}
+
+func (f PostgresTwoFactor) SavePendingTwoFactor(ctx context.Context, uid, secret string) (err error) {
+	ctx, done := f.withTimeout(ctx)
+	defer done(&err)
+	return SavePendingTwoFactor(ctx, uid, secret)
+}
+
+func (f PostgresTwoFactor) EnableTwoFactor(ctx context.Context, uid string, recoveryCodes []string) (err error) {
+	ctx, done := f.withTimeout(ctx)
+	defer done(&err)
+	return EnableTwoFactor(ctx, uid, recoveryCodes)
+}
+
+func (f PostgresTwoFactor) UseRecoveryCode(ctx context.Context, uid, code string) (_ bool, err error) {
+	ctx, done := f.withTimeout(ctx)
+	defer done(&err)
+	return UseRecoveryCode(ctx, uid, code)
+}
+
+func (f PostgresTwoFactor) CountRemainingRecoveryCodes(ctx context.Context, uid string) (_ int, err error) {
+	ctx, done := f.withTimeout(ctx)
+	defer done(&err)
+	return CountRemainingRecoveryCodes(ctx, uid)
+}
+
+func (PostgresTwoFactor) MarkTOTPStepUsed(ctx context.Context, uid string, step int64) (bool, error) {
+	return MarkTOTPStepUsed(ctx, uid, step)
+}
+
+func (PostgresTwoFactor) RegisterTwoFactorFailure(ctx context.Context, challengeID string, ttl time.Duration) (int64, error) {
+	return RegisterTwoFactorFailure(ctx, challengeID, ttl)
+}
+
+func (PostgresTwoFactor) IsTwoFactorChallengeUsed(ctx context.Context, challengeID string) (bool, error) {
+	return IsTwoFactorChallengeUsed(ctx, challengeID)
+}
+
+func (PostgresTwoFactor) ConsumeTwoFactorChallenge(ctx context.Context, challengeID string, ttl time.Duration) (bool, error) {
+	return ConsumeTwoFactorChallenge(ctx, challengeID, ttl)
+}
+
+// PostgresPasswords: Implementasi PasswordStore berbasis Postgres
+type PostgresPasswords struct {
+	QueryTimeout
+	Passwords PasswordConfig
+}
+
+func (p PostgresPasswords) GetPasswordHash(ctx context.Context, uid string) (_ string, err error) {
+	ctx, done := p.withTimeout(ctx)
+	defer done(&err)
+	return GetPasswordHash(ctx, uid)
+}
+
+func (p PostgresPasswords) ValidateNewPassword(ctx context.Context, uid, password string) (err error) {
+	ctx, done := p.withTimeout(ctx)
+	defer done(&err)
+	return ValidateNewPassword(ctx, p.Passwords, uid, password)
+}
+
+func (p PostgresPasswords) ChangePassword(ctx context.Context, uid, password string, mustChange bool) (err error) {
+	ctx, done := p.withTimeout(ctx)
+	defer done(&err)
+	return ChangePassword(ctx, p.Passwords, uid, password, mustChange)
+}
+
+func (p PostgresPasswords) UpdatePassword(ctx context.Context, uid, hashedPassword string, mustChange bool) (err error) {
+	ctx, done := p.withTimeout(ctx)
+	defer done(&err)
+	return UpdatePassword(ctx, p.Passwords, uid, hashedPassword, mustChange)
+}
+
+// RedisResetTokens: Implementasi ResetTokenStore; token di Redis, penerima email dari Postgres
+type RedisResetTokens struct {
+	QueryTimeout
+}
+
+func (r RedisResetTokens) GetResetRecipient(ctx context.Context, username string) (_ *ResetRecipient, err error) {
+	ctx, done := r.withTimeout(ctx)
+	defer done(&err)
+	return GetResetRecipient(ctx, username)
+}
+
+func (RedisResetTokens) StorePasswordResetToken(ctx context.Context, token, uid string, ttl time.Duration) error {
+	return StorePasswordResetToken(ctx, token, uid, ttl)
+}
+
+func (RedisResetTokens) PeekPasswordResetToken(ctx context.Context, token string) (string, error) {
+	return PeekPasswordResetToken(ctx, token)
+}
+
+func (RedisResetTokens) ConsumePasswordResetToken(ctx context.Context, token string) (string, error) {
+	return ConsumePasswordResetToken(ctx, token)
+}
+
+// PostgresOIDC: Implementasi OIDCStore; identitas di Postgres, state alur login di Redis
+type PostgresOIDC struct {
+	QueryTimeout
+}
+
+func (PostgresOIDC) SaveOIDCState(ctx context.Context, state string, data OIDCState, ttl time.Duration) error {
+	return SaveOIDCState(ctx, state, data, ttl)
+}
+
+func (PostgresOIDC) ConsumeOIDCState(ctx context.Context, state string) (*OIDCState, error) {
+	return ConsumeOIDCState(ctx, state)
+}
+
+func (o PostgresOIDC) GetLinkedUID(ctx context.Context, provider, subject string) (_ string, err error) {
+	ctx, done := o.withTimeout(ctx)
+	defer done(&err)
+	return GetLinkedUID(ctx, provider, subject)
+}
+
+func (o PostgresOIDC) FindUIDByEmail(ctx context.Context, email string) (_ string, err error) {
+	ctx, done := o.withTimeout(ctx)
+	defer done(&err)
+	return FindUIDByEmail(ctx, email)
+}
+
+func (o PostgresOIDC) LinkIdentity(ctx context.Context, uid, provider, subject, email string) (err error) {
+	ctx, done := o.withTimeout(ctx)
+	defer done(&err)
+	return LinkIdentity(ctx, uid, provider, subject, email)
+}
+
+func (o PostgresOIDC) TouchIdentity(ctx context.Context, provider, subject string) {
+	ctx, done := o.withTimeout(ctx)
+	defer done(nil)
+	TouchIdentity(ctx, provider, subject)
+}
+
+func (o PostgresOIDC) ListIdentities(ctx context.Context, uid string) (_ []UserIdentityLink, err error) {
+	ctx, done := o.withTimeout(ctx)
+	defer done(&err)
+	return ListIdentities(ctx, uid)
+}
+
+func (o PostgresOIDC) UnlinkIdentity(ctx context.Context, uid, provider string) (err error) {
+	ctx, done := o.withTimeout(ctx)
+	defer done(&err)
+	return UnlinkIdentity(ctx, uid, provider)
+}
+
+// PostgresServiceAccounts: Implementasi ServiceAccountStore berbasis Postgres
+type PostgresServiceAccounts struct {
+	QueryTimeout
+}
+
+func (a PostgresServiceAccounts) CreateServiceAccount(ctx context.Context, sa *ServiceAccount) (err error) {
+	ctx, done := a.withTimeout(ctx)
+	defer done(&err)
+	return CreateServiceAccount(ctx, sa)
+}
+
+func (a PostgresServiceAccounts) ListServiceAccounts(ctx context.Context) (_ []ServiceAccount, err error) {
+	ctx, done := a.withTimeout(ctx)
+	defer done(&err)
+	return ListServiceAccounts(ctx)
+}
+
+func (a PostgresServiceAccounts) GetServiceAccount(ctx context.Context, id string) (_ *ServiceAccount, err error) {
+	ctx, done := a.withTimeout(ctx)
+	defer done(&err)
+	return GetServiceAccount(ctx, id)
+}
+
+func (a PostgresServiceAccounts) UpdateServiceAccount(ctx context.Context, id, name, description string, scopes []string, disabled bool) (err error) {
+	ctx, done := a.withTimeout(ctx)
+	defer done(&err)
+	return UpdateServiceAccount(ctx, id, name, description, scopes, disabled)
+}
+
+func (a PostgresServiceAccounts) DeleteServiceAccount(ctx context.Context, id string) (err error) {
+	ctx, done := a.withTimeout(ctx)
+	defer done(&err)
+	return DeleteServiceAccount(ctx, id)
+}
+
+func (a PostgresServiceAccounts) CreateAPIKey(ctx context.Context, serviceAccountID, key, prefix string, expiresAt *time.Time) (_ *APIKey, err error) {
+	ctx, done := a.withTimeout(ctx)
+	defer done(&err)
+	return CreateAPIKey(ctx, serviceAccountID, key, prefix, expiresAt)
+}
+
+func (a PostgresServiceAccounts) RevokeAPIKey(ctx context.Context, serviceAccountID, keyID string) (err error) {
+	ctx, done := a.withTimeout(ctx)
+	defer done(&err)
+	return RevokeAPIKey(ctx, serviceAccountID, keyID)
+}
+
+// RedisAuth: Implementasi AuthStore; blacklist, token version dan penanda sesi dicabut di Redis
+// (token version jatuh ke Postgres saat cache kosong), API key diverifikasi di Postgres
+type RedisAuth struct {
+	QueryTimeout
+	Blacklist configs.TokenBlacklistConfig
+}
+
+func (a RedisAuth) IsTokenBlacklisted(ctx context.Context, token string) bool {
+	return IsTokenBlacklisted(ctx, a.Blacklist, token)
+}
+
+func (a RedisAuth) GetTokenVersion(ctx context.Context, uid string) (_ int, err error) {
+	ctx, done := a.withTimeout(ctx)
+	defer done(&err)
+	return GetTokenVersion(ctx, uid)
+}
+
+func (RedisAuth) IsSessionRevoked(ctx context.Context, sessionID string) bool {
+	return IsSessionRevoked(ctx, sessionID)
+}
+
+func (a RedisAuth) AuthenticateAPIKey(ctx context.Context, key, ip string) (_ *APIKeyPrincipal, err error) {
+	ctx, done := a.withTimeout(ctx)
+	defer done(&err)
+	return AuthenticateAPIKey(ctx, key, ip)
+}
+
+// PostgresAudit: Implementasi AuditStore berbasis Postgres
+type PostgresAudit struct {
+	QueryTimeout
+}
+
+func (a PostgresAudit) InsertAuditLog(ctx context.Context, entry *AuditLog) (err error) {
+	ctx, done := a.withTimeout(ctx)
+	defer done(&err)
+	return InsertAuditLog(ctx, entry)
+}
+
+func (a PostgresAudit) ListAuditLogs(ctx context.Context, f AuditFilter) (_ []AuditLog, _ int, err error) {
+	ctx, done := a.withTimeout(ctx)
+	defer done(&err)
+	return ListAuditLogs(ctx, f)
+}
+
+func (a PostgresAudit) RecordAuthEvent(ctx context.Context, ev AuthEvent) {
+	RecordAuthEvent(ctx, a.Timeout, ev)
+}
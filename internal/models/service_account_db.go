@@ -0,0 +1,273 @@
+// models/service_account_db.go
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-sis-be/internal/configs"
+
+	"github.com/lib/pq"
+)
+
+// SERVICE_ROLE_NAME: Role pada principal hasil autentikasi API key (bukan role di tabel roles)
+const SERVICE_ROLE_NAME = "service"
+
+// ServiceAccount: Akun mesin (kiosk absensi, sistem keuangan) yang mengakses API dengan API key
+type ServiceAccount struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Scopes      []string   `json:"scopes"`
+	CreatedBy   string     `json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DisabledAt  *time.Time `json:"disabled_at,omitempty"`
+	Keys        []APIKey   `json:"keys,omitempty"`
+}
+
+// APIKey: Metadata API key milik service account. Nilai key tidak pernah disimpan, hanya hash SHA-256.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Prefix     string     `json:"prefix"` // Potongan awal key agar admin bisa mengenali key tanpa melihat nilainya
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP string     `json:"last_used_ip,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// APIKeyPrincipal: Hasil autentikasi API key yang valid
+type APIKeyPrincipal struct {
+	KeyID            string
+	ServiceAccountID string
+	Name             string
+	Scopes           []string
+}
+
+// HashAPIKey mengembalikan hash SHA-256 (hex) dari nilai API key.
+// API key sudah acak 256-bit sehingga hash cepat cukup, tidak perlu bcrypt.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateServiceAccount menyimpan service account baru dan mengisi ID serta CreatedAt
+func CreateServiceAccount(ctx context.Context, sa *ServiceAccount) error {
+	query := `
+		INSERT INTO service_accounts (name, description, scopes, created_by, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id, created_at`
+
+	err := configs.DB.QueryRowContext(ctx, query, sa.Name, sa.Description, pq.Array(sa.Scopes), nullString(sa.CreatedBy)).
+		Scan(&sa.ID, &sa.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("gagal membuat service account: %w", err)
+	}
+	return nil
+}
+
+// ListServiceAccounts mengambil semua service account beserta key-nya, terbaru di atas
+func ListServiceAccounts(ctx context.Context) ([]ServiceAccount, error) {
+	query := `
+		SELECT id, name, description, scopes, COALESCE(created_by::text, ''), created_at, disabled_at
+		FROM service_accounts
+		ORDER BY created_at DESC`
+
+	rows, err := configs.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil service account: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := []ServiceAccount{}
+	for rows.Next() {
+		sa, err := scanServiceAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, *sa)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range accounts {
+		if accounts[i].Keys, err = listAPIKeys(ctx, accounts[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return accounts, nil
+}
+
+// GetServiceAccount mengambil satu service account beserta key-nya.
+// Mengembalikan sql.ErrNoRows jika tidak ada.
+func GetServiceAccount(ctx context.Context, id string) (*ServiceAccount, error) {
+	query := `
+		SELECT id, name, description, scopes, COALESCE(created_by::text, ''), created_at, disabled_at
+		FROM service_accounts
+		WHERE id = $1`
+
+	sa, err := scanServiceAccount(configs.DB.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, err
+	}
+	if sa.Keys, err = listAPIKeys(ctx, id); err != nil {
+		return nil, err
+	}
+	return sa, nil
+}
+
+// UpdateServiceAccount memperbarui nama, deskripsi, scope, dan status nonaktif.
+// Mengembalikan sql.ErrNoRows jika service account tidak ada.
+func UpdateServiceAccount(ctx context.Context, id, name, description string, scopes []string, disabled bool) error {
+	query := `
+		UPDATE service_accounts
+		SET name = $2, description = $3, scopes = $4,
+			disabled_at = CASE WHEN $5 THEN COALESCE(disabled_at, NOW()) ELSE NULL END
+		WHERE id = $1`
+
+	res, err := configs.DB.ExecContext(ctx, query, id, name, description, pq.Array(scopes), disabled)
+	if err != nil {
+		return fmt.Errorf("gagal memperbarui service account: %w", err)
+	}
+	return requireAffected(res)
+}
+
+// DeleteServiceAccount menghapus service account; key-nya ikut terhapus (ON DELETE CASCADE).
+// Mengembalikan sql.ErrNoRows jika service account tidak ada.
+func DeleteServiceAccount(ctx context.Context, id string) error {
+	res, err := configs.DB.ExecContext(ctx, `DELETE FROM service_accounts WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("gagal menghapus service account: %w", err)
+	}
+	return requireAffected(res)
+}
+
+// CreateAPIKey menyimpan hash key baru untuk service account. expiresAt nil berarti tidak kedaluwarsa.
+func CreateAPIKey(ctx context.Context, serviceAccountID, key, prefix string, expiresAt *time.Time) (*APIKey, error) {
+	k := APIKey{Prefix: prefix, ExpiresAt: expiresAt}
+	query := `
+		INSERT INTO api_keys (service_account_id, key_hash, prefix, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id, created_at`
+
+	err := configs.DB.QueryRowContext(ctx, query, serviceAccountID, HashAPIKey(key), prefix, expiresAt).Scan(&k.ID, &k.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat API key: %w", err)
+	}
+	return &k, nil
+}
+
+// RevokeAPIKey mencabut satu key milik service account.
+// Mengembalikan sql.ErrNoRows jika key tidak ada atau sudah dicabut.
+func RevokeAPIKey(ctx context.Context, serviceAccountID, keyID string) error {
+	query := `
+		UPDATE api_keys SET revoked_at = NOW()
+		WHERE id = $1 AND service_account_id = $2 AND revoked_at IS NULL`
+
+	res, err := configs.DB.ExecContext(ctx, query, keyID, serviceAccountID)
+	if err != nil {
+		return fmt.Errorf("gagal mencabut API key: %w", err)
+	}
+	return requireAffected(res)
+}
+
+// AuthenticateAPIKey mencari key aktif (belum dicabut, belum kedaluwarsa, akun tidak nonaktif)
+// dan sekaligus mencatat waktu serta IP pemakaian terakhir. Mengembalikan nil jika key tidak valid.
+func AuthenticateAPIKey(ctx context.Context, key, ip string) (*APIKeyPrincipal, error) {
+	var p APIKeyPrincipal
+	query := `
+		UPDATE api_keys k
+		SET last_used_at = NOW(), last_used_ip = $2
+		FROM service_accounts sa
+		WHERE k.key_hash = $1
+			AND sa.id = k.service_account_id
+			AND k.revoked_at IS NULL
+			AND (k.expires_at IS NULL OR k.expires_at > NOW())
+			AND sa.disabled_at IS NULL
+		RETURNING k.id, sa.id, sa.name, sa.scopes`
+
+	err := configs.DB.QueryRowContext(ctx, query, HashAPIKey(key), ip).
+		Scan(&p.KeyID, &p.ServiceAccountID, &p.Name, pq.Array(&p.Scopes))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal memverifikasi API key: %w", err)
+	}
+	return &p, nil
+}
+
+func listAPIKeys(ctx context.Context, serviceAccountID string) ([]APIKey, error) {
+	query := `
+		SELECT id, prefix, expires_at, last_used_at, COALESCE(last_used_ip, ''), revoked_at, created_at
+		FROM api_keys
+		WHERE service_account_id = $1
+		ORDER BY created_at DESC`
+
+	rows, err := configs.DB.QueryContext(ctx, query, serviceAccountID)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil API key: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		var k APIKey
+		var expiresAt, lastUsedAt, revokedAt sql.NullTime
+		if err := rows.Scan(&k.ID, &k.Prefix, &expiresAt, &lastUsedAt, &k.LastUsedIP, &revokedAt, &k.CreatedAt); err != nil {
+			return nil, fmt.Errorf("gagal scan API key: %w", err)
+		}
+		k.ExpiresAt = nullTimePtr(expiresAt)
+		k.LastUsedAt = nullTimePtr(lastUsedAt)
+		k.RevokedAt = nullTimePtr(revokedAt)
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// rowScanner: *sql.Row maupun *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanServiceAccount(row rowScanner) (*ServiceAccount, error) {
+	var sa ServiceAccount
+	var disabledAt sql.NullTime
+	err := row.Scan(&sa.ID, &sa.Name, &sa.Description, pq.Array(&sa.Scopes), &sa.CreatedBy, &sa.CreatedAt, &disabledAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal scan service account: %w", err)
+	}
+	if sa.Scopes == nil {
+		sa.Scopes = []string{}
+	}
+	sa.DisabledAt = nullTimePtr(disabledAt)
+	return &sa, nil
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// requireAffected mengubah hasil UPDATE/DELETE tanpa baris terdampak menjadi sql.ErrNoRows
+func requireAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
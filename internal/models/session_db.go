@@ -0,0 +1,199 @@
+// models/session_db.go
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go-sis-be/internal/configs"
+	"go-sis-be/internal/utils"
+)
+
+// Session: Satu sesi login per perangkat (tabel sessions).
+// ID sesi sekaligus menjadi token family untuk refresh_tokens.
+type Session struct {
+	ID         string     `json:"id"`
+	UID        string     `json:"-"`
+	Device     string     `json:"device"`
+	IPAddress  string     `json:"ip_address"`
+	UserAgent  string     `json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Current    bool       `json:"current"`
+}
+
+// CreateSession membuat sesi baru untuk user dan mengembalikan ID-nya
+func CreateSession(ctx context.Context, uid, device, ip, userAgent string) (string, error) {
+	var id string
+	query := `
+		INSERT INTO sessions (uid, device, ip_address, user_agent, created_at, last_used_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		RETURNING id`
+
+	err := configs.DB.QueryRowContext(ctx, query, uid, device, ip, userAgent).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("gagal membuat sesi: %w", err)
+	}
+	return id, nil
+}
+
+// GetSession mengambil sesi berdasarkan ID. Mengembalikan nil jika tidak ada.
+func GetSession(ctx context.Context, id string) (*Session, error) {
+	var s Session
+	var revokedAt sql.NullTime
+
+	query := `
+		SELECT id, uid, device, ip_address, user_agent, created_at, last_used_at, revoked_at
+		FROM sessions
+		WHERE id = $1`
+
+	err := configs.DB.QueryRowContext(ctx, query, id).Scan(
+		&s.ID, &s.UID, &s.Device, &s.IPAddress, &s.UserAgent,
+		&s.CreatedAt, &s.LastUsedAt, &revokedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil sesi: %w", err)
+	}
+
+	if revokedAt.Valid {
+		s.RevokedAt = &revokedAt.Time
+	}
+	return &s, nil
+}
+
+// TouchSession memperbarui waktu terakhir sesi dipakai beserta IP terakhirnya
+func TouchSession(ctx context.Context, id, ip string) error {
+	query := `UPDATE sessions SET last_used_at = NOW(), ip_address = $2 WHERE id = $1`
+	if _, err := configs.DB.ExecContext(ctx, query, id, ip); err != nil {
+		return fmt.Errorf("gagal memperbarui sesi: %w", err)
+	}
+	return nil
+}
+
+// ListActiveSessions mengambil semua sesi aktif milik user, terbaru di atas
+func ListActiveSessions(ctx context.Context, uid string) ([]Session, error) {
+	query := `
+		SELECT id, uid, device, ip_address, user_agent, created_at, last_used_at
+		FROM sessions
+		WHERE uid = $1 AND revoked_at IS NULL
+		ORDER BY last_used_at DESC`
+
+	rows, err := configs.DB.QueryContext(ctx, query, uid)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil daftar sesi: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		var s Session
+		if err := rows.Scan(&s.ID, &s.UID, &s.Device, &s.IPAddress, &s.UserAgent, &s.CreatedAt, &s.LastUsedAt); err != nil {
+			return nil, fmt.Errorf("gagal scan sesi: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RevokeSession mencabut satu sesi milik uid beserta seluruh refresh token di dalamnya.
+// Mengembalikan sql.ErrNoRows jika sesi tidak ada / bukan milik uid / sudah dicabut.
+func RevokeSession(ctx context.Context, uid, sessionID string) error {
+	tx, err := configs.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND uid = $2 AND revoked_at IS NULL`, sessionID, uid)
+	if err != nil {
+		return fmt.Errorf("gagal mencabut sesi: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL`, sessionID)
+	if err != nil {
+		return fmt.Errorf("gagal mencabut refresh token sesi: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	// Sesi sudah dicabut di database; penanda Redis tetap dipasang walau request dibatalkan
+	markSessionRevoked(context.WithoutCancel(ctx), sessionID)
+	return nil
+}
+
+// RevokeAllSessions mencabut semua sesi aktif milik user ("sign out everywhere").
+// Mengembalikan jumlah sesi yang dicabut.
+func RevokeAllSessions(ctx context.Context, uid string) (int64, error) {
+	tx, err := configs.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `UPDATE sessions SET revoked_at = NOW() WHERE uid = $1 AND revoked_at IS NULL RETURNING id`, uid)
+	if err != nil {
+		return 0, fmt.Errorf("gagal mencabut semua sesi: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("gagal scan sesi: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = NOW() WHERE uid = $1 AND revoked_at IS NULL`, uid)
+	if err != nil {
+		return 0, fmt.Errorf("gagal mencabut refresh token user: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	detached := context.WithoutCancel(ctx)
+	for _, id := range ids {
+		markSessionRevoked(detached, id)
+	}
+	return int64(len(ids)), nil
+}
+
+// markSessionRevoked menandai sesi sebagai dicabut di Redis selama umur access token,
+// supaya access token yang masih beredar dari sesi itu langsung ditolak AuthMiddleware.
+func markSessionRevoked(ctx context.Context, sessionID string) {
+	err := configs.RedisClient.Set(ctx, "session_revoked:"+sessionID, "true", utils.AccessTokenTTL).Err()
+	if err != nil {
+		log.Printf("Redis error menandai sesi %s dicabut: %v", sessionID, err)
+	}
+}
+
+// IsSessionRevoked mengecek apakah access token dari sesi sessionID sudah tidak boleh dipakai
+func IsSessionRevoked(ctx context.Context, sessionID string) bool {
+	val, err := configs.RedisClient.Exists(ctx, "session_revoked:"+sessionID).Result()
+	if err != nil {
+		log.Printf("Redis error checking session revoke: %v", err)
+		return false
+	}
+	return val > 0
+}
@@ -0,0 +1,127 @@
+// models/token_blacklist_db.go
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"go-sis-be/internal/configs"
+)
+
+// Access token yang sudah logout disimpan di Redis sebagai hash, bukan JWT mentah:
+//
+//	blacklist:<sha256(token)> -> ada sampai token kadaluarsa
+const tokenBlacklistPrefix = "blacklist:"
+
+// maxBlacklistFallback: Batas jumlah token di cache cadangan dalam memori
+const maxBlacklistFallback = 10000
+
+// blacklistFallback: Token yang baru di-blacklist oleh proses ini, tetap ditolak walau Redis mati.
+// Hanya mencakup logout yang terjadi di instance ini.
+var blacklistFallback = struct {
+	sync.Mutex
+	entries map[string]time.Time // hash token -> kadaluarsa
+}{entries: map[string]time.Time{}}
+
+// TokenBlacklistHealth: Status pencabutan token untuk endpoint health
+type TokenBlacklistHealth struct {
+	Mode            string `json:"mode"`     // fail_open / fail_closed
+	Degraded        bool   `json:"degraded"` // Redis tidak sehat: hanya cache cadangan yang dipakai
+	FallbackEntries int    `json:"fallback_entries"`
+}
+
+func hashBlacklistToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// BlacklistToken menyimpan token di Redis dan di cache cadangan (selama cfg.FallbackTTL)
+func BlacklistToken(ctx context.Context, cfg configs.TokenBlacklistConfig, token string, expiry time.Duration) error {
+	hash := hashBlacklistToken(token)
+	rememberBlacklisted(hash, expiry, cfg.FallbackTTL)
+
+	// Simpan ke Redis dengan TTL sisa umur token
+	return configs.RedisClient.Set(ctx, tokenBlacklistPrefix+hash, "true", expiry).Err()
+}
+
+// IsTokenBlacklisted mengecek cache cadangan lalu Redis. Jika Redis error, hasilnya
+// mengikuti cfg.FailClosed (TOKEN_BLACKLIST_FAIL_MODE): open = token diterima, closed = token ditolak.
+func IsTokenBlacklisted(ctx context.Context, cfg configs.TokenBlacklistConfig, token string) bool {
+	hash := hashBlacklistToken(token)
+	if recentlyBlacklisted(hash) {
+		return true
+	}
+
+	val, err := configs.RedisClient.Exists(ctx, tokenBlacklistPrefix+hash).Result()
+	if err != nil {
+		// Saat breaker terbuka kegagalan sudah di-log oleh breaker, tidak perlu per request
+		if !errors.Is(err, configs.ErrRedisUnavailable) {
+			log.Printf("Redis error checking blacklist (fail_closed=%t): %v", cfg.FailClosed, err)
+		}
+		return cfg.FailClosed
+	}
+	return val > 0
+}
+
+// TokenBlacklistStatus mengembalikan mode (dari cfg) dan kondisi pencabutan token saat ini
+func TokenBlacklistStatus(cfg configs.TokenBlacklistConfig) TokenBlacklistHealth {
+	blacklistFallback.Lock()
+	entries := len(blacklistFallback.entries)
+	blacklistFallback.Unlock()
+
+	mode := "fail_open"
+	if cfg.FailClosed {
+		mode = "fail_closed"
+	}
+	return TokenBlacklistHealth{
+		Mode:            mode,
+		Degraded:        !configs.RedisBreaker.Health().Healthy,
+		FallbackEntries: entries,
+	}
+}
+
+func rememberBlacklisted(hash string, expiry, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	if expiry < ttl {
+		ttl = expiry
+	}
+
+	now := time.Now()
+	blacklistFallback.Lock()
+	defer blacklistFallback.Unlock()
+
+	if len(blacklistFallback.entries) >= maxBlacklistFallback {
+		for h, exp := range blacklistFallback.entries {
+			if now.After(exp) {
+				delete(blacklistFallback.entries, h)
+			}
+		}
+	}
+	// Masih penuh: buang entri sembarang, Redis tetap sumber utama
+	for h := range blacklistFallback.entries {
+		if len(blacklistFallback.entries) < maxBlacklistFallback {
+			break
+		}
+		delete(blacklistFallback.entries, h)
+	}
+	blacklistFallback.entries[hash] = now.Add(ttl)
+}
+
+func recentlyBlacklisted(hash string) bool {
+	blacklistFallback.Lock()
+	defer blacklistFallback.Unlock()
+
+	exp, ok := blacklistFallback.entries[hash]
+	if ok && time.Now().After(exp) {
+		delete(blacklistFallback.entries, hash)
+		return false
+	}
+	return ok
+}
@@ -0,0 +1,105 @@
+// models/token_version_db.go
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"go-sis-be/internal/configs"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Versi token per user (login_users.token_version) di-cache di Redis agar AuthMiddleware
+// tidak perlu query ke Postgres di setiap request.
+//
+// TTL sengaja pendek: token_version juga bisa naik tanpa lewat kode ini (role diubah langsung
+// di database, trigger tetap menaikkan versi), dan perubahan seperti itu baru terlihat setelah
+// cache kadaluarsa. Ganti role lewat API (ChangeUserRole) menimpa cache sehingga berlaku seketika.
+//
+// Hanya penulis (bump, ganti password, ganti role, hapus user) yang boleh menimpa cache. Pembaca yang
+// mengisi cache setelah query DB memakai SETNX, supaya nilai lama yang dibaca sebelum bump
+// tidak menimpa versi baru yang sudah ditulis penulis.
+const (
+	tokenVersionPrefix   = "token_version:"
+	tokenVersionCacheTTL = time.Minute
+
+	// tokenVersionGone: Nilai cache untuk user yang sudah dihapus
+	tokenVersionGone = -1
+)
+
+// ErrUserGone: User pemilik token sudah dihapus
+var ErrUserGone = errors.New("user tidak ditemukan")
+
+// GetTokenVersion mengambil token_version user, dari cache Redis jika ada.
+// Mengembalikan ErrUserGone jika user sudah tidak ada.
+func GetTokenVersion(ctx context.Context, uid string) (int, error) {
+	cached, err := configs.RedisClient.Get(ctx, tokenVersionPrefix+uid).Result()
+	if err == nil {
+		if v, convErr := strconv.Atoi(cached); convErr == nil {
+			if v == tokenVersionGone {
+				return 0, ErrUserGone
+			}
+			return v, nil
+		}
+	} else if !errors.Is(err, redis.Nil) {
+		log.Printf("Redis error ambil token version: %v", err)
+	}
+
+	var version int
+	err = configs.DB.QueryRowContext(ctx, `SELECT token_version FROM login_users WHERE uid = $1`, uid).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrUserGone
+	}
+	if err != nil {
+		return 0, fmt.Errorf("gagal mengambil token version: %w", err)
+	}
+
+	// SETNX: jika penulis sudah menyimpan versi yang lebih baru, nilai hasil baca ini dibuang
+	if err := configs.RedisClient.SetNX(ctx, tokenVersionPrefix+uid, version, tokenVersionCacheTTL).Err(); err != nil {
+		log.Printf("Redis error simpan token version: %v", err)
+	}
+	return version, nil
+}
+
+// BumpTokenVersion menaikkan token_version user sehingga semua access token yang
+// sudah terbit untuk user tersebut langsung ditolak AuthMiddleware.
+func BumpTokenVersion(ctx context.Context, uid string) (int, error) {
+	var version int
+	query := `
+		UPDATE login_users
+		SET token_version = token_version + 1, updated_at = NOW()
+		WHERE uid = $1
+		RETURNING token_version`
+
+	err := configs.DB.QueryRowContext(ctx, query, uid).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, sql.ErrNoRows
+	}
+	if err != nil {
+		return 0, fmt.Errorf("gagal menaikkan token version: %w", err)
+	}
+
+	// Versi baru sudah tersimpan; cache tetap diperbarui walau request dibatalkan
+	cacheTokenVersion(context.WithoutCancel(ctx), uid, version)
+	return version, nil
+}
+
+// markTokenVersionGone menandai user sudah dihapus di cache. Cache tidak dihapus (DEL)
+// karena pembaca yang masih memegang versi lama dari DB bisa mengisinya kembali lewat SETNX.
+func markTokenVersionGone(ctx context.Context, uid string) {
+	cacheTokenVersion(ctx, uid, tokenVersionGone)
+}
+
+// cacheTokenVersion menimpa cache dengan versi terbaru; hanya dipanggil penulis setelah commit
+func cacheTokenVersion(ctx context.Context, uid string, version int) {
+	err := configs.RedisClient.Set(ctx, tokenVersionPrefix+uid, version, tokenVersionCacheTTL).Err()
+	if err != nil {
+		log.Printf("Redis error simpan token version: %v", err)
+	}
+}
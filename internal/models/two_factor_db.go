@@ -0,0 +1,173 @@
+// models/two_factor_db.go
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go-sis-be/internal/configs"
+)
+
+// ErrTwoFactorAlreadyEnabled: Setup ulang ditolak karena 2FA user sudah aktif
+var ErrTwoFactorAlreadyEnabled = errors.New("2FA sudah aktif")
+
+// TwoFactor: Data TOTP milik user (tabel user_two_factor).
+// EnabledAt nil berarti enrollment belum diverifikasi.
+type TwoFactor struct {
+	UID       string
+	Secret    string
+	EnabledAt *time.Time
+	CreatedAt time.Time
+}
+
+// Enabled mengecek apakah 2FA sudah aktif
+func (t *TwoFactor) Enabled() bool {
+	return t != nil && t.EnabledAt != nil
+}
+
+// GetTwoFactor mengambil data 2FA user. Mengembalikan nil jika user belum pernah setup.
+func GetTwoFactor(ctx context.Context, uid string) (*TwoFactor, error) {
+	var tf TwoFactor
+	var enabledAt sql.NullTime
+
+	query := `SELECT uid, secret, enabled_at, created_at FROM user_two_factor WHERE uid = $1`
+	err := configs.DB.QueryRowContext(ctx, query, uid).Scan(&tf.UID, &tf.Secret, &enabledAt, &tf.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil data 2FA: %w", err)
+	}
+
+	if enabledAt.Valid {
+		tf.EnabledAt = &enabledAt.Time
+	}
+	return &tf, nil
+}
+
+// IsTwoFactorEnabled mengecek apakah user sudah mengaktifkan 2FA
+func IsTwoFactorEnabled(ctx context.Context, uid string) (bool, error) {
+	tf, err := GetTwoFactor(ctx, uid)
+	if err != nil {
+		return false, err
+	}
+	return tf.Enabled(), nil
+}
+
+// SavePendingTwoFactor menyimpan secret baru yang belum diverifikasi (menimpa setup sebelumnya
+// yang belum aktif). Tidak menimpa 2FA yang sudah aktif.
+func SavePendingTwoFactor(ctx context.Context, uid, secret string) error {
+	query := `
+		INSERT INTO user_two_factor (uid, secret, enabled_at, created_at)
+		VALUES ($1, $2, NULL, NOW())
+		ON CONFLICT (uid) DO UPDATE
+		SET secret = EXCLUDED.secret, created_at = NOW()
+		WHERE user_two_factor.enabled_at IS NULL`
+
+	res, err := configs.DB.ExecContext(ctx, query, uid, secret)
+	if err != nil {
+		return fmt.Errorf("gagal menyimpan secret 2FA: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrTwoFactorAlreadyEnabled
+	}
+	return nil
+}
+
+// EnableTwoFactor mengaktifkan 2FA dan mengganti seluruh recovery code user dalam satu transaksi
+func EnableTwoFactor(ctx context.Context, uid string, recoveryCodes []string) error {
+	tx, err := configs.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `UPDATE user_two_factor SET enabled_at = NOW() WHERE uid = $1 AND enabled_at IS NULL`, uid)
+	if err != nil {
+		return fmt.Errorf("gagal mengaktifkan 2FA: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM two_factor_recovery_codes WHERE uid = $1`, uid); err != nil {
+		return fmt.Errorf("gagal menghapus recovery code lama: %w", err)
+	}
+	for _, code := range recoveryCodes {
+		_, err := tx.ExecContext(ctx, `INSERT INTO two_factor_recovery_codes (uid, code_hash) VALUES ($1, $2)`, uid, hashRecoveryCode(code))
+		if err != nil {
+			return fmt.Errorf("gagal menyimpan recovery code: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UseRecoveryCode menandai recovery code sebagai terpakai. Mengembalikan false jika kode
+// tidak cocok atau sudah pernah dipakai.
+func UseRecoveryCode(ctx context.Context, uid, code string) (bool, error) {
+	query := `
+		UPDATE two_factor_recovery_codes
+		SET used_at = NOW()
+		WHERE uid = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	res, err := configs.DB.ExecContext(ctx, query, uid, hashRecoveryCode(code))
+	if err != nil {
+		return false, fmt.Errorf("gagal memakai recovery code: %w", err)
+	}
+	rows, _ := res.RowsAffected()
+	return rows > 0, nil
+}
+
+// CountRemainingRecoveryCodes menghitung recovery code yang belum dipakai
+func CountRemainingRecoveryCodes(ctx context.Context, uid string) (int, error) {
+	var count int
+	err := configs.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM two_factor_recovery_codes WHERE uid = $1 AND used_at IS NULL`, uid).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("gagal menghitung recovery code: %w", err)
+	}
+	return count, nil
+}
+
+// MarkTOTPStepUsed mencatat bahwa kode TOTP pada langkah step sudah dipakai uid.
+// Mengembalikan false jika kode yang sama sudah pernah dipakai (replay).
+func MarkTOTPStepUsed(ctx context.Context, uid string, step int64) (bool, error) {
+	key := "totp_used:" + uid + ":" + strconv.FormatInt(step, 10)
+	return configs.RedisClient.SetNX(ctx, key, "1", 3*time.Minute).Result()
+}
+
+// RegisterTwoFactorFailure menambah penghitung kode salah untuk satu challenge token
+func RegisterTwoFactorFailure(ctx context.Context, challengeID string, ttl time.Duration) (int64, error) {
+	key := "2fa_fail:" + challengeID
+	pipe := configs.RedisClient.TxPipeline()
+	count := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return count.Val(), nil
+}
+
+// IsTwoFactorChallengeUsed mengecek apakah challenge sudah ditukar atau sudah dihanguskan
+func IsTwoFactorChallengeUsed(ctx context.Context, challengeID string) (bool, error) {
+	n, err := configs.RedisClient.Exists(ctx, "2fa_used:"+challengeID).Result()
+	return n > 0, err
+}
+
+// ConsumeTwoFactorChallenge menandai challenge token sudah dipakai. Mengembalikan false
+// jika challenge sudah pernah ditukar dengan token (sekali pakai).
+func ConsumeTwoFactorChallenge(ctx context.Context, challengeID string, ttl time.Duration) (bool, error) {
+	return configs.RedisClient.SetNX(ctx, "2fa_used:"+challengeID, "1", ttl).Result()
+}
+
+// hashRecoveryCode: Recovery code acak berentropi tinggi, cukup disimpan sebagai SHA-256
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -68,14 +68,16 @@ const (
 )
 
 type User struct {
-	UID          string    `json:"uid"`
-	Username     string    `json:"username"`
-	Pass         string    `json:"-"`
-	RoleID       int       `json:"role_id"`
-	RoleName     string    `json:"role_name,omitempty"`
-	RefreshToken string    `json:"-"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	UID       string    `json:"uid"`
+	Username  string    `json:"username"`
+	Pass      string    `json:"-"`
+	RoleID    int       `json:"role_id"`
+	RoleName  string    `json:"role_name,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	MustChangePassword bool `json:"must_change_password"` // Wajib ganti password sebelum bisa memakai API
+	TokenVersion       int  `json:"-"`                    // Naik setiap semua token user harus dicabut
 }
 
 type CreateUserRequest struct {
@@ -286,9 +288,10 @@ type EditTeacherRequest struct {
 	DiplomaNumber       string `json:"diploma_number,omitempty"`
 }
 
-type UserSession struct {
-	UID          string
-	Username     string
-	Role         string
-	RefreshToken string
+type UserIdentity struct {
+	UID                string
+	Username           string
+	Role               string
+	MustChangePassword bool
+	TokenVersion       int
 }
@@ -2,6 +2,7 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -10,22 +11,21 @@ import (
 	"time"
 
 	"go-sis-be/internal/configs"
-	"go-sis-be/internal/utils"
 )
 
 // --- BAGIAN AUTH (Login, Refresh, Logout) ---
-func GetUserForLogin(username string) (*User, string, error) {
+func GetUserForLogin(ctx context.Context, username string) (*User, string, error) {
 	var user User
 	var roleName string
 
 	query := `
-		SELECT u.uid, u.username, u.pass, u.role_id, r.name 
+		SELECT u.uid, u.username, u.pass, u.role_id, r.name, u.must_change_password, u.token_version
 		FROM login_users u
 		JOIN roles r ON u.role_id = r.id
 		WHERE u.username = $1`
 
-	row := configs.DB.QueryRow(query, username)
-	err := row.Scan(&user.UID, &user.Username, &user.Pass, &user.RoleID, &roleName)
+	row := configs.DB.QueryRowContext(ctx, query, username)
+	err := row.Scan(&user.UID, &user.Username, &user.Pass, &user.RoleID, &roleName, &user.MustChangePassword, &user.TokenVersion)
 
 	if err == sql.ErrNoRows {
 		return nil, "", nil
@@ -37,84 +37,10 @@ func GetUserForLogin(username string) (*User, string, error) {
 	return &user, roleName, nil
 }
 
-func UpdateRefreshToken(uid string, token string) error {
-	query := "UPDATE login_users SET refresh_token = $1, updated_at = NOW() WHERE uid = $2::uuid"
-	_, err := configs.DB.Exec(query, token, uid)
-	if err != nil {
-		log.Printf("Error UpdateRefreshToken: %v", err)
-		return err
-	}
-	return nil
-}
-
-func GetRefreshToken(uid string) (string, error) {
-	var storedToken string
-	// Asumsi tabel: refresh_tokens (uid, token_string, expires_at)
-	query := "SELECT token_string FROM refresh_tokens WHERE uid = $1"
-
-	err := configs.DB.QueryRow(query, uid).Scan(&storedToken)
-	if err == sql.ErrNoRows {
-		// Jika token tidak ditemukan (sudah logout atau tidak pernah login), kembalikan string kosong
-		return "", nil
-	}
-	if err != nil {
-		return "", fmt.Errorf("gagal mengambil refresh token: %w", err)
-	}
-	return storedToken, nil
-}
-
-// DeleteRefreshToken menghapus refresh token yang tersimpan, berfungsi sebagai mekanisme logout/revocation.
-func DeleteRefreshToken(uid string) error {
-	// PASTIKAN:
-	// 1. Nama tabel ('refresh_tokens') sudah benar.
-	// 2. Nama kolom UID ('uid' atau 'user_id' dll) sudah benar.
-	query :=
-		`UPDATE login_users 
-        SET refresh_token = NULL 
-        WHERE uid = $1`
-
-	result, err := configs.DB.Exec(query, uid)
-	if err != nil {
-		// Log error di sini
-		return fmt.Errorf("gagal menghapus refresh token: %w", err)
-	}
-
-	// (Opsional) Cek apakah baris benar-benar terpengaruh
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		// Log ini jika perlu, tapi ini bukan error
-		log.Printf("Warning: No refresh token found for UID %s during delete.", uid)
-	}
-
-	return nil
-}
-
-func BlacklistToken(token string, expiry time.Duration) error {
-	// Simpan ke Redis dengan TTL 15 menit
-	// Format Key: "blacklist:<token>"
-	err := configs.RedisClient.Set(configs.Ctx, "blacklist:"+token, "true", expiry).Err()
-	return err
-}
-
-func IsTokenBlacklisted(token string) bool {
-	val, err := configs.RedisClient.Exists(configs.Ctx, "blacklist:"+token).Result()
-	if err != nil {
-		log.Printf("Redis error checking blacklist: %v", err)
-		return false
-	}
-	return val > 0
-}
-
-func LogoutUser(uid string) error {
-	query := "UPDATE login_users SET refresh_token = NULL WHERE uid = $1"
-	_, err := configs.DB.Exec(query, uid)
-	return err
-}
-
 // --- BAGIAN CRUD USER ---
 
-func CreateUser(req *CreateUserRequest) (*UserResponse, error) {
-	hashedPassword, err := utils.HashPassword(req.Password)
+func CreateUser(ctx context.Context, pc PasswordConfig, req *CreateUserRequest) (*UserResponse, error) {
+	hashedPassword, err := hashNewPassword(pc, req.Password, req.Username)
 	if err != nil {
 		return nil, err
 	}
@@ -127,15 +53,19 @@ func CreateUser(req *CreateUserRequest) (*UserResponse, error) {
 		VALUES ($1, $2, $3) 
 		RETURNING uid, created_at, updated_at`
 
-	err = configs.DB.QueryRow(query, req.Username, hashedPassword, req.RoleID).
+	err = configs.DB.QueryRowContext(ctx, query, req.Username, hashedPassword, req.RoleID).
 		Scan(&uid, &createdAt, &updatedAt)
 
 	if err != nil {
 		return nil, err
 	}
 
+	if err := recordPasswordHistory(ctx, configs.DB, pc.Policy.HistorySize, uid, hashedPassword); err != nil {
+		log.Printf("Error riwayat password %s: %v", uid, err)
+	}
+
 	var roleName string
-	_ = configs.DB.QueryRow("SELECT name FROM roles WHERE id = $1", req.RoleID).Scan(&roleName)
+	_ = configs.DB.QueryRowContext(ctx, "SELECT name FROM roles WHERE id = $1", req.RoleID).Scan(&roleName)
 
 	return &UserResponse{
 		UID:      uid,
@@ -144,7 +74,7 @@ func CreateUser(req *CreateUserRequest) (*UserResponse, error) {
 	}, nil
 }
 
-func GetUserByID(uid string) (*UserResponse, error) {
+func GetUserByID(ctx context.Context, uid string) (*UserResponse, error) {
 	var user UserResponse
 
 	query := `
@@ -153,7 +83,7 @@ func GetUserByID(uid string) (*UserResponse, error) {
         JOIN roles r ON u.role_id = r.id
         WHERE u.uid = $1`
 
-	err := configs.DB.QueryRow(query, uid).
+	err := configs.DB.QueryRowContext(ctx, query, uid).
 		Scan(&user.UID, &user.Username, &user.RoleName)
 
 	if err == sql.ErrNoRows {
@@ -167,8 +97,8 @@ func GetUserByID(uid string) (*UserResponse, error) {
 	return &user, nil
 }
 
-func DeleteUser(uid string) error {
-	res, err := configs.DB.Exec("DELETE FROM login_users WHERE uid = $1", uid)
+func DeleteUser(ctx context.Context, uid string) error {
+	res, err := configs.DB.ExecContext(ctx, "DELETE FROM login_users WHERE uid = $1", uid)
 	if err != nil {
 		return err
 	}
@@ -177,14 +107,35 @@ func DeleteUser(uid string) error {
 	if rows == 0 {
 		return sql.ErrNoRows
 	}
+
+	markTokenVersionGone(context.WithoutCancel(ctx), uid)
 	return nil
 }
 
-func GetRoleIDByUID(uid string) (int, error) {
+// ChangeUserRole mengganti role user. Trigger trg_login_users_role_change menaikkan token_version,
+// lalu cache Redis langsung ditimpa supaya token dengan role lama ditolak saat itu juga.
+// Mengembalikan sql.ErrNoRows jika uid tidak ada.
+func ChangeUserRole(ctx context.Context, uid string, roleID int) error {
+	var version int
+	query := `UPDATE login_users SET role_id = $2 WHERE uid = $1 RETURNING token_version`
+
+	err := configs.DB.QueryRowContext(ctx, query, uid, roleID).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return sql.ErrNoRows
+	}
+	if err != nil {
+		return fmt.Errorf("gagal mengganti role: %w", err)
+	}
+
+	cacheTokenVersion(context.WithoutCancel(ctx), uid, version)
+	return nil
+}
+
+func GetRoleIDByUID(ctx context.Context, uid string) (int, error) {
 	var roleID int
 	query := `SELECT role_id FROM login_users WHERE uid = $1`
 
-	err := configs.DB.QueryRow(query, uid).Scan(&roleID)
+	err := configs.DB.QueryRowContext(ctx, query, uid).Scan(&roleID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return 0, errors.New("UID tidak ditemukan")
@@ -194,7 +145,7 @@ func GetRoleIDByUID(uid string) (int, error) {
 	return roleID, nil
 }
 
-func GetAllUsers(page int, limit int, search string, roleID int) ([]UserResponse, int, error) {
+func GetAllUsers(ctx context.Context, page int, limit int, search string, roleID int) ([]UserResponse, int, error) {
 	// Menghitung OFFSET
 	offset := (page - 1) * limit
 
@@ -232,7 +183,7 @@ func GetAllUsers(page int, limit int, search string, roleID int) ([]UserResponse
         %s`, finalWhere)
 
 	var totalCount int
-	err := configs.DB.QueryRow(countQuery, args...).Scan(&totalCount)
+	err := configs.DB.QueryRowContext(ctx, countQuery, args...).Scan(&totalCount)
 	if err != nil {
 		return nil, 0, fmt.Errorf("gagal menghitung total data: %w", err)
 	}
@@ -256,7 +207,7 @@ func GetAllUsers(page int, limit int, search string, roleID int) ([]UserResponse
 	// Menambahkan parameter LIMIT dan OFFSET
 	args = append(args, limit, offset)
 
-	rows, err := configs.DB.Query(dataQuery, args...)
+	rows, err := configs.DB.QueryContext(ctx, dataQuery, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("gagal mengambil data: %w", err)
 	}
@@ -290,23 +241,23 @@ func GetAllUsers(page int, limit int, search string, roleID int) ([]UserResponse
 	return users, totalCount, nil
 }
 
-func GetUserSessionByUID(uid string) (*UserSession, error) {
-	var sess UserSession
-	var rt sql.NullString
+// GetUserIdentityByUID mengambil username dan nama role user (dipakai saat menerbitkan ulang access token)
+func GetUserIdentityByUID(ctx context.Context, uid string) (*UserIdentity, error) {
+	var ident UserIdentity
 
-	// Kita JOIN dengan tabel roles untuk mendapatkan nama role-nya
 	query := `
-		SELECT u.uid, u.username, r.name as role_name, u.refresh_token 
+		SELECT u.uid, u.username, r.name as role_name, u.must_change_password, u.token_version
 		FROM login_users u
 		JOIN roles r ON u.role_id = r.id
 		WHERE u.uid = $1::uuid
 	`
 
-	err := configs.DB.QueryRow(query, uid).Scan(
-		&sess.UID,
-		&sess.Username,
-		&sess.Role,
-		&rt,
+	err := configs.DB.QueryRowContext(ctx, query, uid).Scan(
+		&ident.UID,
+		&ident.Username,
+		&ident.Role,
+		&ident.MustChangePassword,
+		&ident.TokenVersion,
 	)
 
 	if err != nil {
@@ -316,9 +267,5 @@ func GetUserSessionByUID(uid string) (*UserSession, error) {
 		return nil, err
 	}
 
-	if rt.Valid {
-		sess.RefreshToken = rt.String
-	}
-
-	return &sess, nil
+	return &ident, nil
 }
@@ -0,0 +1,321 @@
+// Package oidc adalah klien OpenID Connect generik (authorization code + PKCE)
+// untuk login lewat identity provider eksternal seperti Google Workspace atau Microsoft 365.
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go-sis-be/internal/configs"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksRefreshInterval: Jarak minimal antar pengambilan ulang JWKS saat kid tidak dikenal
+const jwksRefreshInterval = time.Minute
+
+// ErrUnknownProvider: Nama provider di URL tidak terdaftar
+var ErrUnknownProvider = errors.New("provider OIDC tidak dikenal")
+
+// Identity: Identitas eksternal hasil verifikasi ID token
+type Identity struct {
+	Provider      string
+	Subject       string // Klaim "sub", stabil dan unik per provider
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// idTokenClaims: Klaim ID token yang dipakai
+type idTokenClaims struct {
+	Nonce         string      `json:"nonce"`
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"` // Sebagian IdP mengirim string "true"
+	Name          string      `json:"name"`
+	jwt.RegisteredClaims
+}
+
+// discovery: Bagian dokumen /.well-known/openid-configuration yang dipakai
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider: Satu identity provider. Metadata discovery dan JWKS diambil saat pertama dibutuhkan lalu di-cache.
+type Provider struct {
+	cfg    configs.OIDCProviderConfig
+	client *http.Client
+
+	mu          sync.Mutex
+	meta        *discovery
+	keys        map[string]interface{}
+	keysFetched time.Time
+}
+
+// NewProvider membuat provider dari konfigurasi
+func NewProvider(cfg configs.OIDCProviderConfig) *Provider {
+	return &Provider{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Name: Nama provider sesuai konfigurasi
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// Registry: Kumpulan provider berdasarkan nama
+type Registry map[string]*Provider
+
+// NewRegistry membuat Registry dari daftar konfigurasi provider
+func NewRegistry(cfgs []configs.OIDCProviderConfig) Registry {
+	reg := Registry{}
+	for _, cfg := range cfgs {
+		reg[cfg.Name] = NewProvider(cfg)
+	}
+	return reg
+}
+
+// Get mengambil provider berdasarkan nama
+func (reg Registry) Get(name string) (*Provider, error) {
+	p, ok := reg[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return p, nil
+}
+
+// CodeChallenge menghitung PKCE code_challenge metode S256 dari verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL membangun URL authorize IdP untuk state, nonce, dan PKCE verifier yang diberikan
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.cfg.RedirectURL)
+	q.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", CodeChallenge(verifier))
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange menukar authorization code dengan token, lalu memverifikasi ID token beserta nonce-nya
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("client_id", p.cfg.ClientID)
+	form.Set("client_secret", p.cfg.ClientSecret)
+	form.Set("code_verifier", verifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("gagal menghubungi token endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint mengembalikan status %d: %s", resp.StatusCode, body)
+	}
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return nil, fmt.Errorf("respons token tidak valid: %w", err)
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("respons token tidak berisi id_token")
+	}
+
+	return p.VerifyIDToken(ctx, tokens.IDToken, nonce)
+}
+
+// VerifyIDToken memverifikasi tanda tangan, issuer, audience, masa berlaku, dan nonce ID token
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*Identity, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &idTokenClaims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "ES256", "ES384"}),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(30*time.Second),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ID token tidak valid: %w", err)
+	}
+
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, errors.New("nonce ID token tidak cocok")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("ID token tidak berisi sub")
+	}
+
+	return &Identity{
+		Provider:      p.cfg.Name,
+		Subject:       claims.Subject,
+		Email:         strings.ToLower(strings.TrimSpace(claims.Email)),
+		EmailVerified: p.emailTrusted(claims),
+		Name:          claims.Name,
+	}, nil
+}
+
+// emailTrusted: Email boleh dipakai untuk mencocokkan akun jika diverifikasi IdP,
+// atau berasal dari domain yang diizinkan secara eksplisit di konfigurasi.
+func (p *Provider) emailTrusted(c *idTokenClaims) bool {
+	if c.Email == "" {
+		return false
+	}
+	domain := strings.ToLower(c.Email[strings.LastIndex(c.Email, "@")+1:])
+
+	if len(p.cfg.AllowedDomains) > 0 {
+		for _, d := range p.cfg.AllowedDomains {
+			if d == domain {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch v := c.EmailVerified.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// discover mengambil (dan meng-cache) dokumen discovery provider
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	var meta discovery
+	if err := p.getJSON(ctx, p.cfg.Issuer+"/.well-known/openid-configuration", &meta); err != nil {
+		return nil, fmt.Errorf("gagal discovery OIDC %s: %w", p.cfg.Name, err)
+	}
+	if strings.TrimSuffix(meta.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("issuer discovery %q tidak sama dengan konfigurasi %q", meta.Issuer, p.cfg.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("dokumen discovery tidak lengkap")
+	}
+	p.meta = &meta
+	return p.meta, nil
+}
+
+// key mencari public key IdP berdasarkan kid. JWKS diambil ulang jika kid belum dikenal
+// (rotasi kunci di IdP), dibatasi jwksRefreshInterval.
+func (p *Provider) key(ctx context.Context, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if k, ok := p.lookupKey(kid); ok {
+		return k, nil
+	}
+	if time.Since(p.keysFetched) < jwksRefreshInterval {
+		return nil, fmt.Errorf("kid %q tidak dikenal", kid)
+	}
+
+	var set jwkSet
+	if err := p.getJSON(ctx, p.meta.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("gagal mengambil JWKS: %w", err)
+	}
+	p.keys = set.publicKeys()
+	p.keysFetched = time.Now()
+
+	if k, ok := p.lookupKey(kid); ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("kid %q tidak dikenal", kid)
+}
+
+// lookupKey: Tanpa kid hanya diterima jika JWKS berisi tepat satu kunci
+func (p *Provider) lookupKey(kid string) (interface{}, bool) {
+	if kid == "" {
+		if len(p.keys) == 1 {
+			for _, k := range p.keys {
+				return k, true
+			}
+		}
+		return nil, false
+	}
+	k, ok := p.keys[kid]
+	return k, ok
+}
+
+func (p *Provider) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d dari %s", resp.StatusCode, url)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
@@ -0,0 +1,211 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go-sis-be/internal/configs"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockIdP: Identity provider lokal minimal untuk menguji alur authorization code + PKCE
+type mockIdP struct {
+	t         *testing.T
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	clientID  string
+	challenge string // code_challenge dari request authorize
+	nonce     string
+	claims    jwt.MapClaims
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockIdP{t: t, key: key, clientID: "sis-test"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA", "kid": "k1", "use": "sig",
+				"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("code") != "good-code" || CodeChallenge(r.Form.Get("code_verifier")) != m.challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": m.sign(m.claims)})
+	})
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockIdP) sign(claims jwt.MapClaims) string {
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = "k1"
+	s, err := tok.SignedString(m.key)
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	return s
+}
+
+func (m *mockIdP) provider(domains ...string) *Provider {
+	return NewProvider(configs.OIDCProviderConfig{
+		Name:           "mock",
+		Issuer:         m.server.URL,
+		ClientID:       m.clientID,
+		ClientSecret:   "secret",
+		RedirectURL:    "http://localhost/api/v1/auth/oidc/mock/callback",
+		Scopes:         []string{"openid", "email"},
+		AllowedDomains: domains,
+	})
+}
+
+// authorize mensimulasikan browser membuka URL authorize: IdP mencatat challenge dan nonce
+func (m *mockIdP) authorize(t *testing.T, authURL string) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("client_id") != m.clientID {
+		t.Fatalf("parameter authorize salah: %s", authURL)
+	}
+	m.challenge = q.Get("code_challenge")
+	m.nonce = q.Get("nonce")
+}
+
+func (m *mockIdP) baseClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            m.server.URL,
+		"aud":            m.clientID,
+		"sub":            "user-123",
+		"email":          "Guru@Sekolah.sch.id",
+		"email_verified": true,
+		"nonce":          m.nonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(5 * time.Minute).Unix(),
+	}
+}
+
+func TestExchangeSuccess(t *testing.T) {
+	idp := newMockIdP(t)
+	p := idp.provider()
+	ctx := context.Background()
+
+	authURL, err := p.AuthCodeURL(ctx, "state-1", "nonce-1", "verifier-verifier-verifier-verifier-verifier")
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	idp.authorize(t, authURL)
+	idp.claims = idp.baseClaims()
+
+	ident, err := p.Exchange(ctx, "good-code", "verifier-verifier-verifier-verifier-verifier", "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if ident.Subject != "user-123" || ident.Email != "guru@sekolah.sch.id" || !ident.EmailVerified {
+		t.Fatalf("identitas salah: %+v", ident)
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	idp := newMockIdP(t)
+	p := idp.provider()
+	ctx := context.Background()
+
+	authURL, _ := p.AuthCodeURL(ctx, "s", "nonce-1", "verifier-asli-verifier-asli-verifier-asli-xx")
+	idp.authorize(t, authURL)
+	idp.claims = idp.baseClaims()
+
+	if _, err := p.Exchange(ctx, "good-code", "verifier-lain-verifier-lain-verifier-lain-xx", "nonce-1"); err == nil {
+		t.Fatal("verifier PKCE yang salah harus ditolak")
+	}
+}
+
+func TestVerifyIDTokenRejections(t *testing.T) {
+	idp := newMockIdP(t)
+	p := idp.provider()
+	ctx := context.Background()
+	idp.nonce = "nonce-1"
+
+	cases := map[string]func(jwt.MapClaims){
+		"nonce berbeda":    func(c jwt.MapClaims) { c["nonce"] = "nonce-lain" },
+		"audience berbeda": func(c jwt.MapClaims) { c["aud"] = "client-lain" },
+		"issuer berbeda":   func(c jwt.MapClaims) { c["iss"] = "https://idp-lain.example" },
+		"kadaluarsa":       func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"tanpa sub":        func(c jwt.MapClaims) { delete(c, "sub") },
+	}
+	for name, mutate := range cases {
+		claims := idp.baseClaims()
+		mutate(claims)
+		if _, err := p.VerifyIDToken(ctx, idp.sign(claims), "nonce-1"); err == nil {
+			t.Errorf("%s: harusnya ditolak", name)
+		}
+	}
+
+	// Tanda tangan dari kunci lain
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, idp.baseClaims())
+	tok.Header["kid"] = "k1"
+	forged, _ := tok.SignedString(other)
+	if _, err := p.VerifyIDToken(ctx, forged, "nonce-1"); err == nil || !strings.Contains(err.Error(), "tidak valid") {
+		t.Errorf("tanda tangan palsu harus ditolak, dapat %v", err)
+	}
+}
+
+func TestEmailTrust(t *testing.T) {
+	idp := newMockIdP(t)
+	ctx := context.Background()
+	idp.nonce = "n"
+
+	// Tanpa email_verified: hanya dipercaya jika domain diizinkan
+	claims := idp.baseClaims()
+	delete(claims, "email_verified")
+
+	ident, err := idp.provider().VerifyIDToken(ctx, idp.sign(claims), "n")
+	if err != nil || ident.EmailVerified {
+		t.Fatalf("email tanpa email_verified tidak boleh dipercaya: %+v %v", ident, err)
+	}
+	ident, err = idp.provider("sekolah.sch.id").VerifyIDToken(ctx, idp.sign(claims), "n")
+	if err != nil || !ident.EmailVerified {
+		t.Fatalf("email dari domain yang diizinkan harus dipercaya: %+v %v", ident, err)
+	}
+
+	// Domain di luar daftar tidak dipercaya walau email_verified true
+	claims = idp.baseClaims()
+	claims["email"] = "orang@gmail.com"
+	ident, err = idp.provider("sekolah.sch.id").VerifyIDToken(ctx, idp.sign(claims), "n")
+	if err != nil || ident.EmailVerified {
+		t.Fatalf("domain di luar daftar tidak boleh dipercaya: %+v %v", ident, err)
+	}
+}
@@ -0,0 +1,100 @@
+// Package policy berisi aturan akses level resource (kepemilikan data),
+// pelengkap RBAC di middleware yang hanya melihat role.
+package policy
+
+import (
+	"context"
+	"time"
+
+	"go-sis-be/internal/configs"
+	"go-sis-be/internal/models"
+)
+
+// Action: Jenis operasi terhadap profil user
+type Action string
+
+const (
+	ActionRead   Action = "read"
+	ActionEdit   Action = "edit"
+	ActionDelete Action = "delete"
+)
+
+// Actor: Identitas pemanggil (diambil dari klaim JWT)
+type Actor struct {
+	UID  string
+	Role string
+}
+
+// Relations: Sumber data relasi antar user (wali-murid, guru-murid).
+// Dipisah sebagai interface agar aturan bisa dites tanpa database.
+type Relations interface {
+	IsParentOf(ctx context.Context, parentUID, studentUID string) (bool, error)
+	TeachesStudent(ctx context.Context, teacherUID, studentUID string) (bool, error)
+}
+
+// DBRelations: Implementasi Relations berbasis database (package models)
+type DBRelations struct {
+	Timeout time.Duration // Batas waktu per query (DB_QUERY_TIMEOUT_MS, 0 = tanpa batas)
+}
+
+func (d DBRelations) IsParentOf(ctx context.Context, parentUID, studentUID string) (bool, error) {
+	ctx, cancel := configs.WithQueryTimeout(ctx, d.Timeout)
+	defer cancel()
+	ok, err := models.IsParentOfStudent(ctx, parentUID, studentUID)
+	return ok, configs.QueryError(ctx, err)
+}
+
+func (d DBRelations) TeachesStudent(ctx context.Context, teacherUID, studentUID string) (bool, error) {
+	ctx, cancel := configs.WithQueryTimeout(ctx, d.Timeout)
+	defer cancel()
+	ok, err := models.IsTeacherOfStudent(ctx, teacherUID, studentUID)
+	return ok, configs.QueryError(ctx, err)
+}
+
+// CanAccessProfile menentukan apakah actor boleh melakukan action terhadap profil targetUID.
+//
+// Aturan:
+//   - Admin boleh semuanya, dan hanya admin yang boleh menghapus profil.
+//   - Service account (API key) hanya boleh membaca; RBAC sudah mensyaratkan scope users:read,
+//     yang cakupannya sama dengan users:list (semua akun). Edit & hapus selalu ditolak.
+//   - Setiap user boleh membaca profilnya sendiri.
+//   - Murid dan Guru boleh mengedit profilnya sendiri.
+//   - Wali hanya boleh membaca profil anak yang terhubung dengannya.
+//   - Guru boleh membaca profil murid di kelas yang ia ajar.
+func CanAccessProfile(ctx context.Context, rel Relations, actor Actor, targetUID string, action Action) (bool, error) {
+	if actor.UID == "" || targetUID == "" {
+		return false, nil
+	}
+
+	if actor.Role == models.ADMIN_ROLE_NAME {
+		return true, nil
+	}
+
+	if actor.Role == models.SERVICE_ROLE_NAME {
+		return action == ActionRead, nil
+	}
+
+	if actor.UID == targetUID {
+		switch action {
+		case ActionRead:
+			return true, nil
+		case ActionEdit:
+			return actor.Role == models.STUDENT_ROLE_NAME || actor.Role == models.TEACHER_ROLE_NAME, nil
+		}
+		return false, nil
+	}
+
+	// Selain diri sendiri, hanya boleh membaca
+	if action != ActionRead {
+		return false, nil
+	}
+
+	switch actor.Role {
+	case models.PARENT_ROLE_NAME:
+		return rel.IsParentOf(ctx, actor.UID, targetUID)
+	case models.TEACHER_ROLE_NAME:
+		return rel.TeachesStudent(ctx, actor.UID, targetUID)
+	}
+
+	return false, nil
+}
@@ -0,0 +1,115 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-sis-be/internal/models"
+)
+
+// fakeRelations: Relations in-memory untuk test
+type fakeRelations struct {
+	parents  map[string][]string // parentUID -> studentUID
+	teaching map[string][]string // teacherUID -> studentUID
+	err      error
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fakeRelations) IsParentOf(_ context.Context, parentUID, studentUID string) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	return contains(f.parents[parentUID], studentUID), nil
+}
+
+func (f fakeRelations) TeachesStudent(_ context.Context, teacherUID, studentUID string) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	return contains(f.teaching[teacherUID], studentUID), nil
+}
+
+func TestCanAccessProfile(t *testing.T) {
+	rel := fakeRelations{
+		parents:  map[string][]string{"wali-1": {"murid-1"}},
+		teaching: map[string][]string{"guru-1": {"murid-1"}},
+	}
+
+	admin := Actor{UID: "admin-1", Role: models.ADMIN_ROLE_NAME}
+	guru := Actor{UID: "guru-1", Role: models.TEACHER_ROLE_NAME}
+	murid := Actor{UID: "murid-1", Role: models.STUDENT_ROLE_NAME}
+	wali := Actor{UID: "wali-1", Role: models.PARENT_ROLE_NAME}
+	service := Actor{UID: "sa-1", Role: models.SERVICE_ROLE_NAME}
+
+	tests := []struct {
+		name   string
+		actor  Actor
+		target string
+		action Action
+		want   bool
+	}{
+		{"admin baca siapa saja", admin, "murid-2", ActionRead, true},
+		{"admin edit siapa saja", admin, "guru-1", ActionEdit, true},
+
+		{"murid baca diri sendiri", murid, "murid-1", ActionRead, true},
+		{"murid edit diri sendiri", murid, "murid-1", ActionEdit, true},
+		{"murid baca murid lain", murid, "murid-2", ActionRead, false},
+		{"murid edit murid lain", murid, "murid-2", ActionEdit, false},
+
+		{"wali baca anaknya", wali, "murid-1", ActionRead, true},
+		{"wali baca anak orang lain", wali, "murid-2", ActionRead, false},
+		{"wali edit anaknya", wali, "murid-1", ActionEdit, false},
+		{"wali baca diri sendiri", wali, "wali-1", ActionRead, true},
+		{"wali edit diri sendiri", wali, "wali-1", ActionEdit, false},
+
+		{"guru baca murid di kelasnya", guru, "murid-1", ActionRead, true},
+		{"guru baca murid kelas lain", guru, "murid-2", ActionRead, false},
+		{"guru edit murid di kelasnya", guru, "murid-1", ActionEdit, false},
+		{"guru edit diri sendiri", guru, "guru-1", ActionEdit, true},
+
+		{"admin hapus siapa saja", admin, "murid-1", ActionDelete, true},
+		{"murid hapus diri sendiri", murid, "murid-1", ActionDelete, false},
+		{"guru hapus murid di kelasnya", guru, "murid-1", ActionDelete, false},
+
+		{"service account baca profil", service, "murid-1", ActionRead, true},
+		{"service account edit profil", service, "murid-1", ActionEdit, false},
+		{"service account hapus profil", service, "murid-1", ActionDelete, false},
+
+		{"actor tanpa UID", Actor{Role: models.ADMIN_ROLE_NAME}, "murid-1", ActionRead, false},
+		{"target kosong", admin, "", ActionRead, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CanAccessProfile(context.Background(), rel, tt.actor, tt.target, tt.action)
+			if err != nil {
+				t.Fatalf("error tidak terduga: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CanAccessProfile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanAccessProfileRelationError(t *testing.T) {
+	rel := fakeRelations{err: errors.New("db down")}
+	wali := Actor{UID: "wali-1", Role: models.PARENT_ROLE_NAME}
+
+	ok, err := CanAccessProfile(context.Background(), rel, wali, "murid-1", ActionRead)
+	if err == nil {
+		t.Fatal("error dari Relations harus diteruskan")
+	}
+	if ok {
+		t.Error("akses tidak boleh diberikan saat terjadi error")
+	}
+}
@@ -0,0 +1,135 @@
+package seeder
+
+import "go-sis-be/internal/models"
+
+// Daftar kata untuk data palsu. Sengaja dibuat sederhana; tujuannya data terlihat wajar
+// di layar development, bukan representasi statistik penduduk.
+
+var maleFirstNames = []string{
+	"Budi", "Agus", "Andi", "Ahmad", "Rizky", "Dimas", "Fajar", "Hendra", "Joko", "Bayu",
+	"Eko", "Wahyu", "Arif", "Yoga", "Dedi", "Rudi", "Taufik", "Irfan", "Galih", "Putu",
+	"Made", "Kevin", "Yohanes", "Stefanus", "Raka", "Aditya", "Bima", "Gilang", "Hafiz", "Ilham",
+}
+
+var femaleFirstNames = []string{
+	"Siti", "Dewi", "Sri", "Ayu", "Putri", "Rina", "Intan", "Nur", "Wulan", "Fitri",
+	"Anisa", "Lestari", "Indah", "Ratna", "Maya", "Nabila", "Citra", "Kadek", "Ketut", "Maria",
+	"Theresia", "Aulia", "Salsabila", "Zahra", "Nadia", "Dian", "Yuliana", "Rahma", "Kartika", "Melati",
+}
+
+var lastNames = []string{
+	"Santoso", "Wijaya", "Saputra", "Pratama", "Hidayat", "Nugroho", "Setiawan", "Kurniawan",
+	"Wibowo", "Susanto", "Gunawan", "Permana", "Siregar", "Nasution", "Lubis", "Harahap",
+	"Simanjuntak", "Sinaga", "Tanjung", "Rahman", "Hakim", "Halim", "Firmansyah", "Ramadhan",
+	"Purnomo", "Suryadi", "Kusuma", "Utomo", "Manurung", "Pangaribuan",
+}
+
+var streetNames = []string{
+	"Merdeka", "Sudirman", "Diponegoro", "Gatot Subroto", "Ahmad Yani", "Pahlawan", "Kartini",
+	"Pemuda", "Veteran", "Cendrawasih", "Melati", "Mawar", "Flamboyan", "Kenanga", "Anggrek",
+	"Imam Bonjol", "Hasanuddin", "Pattimura", "Teuku Umar", "Gajah Mada",
+}
+
+// region: Kode wilayah 6 digit (provinsi, kabupaten/kota, kecamatan) yang dipakai di awal NIK
+type region struct {
+	Code        string
+	City        string
+	District    string
+	Villages    []string
+	PostalCode  string
+	PhoneRegion string
+}
+
+var regions = []region{
+	{"327301", "Kota Bandung", "Sukasari", []string{"Gegerkalong", "Isola", "Sarijadi", "Sukarasa"}, "40152", "022"},
+	{"327302", "Kota Bandung", "Coblong", []string{"Dago", "Lebak Siliwangi", "Sekeloa", "Cipaganti"}, "40135", "022"},
+	{"317401", "Kota Jakarta Selatan", "Tebet", []string{"Tebet Barat", "Tebet Timur", "Menteng Dalam", "Manggarai"}, "12810", "021"},
+	{"317101", "Kota Jakarta Pusat", "Gambir", []string{"Gambir", "Cideng", "Petojo Utara", "Duri Pulo"}, "10110", "021"},
+	{"337401", "Kota Semarang", "Semarang Tengah", []string{"Miroto", "Brumbungan", "Gabahan", "Sekayu"}, "50132", "024"},
+	{"357801", "Kota Surabaya", "Karang Pilang", []string{"Karang Pilang", "Kebraon", "Kedurus", "Waru Gunung"}, "60221", "031"},
+	{"347101", "Kota Yogyakarta", "Mantrijeron", []string{"Gedongkiwo", "Suryodiningratan", "Mantrijeron"}, "55143", "0274"},
+	{"127101", "Kota Medan", "Medan Kota", []string{"Pasar Baru", "Mesjid", "Teladan Barat", "Sitirejo I"}, "20212", "061"},
+	{"517101", "Kota Denpasar", "Denpasar Selatan", []string{"Sanur", "Sesetan", "Panjer", "Sidakarya"}, "80227", "0361"},
+	{"737101", "Kota Makassar", "Mariso", []string{"Bontorannu", "Lette", "Mariso", "Panambungan"}, "90126", "0411"},
+}
+
+// Agama dengan bobot kasar (mayoritas Islam) agar sebaran data terlihat wajar
+var religionWeights = []weighted{
+	{models.ReligionIslam, 80},
+	{models.ReligionKristen, 8},
+	{models.ReligionKatolik, 4},
+	{models.ReligionHindu, 5},
+	{models.ReligionBuddha, 2},
+	{models.ReligionKonghucu, 1},
+}
+
+var fatherJobWeights = []weighted{
+	{models.JobSwasta, 30},
+	{models.JobWiraswasta, 25},
+	{models.JobPNS, 12},
+	{models.JobTNI_Polri, 5},
+	{models.JobBUMN, 6},
+	{models.JobPetani, 8},
+	{models.JobNelayan, 3},
+	{models.JobLainnya, 8},
+	{models.JobTidakBekerja, 3},
+}
+
+var motherJobWeights = []weighted{
+	{models.JobIRT, 45},
+	{models.JobSwasta, 18},
+	{models.JobWiraswasta, 15},
+	{models.JobPNS, 10},
+	{models.JobBUMN, 4},
+	{models.JobPetani, 3},
+	{models.JobLainnya, 5},
+}
+
+var familyStatusWeights = []weighted{
+	{models.FamilyKandung, 92},
+	{models.FamilyTiri, 3},
+	{models.FamilyAngkat, 3},
+	{models.FamilyLainnya, 2},
+}
+
+var employmentWeights = []weighted{
+	{models.StatusPNS, 35},
+	{models.StatusPPPK, 20},
+	{models.StatusKontrak, 15},
+	{models.StatusHonorer, 20},
+	{models.StatusGuruTamu, 5},
+	{models.StatusLainnya, 5},
+}
+
+var educationWeights = []weighted{
+	{models.EduS1, 75},
+	{models.EduS2, 15},
+	{models.EduD3, 5},
+	{models.EduD2, 3},
+	{models.EduD1, 2},
+}
+
+// Pangkat/golongan guru PNS
+var rankClasses = []string{"III/a", "III/b", "III/c", "III/d", "IV/a", "IV/b"}
+
+var universities = []string{
+	"Universitas Pendidikan Indonesia", "Universitas Negeri Jakarta", "Universitas Negeri Semarang",
+	"Universitas Negeri Yogyakarta", "Universitas Negeri Surabaya", "Universitas Negeri Medan",
+	"Universitas Negeri Makassar", "Universitas Pendidikan Ganesha", "Universitas Terbuka",
+	"Universitas Muhammadiyah Surakarta",
+}
+
+var majors = []string{
+	"Pendidikan Matematika", "Pendidikan Bahasa Indonesia", "Pendidikan Bahasa Inggris",
+	"Pendidikan Biologi", "Pendidikan Fisika", "Pendidikan Sejarah", "Pendidikan Geografi",
+	"Pendidikan Agama Islam", "Pendidikan Jasmani", "Pendidikan Seni", "Bimbingan dan Konseling",
+	"Pendidikan Ekonomi", "Pendidikan Pancasila dan Kewarganegaraan",
+}
+
+var originSchools = []string{
+	"SD Negeri 1", "SD Negeri 2", "SD Negeri 5", "SD Negeri 12", "SD Muhammadiyah 1",
+	"SD Kristen Kalam Kudus", "SD Katolik Santa Maria", "MI Al-Hikmah", "SD Islam Terpadu Nurul Fikri",
+}
+
+// Prefix nomor HP operator seluler Indonesia (setelah "08")
+var mobilePrefixes = []string{"11", "12", "13", "21", "22", "52", "53", "57", "59", "77", "78", "81", "82", "95", "96"}
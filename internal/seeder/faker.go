@@ -0,0 +1,456 @@
+// Package seeder membangkitkan data palsu bergaya Indonesia (keluarga, murid, guru, kelas)
+// untuk database development. Dengan seed yang sama hasilnya selalu sama.
+package seeder
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"go-sis-be/internal/models"
+)
+
+// Tingkat kelas yang dibangkitkan (SMP)
+var grades = []int{7, 8, 9}
+
+// Options: Parameter pembangkitan data
+type Options struct {
+	Seed            uint64 // Seed random; seed sama = data sama
+	Students        int    // Jumlah murid (wali dibuat per keluarga)
+	Teachers        int    // Jumlah guru, guru pertama menjadi kepala sekolah
+	ClassesPerGrade int    // Jumlah rombel per tingkat, mis. 3 = 7A, 7B, 7C
+	Year            int    // Tahun awal tahun ajaran, mis. 2026 untuk 2026/2027
+}
+
+// Dataset: Semua data hasil Generate, siap dimasukkan ke database
+type Dataset struct {
+	AcademicYear string
+	Classes      []Class
+	Families     []Family
+	Teachers     []Teacher
+}
+
+// Class: Satu rombel pada tahun ajaran Dataset.AcademicYear
+type Class struct {
+	Name  string
+	Grade int
+}
+
+// Family: Satu keluarga dengan akun wali (role wali) dan anak-anak yang bersekolah
+type Family struct {
+	Parent   models.RegisterBaseRequest
+	Students []Student
+}
+
+// Student: Data registrasi murid beserta tingkat dan rombelnya (indeks ke Dataset.Classes)
+type Student struct {
+	models.RegisterStudentRequest
+	Grade int
+	Class int
+}
+
+// Teacher: Data registrasi guru beserta rombel yang diajar (indeks ke Dataset.Classes)
+type Teacher struct {
+	models.RegisterTeacherRequest
+	Classes []int
+}
+
+// Faker: Pembangkit data palsu. Menjaga NIK, NISN, NIP dan username tetap unik dalam satu Dataset.
+type Faker struct {
+	rnd  *rand.Rand
+	year int
+	used map[string]bool
+}
+
+// NewFaker membuat Faker deterministik untuk seed dan tahun ajaran tertentu
+func NewFaker(seed uint64, year int) *Faker {
+	return &Faker{
+		rnd:  rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15)),
+		year: year,
+		used: map[string]bool{},
+	}
+}
+
+// Generate membangkitkan kelas, guru, lalu keluarga sampai jumlah murid terpenuhi
+func Generate(opts Options) Dataset {
+	if opts.ClassesPerGrade <= 0 {
+		opts.ClassesPerGrade = 1
+	}
+	f := NewFaker(opts.Seed, opts.Year)
+	ds := Dataset{AcademicYear: fmt.Sprintf("%d/%d", opts.Year, opts.Year+1)}
+
+	classesByGrade := map[int][]int{}
+	for _, g := range grades {
+		for i := 0; i < opts.ClassesPerGrade; i++ {
+			classesByGrade[g] = append(classesByGrade[g], len(ds.Classes))
+			ds.Classes = append(ds.Classes, Class{Name: fmt.Sprintf("%d%c", g, 'A'+i), Grade: g})
+		}
+	}
+
+	for i := 0; i < opts.Teachers; i++ {
+		ds.Teachers = append(ds.Teachers, f.Teacher(i == 0))
+	}
+	f.assignTeachers(ds.Teachers, len(ds.Classes))
+
+	remaining := opts.Students
+	for remaining > 0 {
+		children := 1
+		if remaining > 1 && f.rnd.IntN(100) < 25 {
+			children = 2
+		}
+		fam := f.Family(children)
+		for i := range fam.Students {
+			ids := classesByGrade[fam.Students[i].Grade]
+			fam.Students[i].Class = ids[f.rnd.IntN(len(ids))]
+		}
+		ds.Families = append(ds.Families, fam)
+		remaining -= children
+	}
+	return ds
+}
+
+// Family membangkitkan orang tua dan children anak (kakak-adik beda tingkat)
+func (f *Faker) Family(children int) Family {
+	reg := f.region()
+	address := f.address(reg)
+	religion := f.pick(religionWeights)
+	surname := pickOne(f.rnd, lastNames)
+	singleParent := f.rnd.IntN(100) < 7
+
+	fatherName := pickOne(f.rnd, maleFirstNames) + " " + surname
+	motherName := pickOne(f.rnd, femaleFirstNames) + " " + pickOne(f.rnd, lastNames)
+	fatherJob, motherJob := f.pick(fatherJobWeights), f.pick(motherJobWeights)
+
+	// Akun wali dipegang ayah, atau ibu pada sebagian keluarga dan semua keluarga single parent
+	marital := models.MaritalMarried
+	if singleParent {
+		marital = models.MaritalSingleParent
+	}
+	parentName, parentGender := fatherName, models.GenderMale
+	if singleParent || f.rnd.IntN(100) < 30 {
+		parentName, parentGender = motherName, models.GenderFemale
+	}
+	parentBirth := f.date(f.year - f.between(35, 50))
+	parent := models.RegisterBaseRequest{
+		RoleID:        models.PARENT_ROLE_ID,
+		FullName:      parentName,
+		BirthDate:     parentBirth.Format("2006-01-02"),
+		NIK:           f.nik(reg, parentBirth, parentGender),
+		Gender:        parentGender,
+		Religion:      religion,
+		MaritalStatus: marital,
+		Address:       address,
+		PhoneNumber:   f.phone(),
+	}
+	parent.Username = f.username(parentName)
+	parent.Email = parent.Username + "@example.com"
+
+	fam := Family{Parent: parent}
+
+	// Kakak-adik tersebar di tingkat berbeda, kakak lebih dulu
+	order := 1 + f.rnd.IntN(3)
+	gradeIdx := f.rnd.Perm(len(grades))[:children]
+	if children == 2 && gradeIdx[0] < gradeIdx[1] {
+		gradeIdx[0], gradeIdx[1] = gradeIdx[1], gradeIdx[0]
+	}
+	status := f.pick(familyStatusWeights)
+	for _, gi := range gradeIdx {
+		fam.Students = append(fam.Students, f.student(reg, address, religion, surname, status, order, grades[gi], fatherName, motherName, fatherJob, motherJob))
+		order += 1 + f.rnd.IntN(2)
+	}
+	return fam
+}
+
+func (f *Faker) student(reg region, address, religion, surname, status string, order, grade int, father, mother, fatherJob, motherJob string) Student {
+	gender, first := models.GenderMale, pickOne(f.rnd, maleFirstNames)
+	if f.rnd.IntN(2) == 0 {
+		gender, first = models.GenderFemale, pickOne(f.rnd, femaleFirstNames)
+	}
+	name := first + " " + surname
+
+	// Umur wajar: kelas 7 berumur 12-13 tahun di awal tahun ajaran
+	birth := f.date(f.year - grade - 6 + f.rnd.IntN(2))
+	admitted := f.year - (grade - grades[0])
+
+	req := models.RegisterStudentRequest{
+		RegisterBaseRequest: models.RegisterBaseRequest{
+			RoleID:        models.STUDENT_ROLE_ID,
+			FullName:      name,
+			BirthDate:     birth.Format("2006-01-02"),
+			NIK:           f.nik(reg, birth, gender),
+			Gender:        gender,
+			Religion:      religion,
+			MaritalStatus: models.MaritalSingle,
+			Address:       address,
+		},
+		StudentDetails: models.StudentDetails{
+			NISN:          f.nisn(birth),
+			FamilyStatus:  status,
+			ChildOrder:    order,
+			OriginSchool:  pickOne(f.rnd, originSchools) + " " + strings.TrimPrefix(reg.City, "Kota "),
+			ReceivedClass: fmt.Sprintf("Kelas %d", grades[0]),
+			ReceivedDate:  time.Date(admitted, time.July, 15, 0, 0, 0, 0, time.UTC).Format("2006-01-02"),
+			FatherName:    father,
+			MotherName:    mother,
+			ParentAddress: address,
+			FatherJob:     fatherJob,
+			MotherJob:     motherJob,
+		},
+	}
+	req.Username = f.username(name)
+
+	// Anak yang tidak tinggal dengan orang tua kandung dicatat dengan wali
+	if status == models.FamilyLainnya {
+		guardian := pickOne(f.rnd, maleFirstNames) + " " + pickOne(f.rnd, lastNames)
+		req.GuardianName = guardian
+		req.GuardianAddress = f.address(reg)
+		req.GuardianPhone = f.phone()
+		req.GuardianJob = f.pick(fatherJobWeights)
+	}
+	return Student{RegisterStudentRequest: req, Grade: grade}
+}
+
+// Teacher membangkitkan satu guru. NIP hanya untuk ASN (PNS/PPPK), pangkat hanya untuk PNS.
+func (f *Faker) Teacher(principal bool) Teacher {
+	reg := f.region()
+	gender, first := models.GenderMale, pickOne(f.rnd, maleFirstNames)
+	if f.rnd.IntN(100) < 60 {
+		gender, first = models.GenderFemale, pickOne(f.rnd, femaleFirstNames)
+	}
+	name := first + " " + pickOne(f.rnd, lastNames)
+	if f.rnd.IntN(100) < 50 {
+		name += ", S.Pd."
+	}
+
+	birth := f.date(f.year - f.between(25, 58))
+	hireYear := f.between(birth.Year()+23, f.year)
+	hire := f.date(hireYear)
+
+	position := models.EmploymentGuruMatPel
+	switch n := f.rnd.IntN(100); {
+	case principal:
+		position = models.EmploymentKepsek
+	case n < 35:
+		position = models.EmploymentGuruKelas
+	case n >= 95:
+		position = models.EmploymentLainnya
+	}
+	status := f.pick(employmentWeights)
+	if principal {
+		status = models.StatusPNS
+	}
+	education := f.pick(educationWeights)
+	gradYear := hireYear - f.rnd.IntN(3)
+
+	marital := models.MaritalMarried
+	if f.year-birth.Year() < 30 && f.rnd.IntN(2) == 0 {
+		marital = models.MaritalSingle
+	}
+
+	req := models.RegisterTeacherRequest{
+		RegisterBaseRequest: models.RegisterBaseRequest{
+			RoleID:        models.TEACHER_ROLE_ID,
+			FullName:      name,
+			BirthDate:     birth.Format("2006-01-02"),
+			NIK:           f.nik(reg, birth, gender),
+			Gender:        gender,
+			Religion:      f.pick(religionWeights),
+			MaritalStatus: marital,
+			Address:       f.address(reg),
+			PhoneNumber:   f.phone(),
+		},
+		TeacherDetails: models.TeacherDetails{
+			FunctionalPosition: position,
+			EmploymentStatus:   status,
+			HireDate:           hire.Format("2006-01-02"),
+			LastEducation:      education,
+			University:         pickOne(f.rnd, universities),
+			Major:              pickOne(f.rnd, majors),
+			GraduationYear:     fmt.Sprint(gradYear),
+			DiplomaNumber:      fmt.Sprintf("%d%s%06d", gradYear, reg.Code[:2], f.rnd.IntN(1000000)),
+		},
+	}
+	req.Username = f.username(strings.TrimSuffix(name, ", S.Pd."))
+	req.Email = req.Username + "@example.com"
+
+	if status == models.StatusPNS || status == models.StatusPPPK {
+		req.NIP = f.nip(birth, hire, gender)
+		req.SKAppointmentNumber = fmt.Sprintf("800/%03d/Disdik/%d", f.rnd.IntN(1000), hireYear)
+	}
+	if status == models.StatusPNS {
+		req.RankClass = rankClasses[min(len(rankClasses)-1, (f.year-hireYear)/4)]
+	}
+	if status != models.StatusGuruTamu {
+		req.NUPTK = f.digits(16, true)
+	}
+	if f.rnd.IntN(100) < 60 {
+		req.NRG = f.digits(12, true)
+		req.EducatorCertNumber = fmt.Sprintf("%d%s", hireYear+f.between(2, 5), f.digits(8, false))
+	}
+	return Teacher{RegisterTeacherRequest: req}
+}
+
+// assignTeachers memberi setiap rombel satu wali kelas, lalu guru mapel mengajar 2-4 rombel
+func (f *Faker) assignTeachers(teachers []Teacher, classes int) {
+	if len(teachers) == 0 || classes == 0 {
+		return
+	}
+	var homeroom, subject []int
+	for i, t := range teachers {
+		switch t.FunctionalPosition {
+		case models.EmploymentKepsek, models.EmploymentLainnya:
+		case models.EmploymentGuruKelas:
+			homeroom = append(homeroom, i)
+		default:
+			subject = append(subject, i)
+		}
+	}
+	if len(homeroom) == 0 {
+		homeroom = subject
+	}
+	if len(homeroom) == 0 {
+		homeroom = []int{0}
+	}
+
+	for c := 0; c < classes; c++ {
+		t := homeroom[c%len(homeroom)]
+		teachers[t].Classes = appendUnique(teachers[t].Classes, c)
+	}
+	for _, t := range subject {
+		for _, c := range f.rnd.Perm(classes)[:min(classes, f.between(2, 4))] {
+			teachers[t].Classes = appendUnique(teachers[t].Classes, c)
+		}
+	}
+}
+
+// nik: 6 digit kode wilayah + tanggal lahir DDMMYY (tanggal +40 untuk perempuan) + 4 digit urut
+func (f *Faker) nik(reg region, birth time.Time, gender string) string {
+	day := birth.Day()
+	if gender == models.GenderFemale {
+		day += 40
+	}
+	prefix := fmt.Sprintf("%s%02d%02d%02d", reg.Code, day, int(birth.Month()), birth.Year()%100)
+	return f.unique(func() string { return fmt.Sprintf("%s%04d", prefix, 1+f.rnd.IntN(9999)) })
+}
+
+// nisn: 3 digit akhir tahun lahir + 7 digit urut
+func (f *Faker) nisn(birth time.Time) string {
+	return f.unique(func() string { return fmt.Sprintf("%03d%07d", birth.Year()%1000, f.rnd.IntN(10000000)) })
+}
+
+// nip: Tanggal lahir YYYYMMDD + TMT YYYYMM + kode jenis kelamin (1/2) + 3 digit urut
+func (f *Faker) nip(birth, hire time.Time, gender string) string {
+	sex := 1
+	if gender == models.GenderFemale {
+		sex = 2
+	}
+	prefix := fmt.Sprintf("%s%s%d", birth.Format("20060102"), hire.Format("200601"), sex)
+	return f.unique(func() string { return fmt.Sprintf("%s%03d", prefix, 1+f.rnd.IntN(999)) })
+}
+
+// username: nama.depan.belakang, ditambah angka bila sudah dipakai
+func (f *Faker) username(name string) string {
+	parts := strings.Fields(strings.ToLower(name))
+	base := parts[0]
+	if len(parts) > 1 {
+		base += "." + parts[len(parts)-1]
+	}
+	base = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || r == '.' {
+			return r
+		}
+		return -1
+	}, base)
+
+	if !f.used["u:"+base] {
+		f.used["u:"+base] = true
+		return base
+	}
+	for n := 2; ; n++ {
+		if u := fmt.Sprintf("%s%d", base, n); !f.used["u:"+u] {
+			f.used["u:"+u] = true
+			return u
+		}
+	}
+}
+
+func (f *Faker) address(reg region) string {
+	return fmt.Sprintf("Jl. %s No. %d, RT %03d/RW %03d, Kel. %s, Kec. %s, %s %s",
+		pickOne(f.rnd, streetNames), 1+f.rnd.IntN(150), 1+f.rnd.IntN(12), 1+f.rnd.IntN(9),
+		pickOne(f.rnd, reg.Villages), reg.District, reg.City, reg.PostalCode)
+}
+
+func (f *Faker) phone() string {
+	return "08" + pickOne(f.rnd, mobilePrefixes) + f.digits(4+f.rnd.IntN(5), false)
+}
+
+func (f *Faker) region() region {
+	return regions[f.rnd.IntN(len(regions))]
+}
+
+// date: Tanggal acak di tahun year
+func (f *Faker) date(year int) time.Time {
+	return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, f.rnd.IntN(365))
+}
+
+func (f *Faker) between(lo, hi int) int {
+	if hi <= lo {
+		return lo
+	}
+	return lo + f.rnd.IntN(hi-lo+1)
+}
+
+func (f *Faker) digits(n int, nonZeroFirst bool) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte('0' + f.rnd.IntN(10))
+	}
+	if nonZeroFirst && n > 0 {
+		b[0] = byte('1' + f.rnd.IntN(9))
+	}
+	return string(b)
+}
+
+// unique memanggil gen sampai menghasilkan nilai yang belum pernah dipakai
+func (f *Faker) unique(gen func() string) string {
+	for {
+		if v := gen(); !f.used[v] {
+			f.used[v] = true
+			return v
+		}
+	}
+}
+
+type weighted struct {
+	Value  string
+	Weight int
+}
+
+func (f *Faker) pick(options []weighted) string {
+	total := 0
+	for _, o := range options {
+		total += o.Weight
+	}
+	n := f.rnd.IntN(total)
+	for _, o := range options {
+		if n < o.Weight {
+			return o.Value
+		}
+		n -= o.Weight
+	}
+	return options[len(options)-1].Value
+}
+
+func pickOne(rnd *rand.Rand, list []string) string {
+	return list[rnd.IntN(len(list))]
+}
+
+func appendUnique(list []int, v int) []int {
+	for _, x := range list {
+		if x == v {
+			return list
+		}
+	}
+	return append(list, v)
+}
@@ -0,0 +1,107 @@
+package seeder
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"go-sis-be/internal/models"
+)
+
+var (
+	nikPattern  = regexp.MustCompile(`^\d{6}(0[1-9]|[12]\d|3[01]|4[1-9]|[56]\d|7[01])(0[1-9]|1[0-2])\d{2}\d{4}$`)
+	nisnPattern = regexp.MustCompile(`^\d{10}$`)
+	nipPattern  = regexp.MustCompile(`^(19|20)\d{6}(19|20)\d{2}(0[1-9]|1[0-2])[12]\d{3}$`)
+)
+
+func TestGenerateReproducible(t *testing.T) {
+	opts := Options{Seed: 7, Students: 60, Teachers: 8, ClassesPerGrade: 2, Year: 2026}
+	a, b := Generate(opts), Generate(opts)
+	if !reflect.DeepEqual(a, b) {
+		t.Fatal("seed yang sama harus menghasilkan data yang sama")
+	}
+
+	opts.Seed = 8
+	if reflect.DeepEqual(a, Generate(opts)) {
+		t.Fatal("seed berbeda seharusnya menghasilkan data berbeda")
+	}
+}
+
+func TestGenerateValidData(t *testing.T) {
+	ds := Generate(Options{Seed: 42, Students: 200, Teachers: 20, ClassesPerGrade: 3, Year: 2026})
+
+	if len(ds.Classes) != 9 || ds.AcademicYear != "2026/2027" {
+		t.Fatalf("kelas/tahun ajaran salah: %d %s", len(ds.Classes), ds.AcademicYear)
+	}
+
+	seen := map[string]bool{}
+	unique := func(kind, v string) {
+		if seen[kind+v] {
+			t.Errorf("%s %s dipakai dua kali", kind, v)
+		}
+		seen[kind+v] = true
+	}
+	checkPerson := func(p models.RegisterBaseRequest) {
+		if !nikPattern.MatchString(p.NIK) {
+			t.Errorf("NIK %s (%s) tidak valid", p.NIK, p.FullName)
+		}
+		unique("nik", p.NIK)
+		unique("username", p.Username)
+		if p.Gender != models.GenderMale && p.Gender != models.GenderFemale {
+			t.Errorf("gender %q tidak valid", p.Gender)
+		}
+	}
+
+	students := 0
+	for _, fam := range ds.Families {
+		checkPerson(fam.Parent)
+		if fam.Parent.RoleID != models.PARENT_ROLE_ID {
+			t.Errorf("akun wali harus role wali, dapat %d", fam.Parent.RoleID)
+		}
+		for _, s := range fam.Students {
+			students++
+			checkPerson(s.RegisterBaseRequest)
+			if !nisnPattern.MatchString(s.NISN) {
+				t.Errorf("NISN %s tidak valid", s.NISN)
+			}
+			unique("nisn", s.NISN)
+			if ds.Classes[s.Class].Grade != s.Grade {
+				t.Errorf("murid kelas %d ditempatkan di rombel %s", s.Grade, ds.Classes[s.Class].Name)
+			}
+			if s.FatherJob == "" || s.MotherJob == "" || s.FamilyStatus == "" {
+				t.Errorf("data orang tua murid %s tidak lengkap", s.Username)
+			}
+		}
+	}
+	if students != 200 {
+		t.Errorf("jumlah murid %d, harusnya 200", students)
+	}
+
+	principals := 0
+	homeroom := map[int]bool{}
+	for _, tc := range ds.Teachers {
+		checkPerson(tc.RegisterBaseRequest)
+		if tc.FunctionalPosition == models.EmploymentKepsek {
+			principals++
+		}
+		asn := tc.EmploymentStatus == models.StatusPNS || tc.EmploymentStatus == models.StatusPPPK
+		if asn != (tc.NIP != "") {
+			t.Errorf("NIP hanya untuk ASN: status %s, NIP %q", tc.EmploymentStatus, tc.NIP)
+		}
+		if tc.NIP != "" {
+			if !nipPattern.MatchString(tc.NIP) {
+				t.Errorf("NIP %s tidak valid", tc.NIP)
+			}
+			unique("nip", tc.NIP)
+		}
+		for _, c := range tc.Classes {
+			homeroom[c] = true
+		}
+	}
+	if principals != 1 {
+		t.Errorf("harus ada tepat satu kepala sekolah, dapat %d", principals)
+	}
+	if len(homeroom) != len(ds.Classes) {
+		t.Errorf("setiap rombel harus punya guru, baru %d dari %d", len(homeroom), len(ds.Classes))
+	}
+}
@@ -0,0 +1,154 @@
+package seeder
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go-sis-be/internal/models"
+)
+
+// ErrAlreadySeeded: Database sudah berisi guru/murid/wali, seeding ulang akan bentrok username & NIK
+var ErrAlreadySeeded = errors.New("database sudah berisi data guru/murid/wali")
+
+// Summary: Jumlah baris yang dimasukkan Insert
+type Summary struct {
+	Classes  int
+	Teachers int
+	Students int
+	Parents  int
+}
+
+// Insert memasukkan seluruh Dataset dalam satu transaksi. Semua akun memakai passwordHash yang sama
+// (hash cukup dihitung sekali, karena argon2id sengaja lambat).
+func Insert(ctx context.Context, db *sql.DB, ds Dataset, passwordHash string) (Summary, error) {
+	var sum Summary
+
+	var existing int
+	err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM login_users WHERE role_id IN ($1, $2, $3)`,
+		models.TEACHER_ROLE_ID, models.STUDENT_ROLE_ID, models.PARENT_ROLE_ID).Scan(&existing)
+	if err != nil {
+		return sum, err
+	}
+	if existing > 0 {
+		return sum, ErrAlreadySeeded
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return sum, err
+	}
+	defer tx.Rollback()
+
+	classIDs := make([]int, len(ds.Classes))
+	for i, c := range ds.Classes {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO classes (name, academic_year) VALUES ($1, $2)
+			ON CONFLICT (name, academic_year) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id`, c.Name, ds.AcademicYear).Scan(&classIDs[i])
+		if err != nil {
+			return sum, fmt.Errorf("gagal insert kelas %s: %w", c.Name, err)
+		}
+		sum.Classes++
+	}
+
+	for _, t := range ds.Teachers {
+		uid, err := insertAccount(ctx, tx, &t.RegisterBaseRequest, passwordHash)
+		if err != nil {
+			return sum, err
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO teacher_details (
+				uid, nip, nuptk, nrg, functional_position, employment_status,
+				rank_class, hire_date, sk_appointment_number, educator_cert_number,
+				last_education, university, major, graduation_year, diploma_number
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
+			uid, nullString(t.NIP), nullString(t.NUPTK), nullString(t.NRG), t.FunctionalPosition, t.EmploymentStatus,
+			nullString(t.RankClass), t.HireDate, nullString(t.SKAppointmentNumber), nullString(t.EducatorCertNumber),
+			t.LastEducation, t.University, t.Major, t.GraduationYear, nullString(t.DiplomaNumber),
+		)
+		if err != nil {
+			return sum, fmt.Errorf("gagal insert teacher details %s: %w", t.Username, err)
+		}
+		for _, c := range t.Classes {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO class_teachers (class_id, teacher_uid) VALUES ($1, $2)`, classIDs[c], uid); err != nil {
+				return sum, fmt.Errorf("gagal insert class_teachers: %w", err)
+			}
+		}
+		sum.Teachers++
+	}
+
+	for _, fam := range ds.Families {
+		parentUID, err := insertAccount(ctx, tx, &fam.Parent, passwordHash)
+		if err != nil {
+			return sum, err
+		}
+		sum.Parents++
+
+		for _, s := range fam.Students {
+			uid, err := insertAccount(ctx, tx, &s.RegisterBaseRequest, passwordHash)
+			if err != nil {
+				return sum, err
+			}
+
+			var nisSeq int64
+			if err := tx.QueryRowContext(ctx, "SELECT nextval('nis_seq')").Scan(&nisSeq); err != nil {
+				return sum, fmt.Errorf("gagal generate nis sequence: %w", err)
+			}
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO student_details (
+					uid, nis, nisn, family_status, child_order,
+					origin_school, received_class, received_date,
+					father_name, mother_name, parent_address, father_job, mother_job,
+					guardian_name, guardian_address, guardian_phone, guardian_job, parent_uid
+				) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`,
+				uid, fmt.Sprintf("%010d", nisSeq), s.NISN, s.FamilyStatus, s.ChildOrder,
+				s.OriginSchool, s.ReceivedClass, s.ReceivedDate,
+				s.FatherName, s.MotherName, s.ParentAddress, s.FatherJob, s.MotherJob,
+				nullString(s.GuardianName), nullString(s.GuardianAddress), nullString(s.GuardianPhone), nullString(s.GuardianJob),
+				parentUID,
+			)
+			if err != nil {
+				return sum, fmt.Errorf("gagal insert student details %s: %w", s.Username, err)
+			}
+			if _, err := tx.ExecContext(ctx, `INSERT INTO class_students (class_id, student_uid) VALUES ($1, $2)`, classIDs[s.Class], uid); err != nil {
+				return sum, fmt.Errorf("gagal insert class_students: %w", err)
+			}
+			sum.Students++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return sum, err
+	}
+	return sum, nil
+}
+
+// insertAccount membuat baris login_users dan person, mengembalikan uid
+func insertAccount(ctx context.Context, tx *sql.Tx, req *models.RegisterBaseRequest, passwordHash string) (string, error) {
+	var uid string
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO login_users (username, pass, role_id) VALUES ($1, $2, $3)
+		RETURNING uid`, req.Username, passwordHash, req.RoleID).Scan(&uid)
+	if err != nil {
+		return "", fmt.Errorf("gagal insert login untuk %s: %w", req.Username, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO person (
+			uid, full_name, birth_date, nik, gender, religion,
+			marital_status, address, phone_number, email
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		uid, req.FullName, req.BirthDate, req.NIK, req.Gender, req.Religion,
+		req.MaritalStatus, req.Address, nullString(req.PhoneNumber), nullString(req.Email),
+	)
+	if err != nil {
+		return "", fmt.Errorf("gagal insert person untuk NIK %s: %w", req.NIK, err)
+	}
+	return uid, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -9,6 +9,7 @@ const (
 	Mime                 = "application/json"
 	ErrMsgInvalidPayload = "Invalid request payload"
 	ErrMsgServerError    = "Internal Server Error"
+	ErrMsgTimeout        = "Database tidak merespons tepat waktu, silakan coba lagi"
 )
 
 func ParseIntQuery(queryStr string, defaultValue int) int {
@@ -2,7 +2,6 @@ package utils
 
 import (
 	"errors"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -10,52 +9,164 @@ import (
 
 // JWTClaims: Payload token kita
 type JWTClaims struct {
-	UID      string `json:"uid"`
-	Username string `json:"username"`
-	Role     string `json:"role"`
+	UID       string `json:"uid"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid"` // ID sesi (tabel sessions) tempat token ini diterbitkan
+	// TokenVersion: Harus sama dengan login_users.token_version, jika tidak token ditolak
+	TokenVersion int `json:"tv"`
+	// MustChangePassword: Token hanya boleh dipakai untuk ganti password / logout
+	MustChangePassword bool `json:"mcp,omitempty"`
+	// TwoFactorSetupRequired: Role wajib 2FA tapi user belum enroll; token hanya untuk enroll 2FA
+	TwoFactorSetupRequired bool `json:"tfr,omitempty"`
+	// Scopes: Hanya diisi untuk principal API key (service account), tidak pernah ada di JWT
+	Scopes []string `json:"-"`
+	// Actor: Admin asli saat token ini adalah token impersonation (klaim "act", RFC 8693)
+	Actor *ActorClaim `json:"act,omitempty"`
+	// TokenType: Jenis token (klaim "typ"); ValidateToken hanya menerima tokenTypeAccess
+	TokenType string `json:"typ"`
 	jwt.RegisteredClaims
 }
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+// Jenis token yang ditandatangani dengan kunci JWKS. Kunci yang sama dipublikasikan, jadi
+// pihak lain yang memverifikasi token wajib membedakan jenisnya lewat klaim typ dan aud.
+const (
+	tokenTypeAccess             = "access"
+	tokenTypeTwoFactorChallenge = "2fa-challenge"
+)
+
+// ActorClaim: Identitas admin yang sedang login sebagai user lain
+type ActorClaim struct {
+	UID          string `json:"sub"`
+	Username     string `json:"username"`
+	TokenVersion int    `json:"tv"` // token_version admin; token ikut mati jika admin dicabut
+}
+
+// Impersonated: true jika token ini token impersonation
+func (c *JWTClaims) Impersonated() bool {
+	return c.Actor != nil
+}
+
+// AccessFlags: Pembatasan yang ikut dibawa access token
+type AccessFlags struct {
+	MustChangePassword     bool
+	TwoFactorSetupRequired bool
+}
+
+// Masa berlaku token
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 7 * 24 * time.Hour
+)
 
-func GenerateAccessToken(uid, username, role string) (string, error) {
+func GenerateAccessToken(uid, username, role, sessionID string, tokenVersion int, flags AccessFlags) (string, error) {
 	claims := &JWTClaims{
-		UID:      uid,
-		Username: username,
-		Role:     role,
+		UID:                    uid,
+		Username:               username,
+		Role:                   role,
+		SessionID:              sessionID,
+		TokenVersion:           tokenVersion,
+		MustChangePassword:     flags.MustChangePassword,
+		TwoFactorSetupRequired: flags.TwoFactorSetupRequired,
+		TokenType:              tokenTypeAccess,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   uid,
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	ks, err := activeKeys()
+	if err != nil {
+		return "", err
+	}
+	claims.Issuer = ks.Issuer
+	claims.Audience = jwt.ClaimStrings{ks.Audience}
+	return ks.sign(claims)
 }
 
-func GenerateRefreshToken(uid string) (string, error) {
+// ImpersonationTTL: Masa berlaku token impersonation; tidak bisa di-refresh
+const ImpersonationTTL = 10 * time.Minute
+
+// GenerateImpersonationToken membuat access token atas nama user target dengan klaim act berisi admin.
+// sessionID adalah sesi admin sehingga token ikut mati saat admin logout / sesinya dicabut.
+func GenerateImpersonationToken(uid, username, role, sessionID string, tokenVersion int, actor ActorClaim) (string, *JWTClaims, error) {
+	jti, err := NewUUID()
+	if err != nil {
+		return "", nil, err
+	}
+
+	claims := &JWTClaims{
+		UID:          uid,
+		Username:     username,
+		Role:         role,
+		SessionID:    sessionID,
+		TokenVersion: tokenVersion,
+		Actor:        &actor,
+		TokenType:    tokenTypeAccess,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ImpersonationTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   uid,
+		},
+	}
+	ks, err := activeKeys()
+	if err != nil {
+		return "", nil, err
+	}
+	claims.Issuer = ks.Issuer
+	claims.Audience = jwt.ClaimStrings{ks.Audience}
+	signed, err := ks.sign(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
+}
+
+// GenerateRefreshToken membuat refresh token baru dalam token family familyID.
+// Setiap token punya ID unik (jti) yang dicatat di tabel refresh_tokens untuk rotasi.
+func GenerateRefreshToken(uid, familyID string) (string, *RefreshClaims, error) {
+	jti, err := NewUUID()
+	if err != nil {
+		return "", nil, err
+	}
+
 	claims := &RefreshClaims{
-		UID: uid,
+		UID:      uid,
+		FamilyID: familyID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)),
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(RefreshTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   uid,
 		},
 	}
+	ks, err := activeKeys()
+	if err != nil {
+		return "", nil, err
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(os.Getenv("JWT_REFRESH_SECRET")))
+	signed, err := token.SignedString(ks.refreshSecret)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
 }
 
 func ValidateToken(tokenString string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+	ks, err := activeKeys()
+	if err != nil {
+		return nil, err
+	}
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, ks.keyFunc,
+		jwt.WithIssuer(ks.Issuer), jwt.WithAudience(ks.Audience))
 
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
+	// Token lain yang ditandatangani kunci yang sama (mis. challenge 2FA) bukan access token
+	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid && claims.TokenType == tokenTypeAccess {
 		return claims, nil
 	}
 
@@ -64,15 +175,20 @@ func ValidateToken(tokenString string) (*JWTClaims, error) {
 
 // Pastikan struct Claims sudah ada
 type RefreshClaims struct {
-	UID string `json:"uid"`
+	UID      string `json:"uid"`
+	FamilyID string `json:"fid"` // Token family: semua hasil rotasi dari satu login
 	jwt.RegisteredClaims
 }
 
 func ValidateRefreshToken(tokenString string) (*RefreshClaims, error) {
+	ks, err := activeKeys()
+	if err != nil {
+		return nil, err
+	}
 	token, err := jwt.ParseWithClaims(tokenString, &RefreshClaims{}, func(token *jwt.Token) (interface{}, error) {
 		// Gunakan Secret Key khusus Refresh Token Anda
-		return []byte(os.Getenv("JWT_REFRESH_SECRET")), nil
-	})
+		return ks.refreshSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 	if err != nil {
 		return nil, err
@@ -84,3 +200,66 @@ func ValidateRefreshToken(tokenString string) (*RefreshClaims, error) {
 
 	return nil, errors.New("invalid refresh token")
 }
+
+// TwoFactorChallengeTTL: Waktu untuk memasukkan kode 2FA setelah password benar
+const TwoFactorChallengeTTL = 5 * time.Minute
+
+const twoFactorAudience = "2fa-challenge"
+
+// TwoFactorChallengeClaims: Token sementara antara langkah password dan langkah kode 2FA
+type TwoFactorChallengeClaims struct {
+	UID       string `json:"uid"`
+	Device    string `json:"device,omitempty"`
+	TokenType string `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+// GenerateTwoFactorChallenge membuat challenge token untuk uid yang sudah lolos cek password
+func GenerateTwoFactorChallenge(uid, device string) (string, *TwoFactorChallengeClaims, error) {
+	jti, err := NewUUID()
+	if err != nil {
+		return "", nil, err
+	}
+
+	claims := &TwoFactorChallengeClaims{
+		UID:       uid,
+		Device:    device,
+		TokenType: tokenTypeTwoFactorChallenge,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Audience:  jwt.ClaimStrings{twoFactorAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(TwoFactorChallengeTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   uid,
+		},
+	}
+	ks, err := activeKeys()
+	if err != nil {
+		return "", nil, err
+	}
+	claims.Issuer = ks.Issuer
+	signed, err := ks.sign(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
+}
+
+func ValidateTwoFactorChallenge(tokenString string) (*TwoFactorChallengeClaims, error) {
+	ks, err := activeKeys()
+	if err != nil {
+		return nil, err
+	}
+	token, err := jwt.ParseWithClaims(tokenString, &TwoFactorChallengeClaims{}, ks.keyFunc,
+		jwt.WithIssuer(ks.Issuer), jwt.WithAudience(twoFactorAudience))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*TwoFactorChallengeClaims); ok && token.Valid && claims.TokenType == tokenTypeTwoFactorChallenge {
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid 2fa challenge")
+}
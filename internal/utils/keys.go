@@ -0,0 +1,266 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeySet: Kunci penandatangan access token (satu aktif) dan kunci verifikasi (bisa banyak, untuk rotasi).
+// Setiap kunci dikenali lewat kid di header JWT.
+type KeySet struct {
+	SigningKID string
+	signingKey crypto.Signer
+	verifyKeys map[string]crypto.PublicKey
+
+	refreshSecret []byte // Refresh token tetap HMAC: hanya dipakai server ini, tidak perlu diverifikasi pihak lain
+
+	// Issuer dan Audience: Klaim iss/aud access token; wajib cocok saat validasi
+	Issuer   string
+	Audience string
+}
+
+// Nilai default klaim iss/aud access token
+const (
+	DefaultJWTIssuer   = "go-sis-be"
+	DefaultJWTAudience = "go-sis-api"
+)
+
+// keys: KeySet aktif, diisi InitJWTKeys saat startup
+var keys *KeySet
+
+// JWTKeyConfig: Lokasi materi kunci JWT
+type JWTKeyConfig struct {
+	PrivateKeyFile string // JWT_PRIVATE_KEY_FILE: PEM private key RSA (RS256) atau Ed25519 (EdDSA) untuk menandatangani
+	KeyID          string // JWT_KEY_ID: kid kunci aktif (default: nama file tanpa ekstensi)
+	PublicKeysDir  string // JWT_PUBLIC_KEYS_DIR: folder berisi <kid>.pem public key lama yang masih diterima (opsional)
+	RefreshSecret  string // JWT_REFRESH_SECRET: secret HMAC untuk refresh token
+	Issuer         string // JWT_ISSUER: klaim iss access token
+	Audience       string // JWT_AUDIENCE: klaim aud access token
+}
+
+// InitJWTKeys memuat kunci JWT dan gagal jika materi kunci tidak lengkap
+func InitJWTKeys(cfg JWTKeyConfig) error {
+	ks, err := LoadKeySet(cfg.PrivateKeyFile, cfg.KeyID, cfg.PublicKeysDir, cfg.RefreshSecret)
+	if err != nil {
+		return err
+	}
+	if cfg.Issuer != "" {
+		ks.Issuer = cfg.Issuer
+	}
+	if cfg.Audience != "" {
+		ks.Audience = cfg.Audience
+	}
+	keys = ks
+	return nil
+}
+
+// LoadKeySet membangun KeySet dari file kunci
+func LoadKeySet(privateKeyFile, kid, publicKeysDir, refreshSecret string) (*KeySet, error) {
+	if privateKeyFile == "" {
+		return nil, errors.New("JWT_PRIVATE_KEY_FILE wajib diisi")
+	}
+	if refreshSecret == "" {
+		return nil, errors.New("JWT_REFRESH_SECRET wajib diisi")
+	}
+
+	pemBytes, err := os.ReadFile(privateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca private key: %w", err)
+	}
+	signer, err := parsePrivateKey(pemBytes)
+	if err != nil {
+		return nil, fmt.Errorf("private key %s: %w", privateKeyFile, err)
+	}
+
+	if kid == "" {
+		kid = strings.TrimSuffix(filepath.Base(privateKeyFile), filepath.Ext(privateKeyFile))
+	}
+
+	ks := &KeySet{
+		SigningKID:    kid,
+		signingKey:    signer,
+		verifyKeys:    map[string]crypto.PublicKey{kid: signer.Public()},
+		refreshSecret: []byte(refreshSecret),
+		Issuer:        DefaultJWTIssuer,
+		Audience:      DefaultJWTAudience,
+	}
+
+	if publicKeysDir != "" {
+		files, err := filepath.Glob(filepath.Join(publicKeysDir, "*.pem"))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			pubKID := strings.TrimSuffix(filepath.Base(f), ".pem")
+			if pubKID == kid {
+				continue
+			}
+			pemBytes, err := os.ReadFile(f)
+			if err != nil {
+				return nil, fmt.Errorf("gagal membaca public key %s: %w", f, err)
+			}
+			pub, err := parsePublicKey(pemBytes)
+			if err != nil {
+				return nil, fmt.Errorf("public key %s: %w", f, err)
+			}
+			ks.verifyKeys[pubKID] = pub
+		}
+	}
+
+	return ks, nil
+}
+
+// signingMethodFor memetakan tipe kunci ke algoritma JWT
+func signingMethodFor(key interface{}) (jwt.SigningMethod, error) {
+	switch key.(type) {
+	case *rsa.PrivateKey, *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PrivateKey, ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, fmt.Errorf("tipe kunci %T tidak didukung (gunakan RSA atau Ed25519)", key)
+}
+
+// sign menandatangani claims dengan kunci aktif dan menambahkan kid ke header
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	method, err := signingMethodFor(ks.signingKey)
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = ks.SigningKID
+	return token.SignedString(ks.signingKey)
+}
+
+// keyFunc memilih kunci verifikasi berdasarkan kid dan memastikan algoritma cocok dengan tipe kunci
+func (ks *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	pub, ok := ks.verifyKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("kid %q tidak dikenal", kid)
+	}
+
+	method, err := signingMethodFor(pub)
+	if err != nil {
+		return nil, err
+	}
+	if token.Method.Alg() != method.Alg() {
+		return nil, fmt.Errorf("algoritma %s tidak cocok untuk kid %q", token.Method.Alg(), kid)
+	}
+	return pub, nil
+}
+
+// JWK: Satu public key dalam format JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`   // RSA modulus
+	E   string `json:"e,omitempty"`   // RSA exponent
+	Crv string `json:"crv,omitempty"` // OKP curve
+	X   string `json:"x,omitempty"`   // OKP public key
+}
+
+// JWKS: Dokumen /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWKS mengembalikan semua kunci verifikasi aktif dalam format JWKS
+func PublicJWKS() JWKS {
+	if keys == nil {
+		return JWKS{Keys: []JWK{}}
+	}
+	return keys.JWKS()
+}
+
+// JWKS mengubah kunci verifikasi KeySet ke format JWKS
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for kid, pub := range ks.verifyKeys {
+		b64 := base64.RawURLEncoding
+		switch k := pub.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA", Use: "sig", Alg: "RS256", Kid: kid,
+				N: b64.EncodeToString(k.N.Bytes()),
+				E: b64.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP", Use: "sig", Alg: "EdDSA", Kid: kid,
+				Crv: "Ed25519",
+				X:   b64.EncodeToString(k),
+			})
+		}
+	}
+	return set
+}
+
+// ActiveKeyID mengembalikan kid kunci yang sedang dipakai menandatangani
+func ActiveKeyID() string {
+	if keys == nil {
+		return ""
+	}
+	return keys.SigningKID
+}
+
+// activeKeys mengembalikan KeySet aktif, atau error jika InitJWTKeys belum dipanggil
+func activeKeys() (*KeySet, error) {
+	if keys == nil {
+		return nil, errors.New("kunci JWT belum diinisialisasi")
+	}
+	return keys, nil
+}
+
+func parsePrivateKey(pemBytes []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("format PEM tidak valid")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		switch k := key.(type) {
+		case *rsa.PrivateKey:
+			return k, nil
+		case ed25519.PrivateKey:
+			return k, nil
+		}
+		return nil, fmt.Errorf("tipe kunci %T tidak didukung (gunakan RSA atau Ed25519)", key)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("private key harus PKCS#8 atau PKCS#1")
+}
+
+func parsePublicKey(pemBytes []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("format PEM tidak valid")
+	}
+
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		if _, err := signingMethodFor(key); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("public key harus PKIX atau PKCS#1")
+}
@@ -0,0 +1,254 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func writePrivateKey(t *testing.T, dir, name string, key interface{}) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func writePublicKey(t *testing.T, dir, name string, pub interface{}) {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// useKeySet memasang ks sebagai KeySet aktif selama test
+func useKeySet(t *testing.T, ks *KeySet) {
+	t.Helper()
+	prev := keys
+	keys = ks
+	t.Cleanup(func() { keys = prev })
+}
+
+func TestLoadKeySetRequiresKeyMaterial(t *testing.T) {
+	if _, err := LoadKeySet("", "", "", "secret"); err == nil {
+		t.Error("tanpa private key harus error")
+	}
+
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	path := writePrivateKey(t, t.TempDir(), "k1.pem", priv)
+	if _, err := LoadKeySet(path, "", "", ""); err == nil {
+		t.Error("tanpa refresh secret harus error")
+	}
+}
+
+func TestAccessTokenRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	for name, key := range map[string]interface{}{"rsa.pem": rsaKey, "ed.pem": edKey} {
+		ks, err := LoadKeySet(writePrivateKey(t, dir, name, key), "", "", "refresh-secret")
+		if err != nil {
+			t.Fatalf("%s: LoadKeySet error: %v", name, err)
+		}
+		useKeySet(t, ks)
+
+		token, err := GenerateAccessToken("uid-1", "budi", "guru", "sess-1", 0, AccessFlags{})
+		if err != nil {
+			t.Fatalf("%s: GenerateAccessToken error: %v", name, err)
+		}
+		claims, err := ValidateToken(token)
+		if err != nil {
+			t.Fatalf("%s: ValidateToken error: %v", name, err)
+		}
+		if claims.UID != "uid-1" || claims.Role != "guru" || claims.SessionID != "sess-1" {
+			t.Errorf("%s: klaim tidak sesuai: %+v", name, claims)
+		}
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	pubDir := filepath.Join(dir, "public")
+	if err := os.Mkdir(pubDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	_, oldKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, newKey, _ := ed25519.GenerateKey(rand.Reader)
+	oldPath := writePrivateKey(t, dir, "2025-12.pem", oldKey)
+	newPath := writePrivateKey(t, dir, "2026-01.pem", newKey)
+
+	// Token lama diterbitkan dengan kunci lama
+	oldKS, err := LoadKeySet(oldPath, "", "", "refresh-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useKeySet(t, oldKS)
+	oldToken, err := GenerateAccessToken("uid-1", "budi", "guru", "sess-1", 0, AccessFlags{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Kunci baru aktif, kunci lama masih dipercaya lewat JWT_PUBLIC_KEYS_DIR
+	writePublicKey(t, pubDir, "2025-12.pem", oldKey.Public())
+	newKS, err := LoadKeySet(newPath, "", pubDir, "refresh-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useKeySet(t, newKS)
+
+	if _, err := ValidateToken(oldToken); err != nil {
+		t.Errorf("token kunci lama harus tetap valid selama rotasi: %v", err)
+	}
+	if got := len(newKS.JWKS().Keys); got != 2 {
+		t.Errorf("JWKS berisi %d kunci, want 2", got)
+	}
+
+	// Setelah kunci lama dicabut dari folder public, token lama ditolak
+	onlyNew, err := LoadKeySet(newPath, "", "", "refresh-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useKeySet(t, onlyNew)
+	if _, err := ValidateToken(oldToken); err == nil {
+		t.Error("token dengan kid yang sudah dicabut harus ditolak")
+	}
+}
+
+func TestRefreshTokenNotAcceptedAsAccessToken(t *testing.T) {
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	ks, err := LoadKeySet(writePrivateKey(t, t.TempDir(), "k1.pem", priv), "", "", "refresh-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useKeySet(t, ks)
+
+	refresh, _, err := GenerateRefreshToken("uid-1", "fam-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateToken(refresh); err == nil {
+		t.Error("refresh token tidak boleh lolos sebagai access token")
+	}
+
+	challenge, _, err := GenerateTwoFactorChallenge("uid-1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateToken(challenge); err == nil {
+		t.Error("challenge 2FA tidak boleh lolos sebagai access token")
+	}
+}
+
+func TestAccessTokenRequiresTypeIssuerAudience(t *testing.T) {
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	ks, err := LoadKeySet(writePrivateKey(t, t.TempDir(), "k1.pem", priv), "", "", "refresh-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useKeySet(t, ks)
+
+	token, err := GenerateAccessToken("uid-1", "budi", "guru", "sess-1", 0, AccessFlags{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken error: %v", err)
+	}
+	if claims.TokenType != tokenTypeAccess || claims.Issuer != DefaultJWTIssuer || len(claims.Audience) != 1 || claims.Audience[0] != DefaultJWTAudience {
+		t.Errorf("klaim typ/iss/aud tidak sesuai: %+v", claims)
+	}
+
+	base := func() *JWTClaims {
+		return &JWTClaims{
+			UID:       "uid-1",
+			TokenType: tokenTypeAccess,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    ks.Issuer,
+				Audience:  jwt.ClaimStrings{ks.Audience},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+		}
+	}
+	cases := map[string]func(c *JWTClaims){
+		"tanpa typ":      func(c *JWTClaims) { c.TokenType = "" },
+		"typ challenge":  func(c *JWTClaims) { c.TokenType = tokenTypeTwoFactorChallenge },
+		"issuer lain":    func(c *JWTClaims) { c.Issuer = "layanan-lain" },
+		"tanpa audience": func(c *JWTClaims) { c.Audience = nil },
+		"audience lain":  func(c *JWTClaims) { c.Audience = jwt.ClaimStrings{twoFactorAudience} },
+	}
+	for name, mutate := range cases {
+		c := base()
+		mutate(c)
+		signed, err := ks.sign(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ValidateToken(signed); err == nil {
+			t.Errorf("%s: token harus ditolak", name)
+		}
+	}
+
+	challenge, _, err := GenerateTwoFactorChallenge("uid-1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateTwoFactorChallenge(challenge); err != nil {
+		t.Errorf("challenge 2FA harus valid: %v", err)
+	}
+	if _, err := ValidateTwoFactorChallenge(token); err == nil {
+		t.Error("access token tidak boleh lolos sebagai challenge 2FA")
+	}
+}
+
+func TestImpersonationTokenCarriesActor(t *testing.T) {
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	ks, err := LoadKeySet(writePrivateKey(t, t.TempDir(), "k1.pem", priv), "", "", "refresh-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useKeySet(t, ks)
+
+	token, _, err := GenerateImpersonationToken("uid-murid", "siti", "murid", "sess-admin", 3,
+		ActorClaim{UID: "uid-admin", Username: "admin", TokenVersion: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken error: %v", err)
+	}
+	if !claims.Impersonated() || claims.Actor.UID != "uid-admin" || claims.Actor.TokenVersion != 7 {
+		t.Fatalf("klaim act tidak sesuai: %+v", claims.Actor)
+	}
+	if claims.UID != "uid-murid" || claims.SessionID != "sess-admin" || claims.TokenVersion != 3 {
+		t.Errorf("klaim user target tidak sesuai: %+v", claims)
+	}
+
+	normal, _ := GenerateAccessToken("uid-1", "budi", "guru", "sess-1", 0, AccessFlags{})
+	if c, _ := ValidateToken(normal); c.Impersonated() {
+		t.Error("access token biasa tidak boleh punya klaim act")
+	}
+}
@@ -1,18 +1,184 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func HashPassword(pass string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(pass), bcrypt.DefaultCost)
-	if err != nil {
+// Algoritma hash password yang didukung
+const (
+	HashBcrypt   = "bcrypt"
+	HashArgon2id = "argon2id"
+)
+
+// Argon2Params: Parameter argon2id. Memory dalam KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// PasswordHashConfig: Algoritma dan parameter untuk hash password baru.
+// Hash lama dengan parameter lebih lemah diperbarui otomatis saat login (lihat NeedsRehash).
+type PasswordHashConfig struct {
+	Algorithm  string
+	BcryptCost int
+	Argon2     Argon2Params
+}
+
+// DefaultPasswordHashConfig: argon2id sesuai rekomendasi minimum OWASP (19 MiB, t=2, p=1)
+func DefaultPasswordHashConfig() PasswordHashConfig {
+	return PasswordHashConfig{
+		Algorithm:  HashArgon2id,
+		BcryptCost: 12,
+		Argon2: Argon2Params{
+			Memory:      19 * 1024,
+			Iterations:  2,
+			Parallelism: 1,
+			SaltLength:  16,
+			KeyLength:   32,
+		},
+	}
+}
+
+// Hash meng-hash password dengan konfigurasi c.
+// Format hash: bcrypt standar ($2a$...) atau PHC string ($argon2id$v=19$m=..,t=..,p=..$salt$hash).
+func (c PasswordHashConfig) Hash(pass string) (string, error) {
+	if c.Algorithm == HashBcrypt {
+		bytes, err := bcrypt.GenerateFromPassword([]byte(pass), c.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(bytes), nil
+	}
+
+	p := c.Argon2
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
 		return "", err
 	}
-	return string(bytes), nil
+	key := argon2.IDKey([]byte(pass), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
 }
 
+// CheckPasswordHash mencocokkan password dengan hash bcrypt maupun argon2id
 func CheckPasswordHash(pass, hash string) bool {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		p, salt, key, err := decodeArgon2Hash(hash)
+		if err != nil {
+			return false
+		}
+		other := argon2.IDKey([]byte(pass), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+		return subtle.ConstantTimeCompare(key, other) == 1
+	}
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(pass))
 	return err == nil
 }
+
+// NeedsRehash mengecek apakah hash tersimpan lebih lemah dari konfigurasi c:
+// bcrypt saat argon2id aktif, cost bcrypt lebih kecil, atau parameter argon2id lebih kecil.
+// Hash argon2id tidak diturunkan ke bcrypt walau konfigurasi c bcrypt.
+func (c PasswordHashConfig) NeedsRehash(hash string) bool {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		if c.Algorithm != HashArgon2id {
+			return false
+		}
+		p, _, key, err := decodeArgon2Hash(hash)
+		if err != nil {
+			return false
+		}
+		want := c.Argon2
+		return p.Memory < want.Memory || p.Iterations < want.Iterations ||
+			p.Parallelism < want.Parallelism || uint32(len(key)) < want.KeyLength
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return false
+	}
+	return c.Algorithm == HashArgon2id || cost < c.BcryptCost
+}
+
+var errInvalidArgon2Hash = errors.New("format hash argon2id tidak valid")
+
+func decodeArgon2Hash(hash string) (Argon2Params, []byte, []byte, error) {
+	var p Argon2Params
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != HashArgon2id {
+		return p, nil, nil, errInvalidArgon2Hash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, errInvalidArgon2Hash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, errInvalidArgon2Hash
+	}
+	if p.Memory == 0 || p.Iterations == 0 || p.Parallelism == 0 {
+		return p, nil, nil, errInvalidArgon2Hash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, errInvalidArgon2Hash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, errInvalidArgon2Hash
+	}
+	p.SaltLength, p.KeyLength = uint32(len(salt)), uint32(len(key))
+	return p, salt, key, nil
+}
+
+// CalibrateArgon2id mencari parameter argon2id agar satu hash memakan waktu sekitar target
+// di mesin ini: iterasi dinaikkan dari base sampai target tercapai, memory dikurangi separuh
+// jika satu iterasi saja sudah melewati target.
+func CalibrateArgon2id(base Argon2Params, target time.Duration) (Argon2Params, time.Duration) {
+	p := base
+	p.Iterations = 1
+	salt := make([]byte, p.SaltLength)
+
+	measure := func() time.Duration {
+		start := time.Now()
+		argon2.IDKey([]byte("kalibrasi-password"), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+		return time.Since(start)
+	}
+
+	elapsed := measure()
+	for elapsed > target && p.Memory > 8*1024 {
+		p.Memory /= 2
+		elapsed = measure()
+	}
+	for elapsed < target {
+		p.Iterations++
+		elapsed = measure()
+	}
+	return p, elapsed
+}
+
+// CalibrateBcrypt mencari cost bcrypt terkecil yang memakan waktu minimal target di mesin ini
+func CalibrateBcrypt(target time.Duration) (int, time.Duration) {
+	for cost := bcrypt.DefaultCost; ; cost++ {
+		start := time.Now()
+		bcrypt.GenerateFromPassword([]byte("kalibrasi-password"), cost)
+		elapsed := time.Since(start)
+		if elapsed >= target || cost == bcrypt.MaxCost {
+			return cost, elapsed
+		}
+	}
+}
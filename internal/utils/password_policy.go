@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Nama aturan kebijakan password; ikut dikirim ke client agar tahu aturan mana yang gagal
+const (
+	RuleMinLength      = "min_length"
+	RuleUppercase      = "require_uppercase"
+	RuleLowercase      = "require_lowercase"
+	RuleDigit          = "require_digit"
+	RuleSymbol         = "require_symbol"
+	RuleNoUsername     = "no_username"
+	RuleCommonPassword = "common_password"
+	RulePasswordReuse  = "password_history"
+)
+
+// PasswordPolicy: Aturan password yang berlaku di semua jalur yang menyetel password
+type PasswordPolicy struct {
+	MinLength        int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	DisallowUsername bool // Password tidak boleh mengandung username
+	RejectCommon     bool // Tolak password yang ada di daftar password umum/bocor
+	HistorySize      int  // Jumlah hash password terakhir yang tidak boleh dipakai ulang (0 = nonaktif)
+}
+
+// DefaultPasswordPolicy: Kebijakan bawaan jika tidak dikonfigurasi lewat env
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:        8,
+		RequireLowercase: true,
+		RequireDigit:     true,
+		DisallowUsername: true,
+		RejectCommon:     true,
+		HistorySize:      5,
+	}
+}
+
+// PasswordPolicyError: Password melanggar satu aturan kebijakan
+type PasswordPolicyError struct {
+	Rule    string
+	Message string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return fmt.Sprintf("%s (aturan: %s)", e.Message, e.Rule)
+}
+
+// Validate memeriksa password terhadap kebijakan p dan mengembalikan *PasswordPolicyError
+// untuk aturan pertama yang gagal. username boleh kosong jika belum diketahui (aturan
+// no_username dilewati). Riwayat password dicek terpisah karena butuh database.
+func (p PasswordPolicy) Validate(password, username string) error {
+	if len([]rune(password)) < p.MinLength {
+		return &PasswordPolicyError{RuleMinLength, fmt.Sprintf("Password minimal %d karakter", p.MinLength)}
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, c := range password {
+		switch {
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsDigit(c):
+			digit = true
+		case unicode.IsPunct(c) || unicode.IsSymbol(c) || unicode.IsSpace(c):
+			symbol = true
+		}
+	}
+	if p.RequireUppercase && !upper {
+		return &PasswordPolicyError{RuleUppercase, "Password harus mengandung huruf besar"}
+	}
+	if p.RequireLowercase && !lower {
+		return &PasswordPolicyError{RuleLowercase, "Password harus mengandung huruf kecil"}
+	}
+	if p.RequireDigit && !digit {
+		return &PasswordPolicyError{RuleDigit, "Password harus mengandung angka"}
+	}
+	if p.RequireSymbol && !symbol {
+		return &PasswordPolicyError{RuleSymbol, "Password harus mengandung simbol"}
+	}
+
+	lowered := strings.ToLower(password)
+	if p.DisallowUsername && len(username) >= 3 && strings.Contains(lowered, strings.ToLower(username)) {
+		return &PasswordPolicyError{RuleNoUsername, "Password tidak boleh mengandung username"}
+	}
+	if p.RejectCommon && commonPasswords[lowered] {
+		return &PasswordPolicyError{RuleCommonPassword, "Password terlalu umum dan mudah ditebak"}
+	}
+	return nil
+}
+
+// PasswordReusedError: Dikembalikan saat password sama dengan salah satu password terakhir
+func PasswordReusedError(historySize int) error {
+	return &PasswordPolicyError{RulePasswordReuse, fmt.Sprintf("Password tidak boleh sama dengan %d password terakhir", historySize)}
+}
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+// commonPasswords: Daftar password umum (huruf kecil) dari common_passwords.txt, dicek offline
+var commonPasswords = loadCommonPasswords(commonPasswordsFile)
+
+func loadCommonPasswords(data string) map[string]bool {
+	set := map[string]bool{}
+	sc := bufio.NewScanner(strings.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		set[strings.ToLower(line)] = true
+	}
+	return set
+}
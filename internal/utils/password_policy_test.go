@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPasswordPolicyRules(t *testing.T) {
+	strict := PasswordPolicy{
+		MinLength:        10,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+		DisallowUsername: true,
+		RejectCommon:     true,
+	}
+
+	cases := []struct {
+		password string
+		username string
+		rule     string
+	}{
+		{"Ab1!", "", RuleMinLength},
+		{"abcdefgh1!", "", RuleUppercase},
+		{"ABCDEFGH1!", "", RuleLowercase},
+		{"Abcdefghi!", "", RuleDigit},
+		{"Abcdefghi1", "", RuleSymbol},
+		{"Xbudi.Santoso9!", "budi.santoso", RuleNoUsername},
+		{"XBUDI.SANTOSO9!a", "Budi.Santoso", RuleNoUsername},
+		{"Tahun2024Baru!", "", ""},
+	}
+	for _, c := range cases {
+		err := strict.Validate(c.password, c.username)
+		if c.rule == "" {
+			if err != nil {
+				t.Errorf("%q: harusnya lolos, dapat %v", c.password, err)
+			}
+			continue
+		}
+		var perr *PasswordPolicyError
+		if !errors.As(err, &perr) || perr.Rule != c.rule {
+			t.Errorf("%q: harusnya gagal di aturan %s, dapat %v", c.password, c.rule, err)
+		}
+	}
+}
+
+func TestPasswordPolicyCommonList(t *testing.T) {
+	p := DefaultPasswordPolicy()
+
+	for _, pw := range []string{"password1", "Password1", "qwerty123", "admin123"} {
+		var perr *PasswordPolicyError
+		if err := p.Validate(pw, ""); !errors.As(err, &perr) || perr.Rule != RuleCommonPassword {
+			t.Errorf("%q harusnya ditolak sebagai password umum, dapat %v", pw, err)
+		}
+	}
+
+	p.RejectCommon = false
+	if err := p.Validate("password1", ""); err != nil {
+		t.Errorf("daftar password umum nonaktif, dapat %v", err)
+	}
+}
+
+func TestPasswordPolicyShortUsernameIgnored(t *testing.T) {
+	// Username sangat pendek (mis. "ab") terlalu mudah muncul tanpa sengaja
+	if err := DefaultPasswordPolicy().Validate("kabut1malam", "ab"); err != nil {
+		t.Errorf("username pendek tidak boleh memicu no_username, dapat %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fastHashConfig: Parameter kecil supaya test cepat
+func fastHashConfig(alg string) PasswordHashConfig {
+	cfg := DefaultPasswordHashConfig()
+	cfg.Algorithm = alg
+	cfg.BcryptCost = bcrypt.MinCost
+	cfg.Argon2.Memory = 1024
+	cfg.Argon2.Iterations = 1
+	return cfg
+}
+
+func TestPasswordHashRoundTrip(t *testing.T) {
+	for _, alg := range []string{HashBcrypt, HashArgon2id} {
+		hash, err := fastHashConfig(alg).Hash("rahasia-Sekolah1")
+		if err != nil {
+			t.Fatalf("%s: %v", alg, err)
+		}
+		if alg == HashArgon2id && !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+			t.Fatalf("format PHC salah: %s", hash)
+		}
+		if !CheckPasswordHash("rahasia-Sekolah1", hash) {
+			t.Errorf("%s: password benar ditolak", alg)
+		}
+		if CheckPasswordHash("rahasia-sekolah1", hash) {
+			t.Errorf("%s: password salah diterima", alg)
+		}
+	}
+
+	for _, bad := range []string{"", "$argon2id$v=19$m=0,t=1,p=1$AAAA$AAAA", "$argon2id$v=16$m=1024,t=1,p=1$AAAA$AAAA", "bukan-hash"} {
+		if CheckPasswordHash("x", bad) {
+			t.Errorf("hash rusak %q tidak boleh cocok", bad)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	bcryptHash, _ := fastHashConfig(HashBcrypt).Hash("pw")
+	argonHash, _ := fastHashConfig(HashArgon2id).Hash("pw")
+
+	argonCfg := fastHashConfig(HashArgon2id)
+	if !argonCfg.NeedsRehash(bcryptHash) {
+		t.Error("bcrypt harus di-rehash saat argon2id aktif")
+	}
+	if argonCfg.NeedsRehash(argonHash) {
+		t.Error("argon2id dengan parameter sama tidak perlu di-rehash")
+	}
+	argonCfg.Argon2.Memory *= 2
+	if !argonCfg.NeedsRehash(argonHash) {
+		t.Error("argon2id dengan memory lebih kecil harus di-rehash")
+	}
+
+	bcryptCfg := fastHashConfig(HashBcrypt)
+	if bcryptCfg.NeedsRehash(bcryptHash) || bcryptCfg.NeedsRehash(argonHash) {
+		t.Error("hash yang setara/lebih kuat tidak boleh di-rehash")
+	}
+	bcryptCfg.BcryptCost++
+	if !bcryptCfg.NeedsRehash(bcryptHash) {
+		t.Error("cost bcrypt lebih kecil harus di-rehash")
+	}
+}
+
+// BenchmarkHashPassword mengukur waktu hash dengan konfigurasi default
+func BenchmarkHashPassword(b *testing.B) {
+	cfg := DefaultPasswordHashConfig()
+	for i := 0; i < b.N; i++ {
+		cfg.Hash("benchmark-password")
+	}
+}
+
+// BenchmarkCalibratePasswordHash mencari parameter yang membuat satu hash ~250ms di mesin ini.
+// Jalankan di host deployment, lalu salin nilai PASSWORD_HASH_* yang dicetak ke .env:
+//
+//	go test ./internal/utils -run x -bench CalibratePasswordHash -benchtime 1x
+func BenchmarkCalibratePasswordHash(b *testing.B) {
+	const target = 250 * time.Millisecond
+	for i := 0; i < b.N; i++ {
+		base := DefaultPasswordHashConfig().Argon2
+		base.Memory = 64 * 1024
+		p, took := CalibrateArgon2id(base, target)
+		b.Logf("argon2id (%s): PASSWORD_HASH_ARGON2_MEMORY_KB=%d PASSWORD_HASH_ARGON2_ITERATIONS=%d PASSWORD_HASH_ARGON2_PARALLELISM=%d",
+			took.Round(time.Millisecond), p.Memory, p.Iterations, p.Parallelism)
+
+		cost, took := CalibrateBcrypt(target)
+		b.Logf("bcrypt (%s): PASSWORD_HASH_BCRYPT_COST=%d", took.Round(time.Millisecond), cost)
+	}
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// NewUUID menghasilkan UUID v4 acak (format 8-4-4-4-12)
+func NewUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // versi 4
+	b[8] = (b[8] & 0x3f) | 0x80 // varian RFC 4122
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// RandomHex menghasilkan string hex acak dari n byte
+func RandomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// tempPasswordChars: Karakter password sementara (tanpa karakter yang mirip seperti 0/O, 1/l/I)
+const tempPasswordChars = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnpqrstuvwxyz23456789"
+
+// RandomPassword menghasilkan password sementara sepanjang n karakter
+func RandomPassword(n int) (string, error) {
+	// Byte >= limit dibuang agar setiap karakter punya peluang yang sama
+	limit := 256 - 256%len(tempPasswordChars)
+	out := make([]byte, 0, n)
+	buf := make([]byte, n)
+	for len(out) < n {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, c := range buf {
+			if int(c) >= limit {
+				continue
+			}
+			out = append(out, tempPasswordChars[int(c)%len(tempPasswordChars)])
+			if len(out) == n {
+				break
+			}
+		}
+	}
+	return string(out), nil
+}
+
+// apiKeyPrefix: Penanda API key agar mudah dikenali (mis. oleh secret scanner)
+const apiKeyPrefix = "sis_"
+
+// GenerateAPIKey menghasilkan API key acak 256-bit beserta potongan awalnya untuk ditampilkan
+func GenerateAPIKey() (key, prefix string, err error) {
+	secret, err := RandomHex(32)
+	if err != nil {
+		return "", "", err
+	}
+	key = apiKeyPrefix + secret
+	return key, key[:len(apiKeyPrefix)+8], nil
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type contextKey string
+
+const clientIPKey contextKey = "clientIP"
+
+// WithClientIP menyimpan IP klien hasil ResolveClientIP di context (dipasang oleh middleware.ClientIP)
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey, ip)
+}
+
+// ClientIP mengembalikan IP klien yang sudah ditentukan middleware.ClientIP.
+// Tanpa middleware tersebut (mis. di test) dipakai RemoteAddr.
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey).(string); ok {
+		return ip
+	}
+	return ResolveClientIP(r, false)
+}
+
+// ResolveClientIP mengambil IP klien dari request.
+// Header X-Forwarded-For / X-Real-IP hanya dipercaya jika trustProxy (TRUST_PROXY=true, aplikasi di belakang reverse proxy).
+// Dari X-Forwarded-For dipakai entri paling kanan, yaitu yang ditambahkan reverse proxy kita;
+// entri di kirinya dikirim client dan bisa dipalsukan.
+func ResolveClientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		// Header yang muncul berulang digabung supaya entri terakhir benar-benar dari proxy
+		if xff := strings.Join(r.Header.Values("X-Forwarded-For"), ","); xff != "" {
+			entries := strings.Split(xff, ",")
+			if last := strings.TrimSpace(entries[len(entries)-1]); last != "" {
+				return last
+			}
+		}
+		if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+			return realIP
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// DeviceLabel membuat label perangkat singkat dari User-Agent (untuk daftar sesi)
+func DeviceLabel(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+
+	platform := "Unknown OS"
+	switch {
+	case strings.Contains(ua, "android"):
+		platform = "Android"
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"):
+		platform = "iOS"
+	case strings.Contains(ua, "windows"):
+		platform = "Windows"
+	case strings.Contains(ua, "mac os"):
+		platform = "macOS"
+	case strings.Contains(ua, "linux"):
+		platform = "Linux"
+	}
+
+	browser := "Unknown Browser"
+	switch {
+	case strings.Contains(ua, "edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "chrome/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "safari/"):
+		browser = "Safari"
+	case strings.Contains(ua, "postman"), strings.Contains(ua, "curl"):
+		browser = "API Client"
+	}
+
+	return browser + " on " + platform
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Vektor uji RFC 6238 lampiran B (SHA1, secret ASCII "12345678901234567890"), diambil 6 digit terakhir
+func TestTOTPCodeRFC6238(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		got, err := TOTPCode(secret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCode(%d) error: %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTPCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+
+	prev, _ := TOTPCode(secret, TOTPStep(now)-1)
+	if step, ok := ValidateTOTP(secret, prev, now); !ok || step != TOTPStep(now)-1 {
+		t.Errorf("kode langkah sebelumnya harus diterima (ok=%v step=%d)", ok, step)
+	}
+
+	old, _ := TOTPCode(secret, TOTPStep(now)-3)
+	if _, ok := ValidateTOTP(secret, old, now); ok {
+		t.Errorf("kode 3 langkah lalu tidak boleh diterima")
+	}
+
+	if _, ok := ValidateTOTP(secret, "12345", now); ok {
+		t.Errorf("kode dengan panjang salah tidak boleh diterima")
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("jumlah kode = %d, want 10", len(codes))
+	}
+
+	seen := map[string]bool{}
+	for _, c := range codes {
+		if len(c) != 11 || c[5] != '-' {
+			t.Errorf("format kode tidak valid: %q", c)
+		}
+		if seen[c] {
+			t.Errorf("kode duplikat: %q", c)
+		}
+		seen[c] = true
+
+		typed := strings.ToUpper(strings.ReplaceAll(c, "-", " "))
+		if NormalizeRecoveryCode(typed) != c {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", typed, NormalizeRecoveryCode(typed), c)
+		}
+	}
+}
@@ -2,9 +2,12 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"log"
 	"net/http"
 	"strings"
 
+	"go-sis-be/internal/configs"
 	"go-sis-be/internal/models"
 	"go-sis-be/internal/utils" // Sesuaikan nama module Anda
 )
@@ -13,36 +16,197 @@ type contextKey string
 
 const UserInfoKey contextKey = "userInfo"
 
-func AuthMiddleware(next http.Handler) http.Handler {
+// APIKeyHeader: Header API key service account, alternatif dari Bearer JWT
+const APIKeyHeader = "X-API-Key"
+
+// AuthMiddleware memverifikasi Bearer JWT (atau X-API-Key) dan menaruh klaimnya di context.
+// Status pencabutan token (blacklist, token version, sesi) dan API key dicek lewat store.
+func AuthMiddleware(store models.AuthStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" && r.Header.Get(APIKeyHeader) != "" {
+				authenticateAPIKey(store, next, w, r)
+				return
+			}
+			if authHeader == "" {
+				http.Error(w, "Authorization header missing", http.StatusUnauthorized)
+				return
+			}
+
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				http.Error(w, "Invalid token format", http.StatusUnauthorized)
+				return
+			}
+
+			tokenString := parts[1]
+
+			// 🚨 CEK BLACKLIST REDIS DISINI
+			if store.IsTokenBlacklisted(r.Context(), tokenString) {
+				http.Error(w, "Token sudah tidak berlaku (Logged Out)", http.StatusUnauthorized)
+				return
+			}
+
+			// Baru setelah itu validasi JWT seperti biasa
+			claims, err := utils.ValidateToken(tokenString)
+			if err != nil {
+				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+				return
+			}
+
+			// Token diterbitkan sebelum token_version user dinaikkan (ganti password, dihapus, dll)
+			if !tokenVersionCurrent(r.Context(), store, claims) {
+				http.Error(w, "Token sudah dicabut, silakan login ulang", http.StatusUnauthorized)
+				return
+			}
+
+			// Sesi asal token sudah dicabut (logout perangkat lain / sign out everywhere)
+			if claims.SessionID != "" && store.IsSessionRevoked(r.Context(), claims.SessionID) {
+				http.Error(w, "Sesi sudah dicabut", http.StatusUnauthorized)
+				return
+			}
+
+			if claims.Impersonated() {
+				// Admin asli juga harus masih berlaku (belum ganti password / dicabut)
+				if !actorVersionCurrent(r.Context(), store, claims.Actor) {
+					http.Error(w, "Token impersonation sudah dicabut", http.StatusUnauthorized)
+					return
+				}
+				if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodOptions {
+					log.Printf("[IMPERSONATION] admin %s (%s) sebagai %s (%s): %s %s | req=%s",
+						claims.Actor.UID, claims.Actor.Username, claims.UID, claims.Username,
+						r.Method, r.URL.Path, RequestIDFromContext(r.Context()))
+				}
+			}
+
+			ctx := context.WithValue(r.Context(), UserInfoKey, claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// authenticateAPIKey memverifikasi header X-API-Key dan menaruh principal service account di context.
+// Principal memakai bentuk klaim yang sama dengan JWT supaya handler tidak perlu membedakan.
+func authenticateAPIKey(store models.AuthStore, next http.Handler, w http.ResponseWriter, r *http.Request) {
+	principal, err := store.AuthenticateAPIKey(r.Context(), r.Header.Get(APIKeyHeader), utils.ClientIP(r))
+	if err != nil && errors.Is(r.Context().Err(), context.Canceled) {
+		// Client sudah memutus koneksi; 57014 di sini bukan timeout dan tidak perlu dijawab
+		return
+	}
+	if configs.IsQueryTimeout(r.Context(), err) {
+		http.Error(w, utils.ErrMsgTimeout, http.StatusGatewayTimeout)
+		return
+	}
+	if err != nil {
+		log.Printf("Error autentikasi API key: %v", err)
+		http.Error(w, "Gagal memverifikasi API key", http.StatusInternalServerError)
+		return
+	}
+	if principal == nil {
+		http.Error(w, "Invalid or expired API key", http.StatusUnauthorized)
+		return
+	}
+
+	claims := &utils.JWTClaims{
+		UID:      principal.ServiceAccountID,
+		Username: principal.Name,
+		Role:     models.SERVICE_ROLE_NAME,
+		Scopes:   principal.Scopes,
+	}
+	ctx := context.WithValue(r.Context(), UserInfoKey, claims)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// IsServiceAccount: true jika principal berasal dari API key, bukan login user
+func IsServiceAccount(claims *utils.JWTClaims) bool {
+	return claims.Role == models.SERVICE_ROLE_NAME
+}
+
+// tokenVersionCurrent membandingkan versi token dengan login_users.token_version (cache Redis).
+// Error infrastruktur tidak memblokir request, sama seperti cek blacklist.
+func tokenVersionCurrent(ctx context.Context, store models.AuthStore, claims *utils.JWTClaims) bool {
+	version, err := store.GetTokenVersion(ctx, claims.UID)
+	if errors.Is(err, models.ErrUserGone) {
+		return false
+	}
+	if err != nil {
+		log.Printf("Error cek token version %s: %v", claims.UID, err)
+		return true
+	}
+	return claims.TokenVersion == version
+}
+
+// actorVersionCurrent: Sama seperti tokenVersionCurrent, untuk admin di klaim act
+func actorVersionCurrent(ctx context.Context, store models.AuthStore, actor *utils.ActorClaim) bool {
+	version, err := store.GetTokenVersion(ctx, actor.UID)
+	if errors.Is(err, models.ErrUserGone) {
+		return false
+	}
+	if err != nil {
+		log.Printf("Error cek token version actor %s: %v", actor.UID, err)
+		return true
+	}
+	return actor.TokenVersion == version
+}
+
+// DenyImpersonation menolak token impersonation pada endpoint kredensial milik user
+// (password, 2FA, sesi, akun terhubung) supaya admin tidak bisa mengambil alih akun.
+func DenyImpersonation(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Authorization header missing", http.StatusUnauthorized)
+		claims, ok := ClaimsFromContext(r.Context())
+		if ok && claims.Impersonated() {
+			writeJSONError(w, http.StatusForbidden, "Tidak tersedia saat impersonation. Akhiri dengan POST /impersonation/end")
 			return
 		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ClaimsFromContext mengambil klaim JWT yang disimpan AuthMiddleware
+func ClaimsFromContext(ctx context.Context) (*utils.JWTClaims, bool) {
+	claims, ok := ctx.Value(UserInfoKey).(*utils.JWTClaims)
+	if !ok || claims == nil || claims.UID == "" {
+		return nil, false
+	}
+	return claims, true
+}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, "Invalid token format", http.StatusUnauthorized)
+// RequireUserPrincipal menolak principal service account pada endpoint yang hanya bermakna
+// untuk user manusia (logout, password, 2FA, sesi perangkat).
+func RequireUserPrincipal(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := ClaimsFromContext(r.Context())
+		if ok && IsServiceAccount(claims) {
+			writeJSONError(w, http.StatusForbidden, "Endpoint ini tidak tersedia untuk service account")
 			return
 		}
+		next.ServeHTTP(w, r)
+	})
+}
 
-		tokenString := parts[1]
-
-		// 🚨 CEK BLACKLIST REDIS DISINI
-		if models.IsTokenBlacklisted(tokenString) {
-			http.Error(w, "Token sudah tidak berlaku (Logged Out)", http.StatusUnauthorized)
+// RequirePasswordChanged menolak token yang masih wajib ganti password (password sementara / akun seed).
+// Endpoint ganti password dan logout sengaja tidak dipasangi middleware ini.
+func RequirePasswordChanged(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := ClaimsFromContext(r.Context())
+		if ok && claims.MustChangePassword {
+			writeJSONError(w, http.StatusForbidden, "Password harus diganti terlebih dahulu (PUT /me/password)")
 			return
 		}
+		next.ServeHTTP(w, r)
+	})
+}
 
-		// Baru setelah itu validasi JWT seperti biasa
-		claims, err := utils.ValidateToken(tokenString)
-		if err != nil {
-			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+// RequireTwoFactorEnrolled menolak token milik role wajib 2FA yang belum enroll TOTP.
+// Endpoint enroll 2FA, ganti password, dan logout sengaja tidak dipasangi middleware ini.
+func RequireTwoFactorEnrolled(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := ClaimsFromContext(r.Context())
+		if ok && claims.TwoFactorSetupRequired {
+			writeJSONError(w, http.StatusForbidden, "Role Anda wajib mengaktifkan 2FA terlebih dahulu (POST /me/2fa/setup)")
 			return
 		}
-
-		ctx := context.WithValue(r.Context(), UserInfoKey, claims)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, r)
 	})
 }
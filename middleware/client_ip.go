@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go-sis-be/internal/utils"
+)
+
+// ClientIP menentukan IP klien sekali per request dan menyimpannya di context untuk utils.ClientIP.
+// Header X-Forwarded-For / X-Real-IP hanya dipercaya jika trustProxy (TRUST_PROXY=true).
+func ClientIP(trustProxy bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := utils.ResolveClientIP(r, trustProxy)
+			next.ServeHTTP(w, r.WithContext(utils.WithClientIP(r.Context(), ip)))
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-sis-be/internal/utils"
+)
+
+func TestClientIPTrustProxy(t *testing.T) {
+	cases := map[bool]string{
+		false: "10.0.0.5",    // Header proxy diabaikan
+		true:  "203.0.113.7", // IP yang ditambahkan proxy (paling kanan) di X-Forwarded-For
+	}
+	for trust, want := range cases {
+		var got string
+		h := ClientIP(trust)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = utils.ClientIP(r)
+		}))
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "10.0.0.5:51234"
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		if got != want {
+			t.Errorf("trustProxy=%t: IP %q, harus %q", trust, got, want)
+		}
+	}
+}
+
+func TestClientIPIgnoresSpoofedForwardedFor(t *testing.T) {
+	var got []string
+	h := ClientIP(true)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, utils.ClientIP(r))
+	}))
+
+	// Penyerang mengganti X-Forwarded-For di tiap request; proxy menambahkan IP aslinya di kanan
+	for _, spoofed := range []string{"1.1.1.1", "2.2.2.2, 3.3.3.3", "10.0.0.1"} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "10.0.0.5:51234"
+		req.Header.Set("X-Forwarded-For", spoofed+", 198.51.100.23")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	for _, ip := range got {
+		if ip != "198.51.100.23" {
+			t.Errorf("IP dari header palsu %q dipakai; harus IP yang ditambahkan proxy", ip)
+		}
+	}
+}
@@ -1,18 +1,89 @@
 package middleware
 
-import "net/http"
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
-func CORSMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	"go-sis-be/internal/configs"
+)
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+// CORS hanya mengirim header CORS untuk origin yang ada di allowlist corsConfig; origin lain
+// tidak mendapat header apa pun sehingga browser memblokirnya. Preflight dijawab di sini.
+func CORS(corsConfig configs.CORSConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Add("Vary", "Origin")
+
+			origin := r.Header.Get("Origin")
+			allowed := origin != "" && originAllowed(origin, corsConfig.AllowedOrigins, corsConfig.AllowCredentials)
+			if allowed {
+				if corsConfig.AllowCredentials {
+					h.Set("Access-Control-Allow-Origin", origin)
+					h.Set("Access-Control-Allow-Credentials", "true")
+				} else if containsString(corsConfig.AllowedOrigins, "*") {
+					h.Set("Access-Control-Allow-Origin", "*")
+				} else {
+					h.Set("Access-Control-Allow-Origin", origin)
+				}
+				if len(corsConfig.ExposedHeaders) > 0 {
+					h.Set("Access-Control-Expose-Headers", strings.Join(corsConfig.ExposedHeaders, ", "))
+				}
+			}
+
+			if r.Method == http.MethodOptions {
+				if allowed {
+					h.Add("Vary", "Access-Control-Request-Method")
+					h.Add("Vary", "Access-Control-Request-Headers")
+					h.Set("Access-Control-Allow-Methods", strings.Join(corsConfig.AllowedMethods, ", "))
+					h.Set("Access-Control-Allow-Headers", strings.Join(corsConfig.AllowedHeaders, ", "))
+					if corsConfig.MaxAge > 0 {
+						h.Set("Access-Control-Max-Age", strconv.Itoa(int(corsConfig.MaxAge.Seconds())))
+					}
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// originAllowed mencocokkan origin dengan allowlist: persis, "*" (hanya tanpa credentials),
+// atau wildcard subdomain "https://*.sekolah.sch.id" (tidak termasuk sekolah.sch.id sendiri)
+func originAllowed(origin string, allowlist []string, allowCredentials bool) bool {
+	origin = strings.ToLower(origin)
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return false
+	}
+
+	for _, pattern := range allowlist {
+		switch {
+		case pattern == "*":
+			if !allowCredentials {
+				return true
+			}
+		case pattern == origin:
+			return true
+		case strings.Contains(pattern, "://*."):
+			scheme, rest, _ := strings.Cut(pattern, "://*.")
+			if u.Scheme == scheme && strings.HasSuffix(u.Host, "."+rest) && len(u.Host) > len(rest)+1 {
+				return true
+			}
 		}
+	}
+	return false
+}
 
-		next.ServeHTTP(w, r)
-	})
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
 }
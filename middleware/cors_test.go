@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-sis-be/internal/configs"
+)
+
+var noopHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+func TestCORSAllowlist(t *testing.T) {
+	h := CORS(configs.CORSConfig{
+		AllowedOrigins:   []string{"https://sis.sekolah.sch.id", "https://*.sekolah.sch.id", "http://localhost:3000"},
+		AllowCredentials: true,
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Content-Type", "X-CSRF-Token"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		MaxAge:           10 * time.Minute,
+	})(noopHandler)
+
+	cases := map[string]bool{
+		"https://sis.sekolah.sch.id":      true,
+		"https://guru.sekolah.sch.id":     true,
+		"https://a.b.sekolah.sch.id":      true,
+		"http://localhost:3000":           true,
+		"https://sekolah.sch.id":          false, // wildcard hanya untuk subdomain
+		"http://guru.sekolah.sch.id":      false, // skema berbeda
+		"https://evilsekolah.sch.id":      false,
+		"https://sekolah.sch.id.evil.com": false,
+		"http://localhost:3001":           false,
+	}
+	for origin, want := range cases {
+		req := httptest.NewRequest("GET", "/api/v1/users", nil)
+		req.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		got := rec.Header().Get("Access-Control-Allow-Origin")
+		if want && (got != origin || rec.Header().Get("Access-Control-Allow-Credentials") != "true") {
+			t.Errorf("%s harus diizinkan dengan credentials, header: %v", origin, rec.Header())
+		}
+		if !want && (got != "" || rec.Header().Get("Access-Control-Expose-Headers") != "") {
+			t.Errorf("%s tidak boleh mendapat header CORS, header: %v", origin, rec.Header())
+		}
+		if rec.Header().Get("Vary") != "Origin" {
+			t.Errorf("%s: Vary: Origin wajib ada", origin)
+		}
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	called := false
+	h := CORS(configs.CORSConfig{
+		AllowedOrigins:   []string{"http://localhost:3000"},
+		AllowCredentials: true,
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Content-Type"},
+		MaxAge:           10 * time.Minute,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+
+	req := httptest.NewRequest("OPTIONS", "/api/v1/refresh", nil)
+	req.Header.Set("Origin", "http://localhost:3000")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if called || rec.Code != http.StatusNoContent {
+		t.Fatalf("preflight harus dijawab middleware dengan 204, code=%d called=%v", rec.Code, called)
+	}
+	if rec.Header().Get("Access-Control-Max-Age") != "600" || rec.Header().Get("Access-Control-Allow-Methods") != "GET, POST" {
+		t.Errorf("header preflight salah: %v", rec.Header())
+	}
+}
+
+func TestCORSWildcardWithoutCredentials(t *testing.T) {
+	h := CORS(configs.CORSConfig{AllowedOrigins: []string{"*"}})(noopHandler)
+
+	req := httptest.NewRequest("GET", "/.well-known/jwks.json", nil)
+	req.Header.Set("Origin", "https://siapa.saja")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Header().Get("Access-Control-Allow-Origin") != "*" || rec.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("wildcard tanpa credentials harus mengirim *, header: %v", rec.Header())
+	}
+
+	h = CORS(configs.CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true})(noopHandler)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("wildcard dengan credentials harus ditolak, header: %v", rec.Header())
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// Double-submit CSRF: token acak dikirim sebagai cookie yang bisa dibaca JavaScript (dan header
+// respons), lalu client wajib mengirim ulang nilainya lewat header. Situs lain bisa membuat browser
+// mengirim cookie, tetapi tidak bisa membaca nilainya untuk diisi ke header.
+const (
+	CSRFCookieName = "csrf_token"
+	CSRFHeader     = "X-CSRF-Token"
+)
+
+// RequireCSRFToken menolak request yang header X-CSRF-Token-nya tidak sama dengan cookie csrf_token.
+// Dipasang pada endpoint yang bergantung pada cookie (/refresh) dan /logout.
+func RequireCSRFToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := r.Header.Get(CSRFHeader)
+		cookie, err := r.Cookie(CSRFCookieName)
+		if err != nil || cookie.Value == "" || header == "" {
+			writeJSONError(w, http.StatusForbidden, "CSRF token tidak ada, kirim header "+CSRFHeader+" sesuai cookie "+CSRFCookieName)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(header), []byte(cookie.Value)) != 1 {
+			writeJSONError(w, http.StatusForbidden, "CSRF token tidak valid")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireCSRFToken(t *testing.T) {
+	h := RequireCSRFToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	cases := []struct {
+		name   string
+		cookie string
+		header string
+		want   int
+	}{
+		{"cookie dan header sama", "abc123", "abc123", http.StatusOK},
+		{"tanpa header", "abc123", "", http.StatusForbidden},
+		{"tanpa cookie", "", "abc123", http.StatusForbidden},
+		{"nilai berbeda", "abc123", "abc124", http.StatusForbidden},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest("POST", "/api/v1/refresh", nil)
+		if c.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: c.cookie})
+		}
+		if c.header != "" {
+			req.Header.Set(CSRFHeader, c.header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != c.want {
+			t.Errorf("%s: status %d, harusnya %d", c.name, rec.Code, c.want)
+		}
+	}
+}
@@ -26,12 +26,13 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(recorder, r)
 		duration := time.Since(start)
 		log.Printf(
-			"[%s] %s | %d %s | %v",
+			"[%s] %s | %d %s | %v | req=%s",
 			r.Method,
 			r.URL.Path,
 			recorder.StatusCode,
 			http.StatusText(recorder.StatusCode),
 			duration,
+			RequestIDFromContext(r.Context()),
 		)
 	})
 }
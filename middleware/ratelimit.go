@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go-sis-be/internal/configs"
+	"go-sis-be/internal/models"
+	"go-sis-be/internal/utils"
+)
+
+// hitRateLimit: Penyimpanan sliding window (Redis), bisa diganti di test
+var hitRateLimit = models.HitRateLimit
+
+// rateLimitErrLoggedAt: Unix detik terakhir error Redis di-log, supaya log tidak banjir saat Redis mati
+var rateLimitErrLoggedAt atomic.Int64
+
+// RateLimit membatasi request pada grup name sesuai rateLimits: per uid jika request sudah terautentikasi
+// (dipasang setelah AuthMiddleware), selain itu per IP.
+// Header RateLimit-Limit/-Remaining/-Reset/-Policy dikirim di setiap respons, Retry-After saat 429.
+// Jika Redis tidak tersedia request tetap dilayani (fail-open) karena proteksi login sudah punya lockout sendiri.
+func RateLimit(rateLimits configs.RateLimitConfig, name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit, ok := rateLimits.Routes[name]
+			if !rateLimits.Enabled || !ok || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			subject := "ip:" + utils.ClientIP(r)
+			if claims, ok := ClaimsFromContext(r.Context()); ok {
+				subject = "uid:" + claims.UID
+			}
+
+			res, err := hitRateLimit(r.Context(), name, subject, limit.Limit, limit.Window)
+			if err != nil {
+				now := time.Now().Unix()
+				if last := rateLimitErrLoggedAt.Load(); now-last >= 60 && rateLimitErrLoggedAt.CompareAndSwap(last, now) {
+					log.Printf("Rate limit dilewati (Redis tidak tersedia): %v", err)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			resetSeconds := ceilSeconds(res.Reset)
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(limit.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(resetSeconds))
+			h.Set("RateLimit-Policy", strconv.Itoa(limit.Limit)+";w="+strconv.Itoa(ceilSeconds(limit.Window)))
+
+			if !res.Allowed {
+				h.Set("Retry-After", strconv.Itoa(resetSeconds))
+				h.Set(utils.ContentHeader, utils.Mime)
+				w.WriteHeader(http.StatusTooManyRequests)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"error":       "Terlalu banyak request, coba lagi dalam " + strconv.Itoa(resetSeconds) + " detik",
+					"retry_after": resetSeconds,
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ceilSeconds membulatkan durasi ke atas dalam detik (minimal 1)
+func ceilSeconds(d time.Duration) int {
+	s := int((d + time.Second - 1) / time.Second)
+	if s < 1 {
+		return 1
+	}
+	return s
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-sis-be/internal/configs"
+	"go-sis-be/internal/models"
+	"go-sis-be/internal/utils"
+)
+
+// memoryLimiter: Pengganti Redis untuk test, fixed window sederhana per key
+type memoryLimiter struct {
+	hits map[string]int
+	err  error
+}
+
+func (m *memoryLimiter) hit(_ context.Context, name, subject string, limit int, window time.Duration) (models.RateLimitResult, error) {
+	if m.err != nil {
+		return models.RateLimitResult{}, m.err
+	}
+	key := name + ":" + subject
+	if m.hits[key] >= limit {
+		return models.RateLimitResult{Allowed: false, Remaining: 0, Reset: 1500 * time.Millisecond}, nil
+	}
+	m.hits[key]++
+	return models.RateLimitResult{Allowed: true, Remaining: limit - m.hits[key], Reset: window}, nil
+}
+
+func useLimiter(t *testing.T, m *memoryLimiter) {
+	t.Helper()
+	prev := hitRateLimit
+	hitRateLimit = m.hit
+	t.Cleanup(func() { hitRateLimit = prev })
+}
+
+// testRateLimits: Grup "test" dengan batas 2 request per menit
+var testRateLimits = configs.RateLimitConfig{
+	Enabled: true,
+	Routes:  map[string]configs.RateLimit{"test": {Limit: 2, Window: time.Minute}},
+}
+
+func serveLimited(r *http.Request) *httptest.ResponseRecorder {
+	h := RateLimit(testRateLimits, "test")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestRateLimitBlocksAfterLimit(t *testing.T) {
+	useLimiter(t, &memoryLimiter{hits: map[string]int{}})
+
+	for i, wantRemaining := range []string{"1", "0"} {
+		rec := serveLimited(httptest.NewRequest("POST", "/login", nil))
+		if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Remaining") != wantRemaining {
+			t.Fatalf("request %d: code %d remaining %q", i+1, rec.Code, rec.Header().Get("RateLimit-Remaining"))
+		}
+		if rec.Header().Get("RateLimit-Limit") != "2" || rec.Header().Get("RateLimit-Policy") != "2;w=60" {
+			t.Fatalf("header rate limit salah: %v", rec.Header())
+		}
+	}
+
+	rec := serveLimited(httptest.NewRequest("POST", "/login", nil))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("request ketiga harus 429, dapat %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "2" {
+		t.Errorf("Retry-After harus dibulatkan ke atas jadi 2, dapat %q", rec.Header().Get("Retry-After"))
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body["retry_after"] != float64(2) {
+		t.Errorf("body 429 salah: %v %v", body, err)
+	}
+}
+
+func TestRateLimitKeysByPrincipal(t *testing.T) {
+	m := &memoryLimiter{hits: map[string]int{}}
+	useLimiter(t, m)
+
+	req := httptest.NewRequest("GET", "/users", nil)
+	req = req.WithContext(context.WithValue(req.Context(), UserInfoKey, &utils.JWTClaims{UID: "u-1"}))
+	serveLimited(req)
+
+	if m.hits["test:uid:u-1"] != 1 {
+		t.Fatalf("request terautentikasi harus dihitung per uid: %v", m.hits)
+	}
+}
+
+func TestRateLimitFailsOpen(t *testing.T) {
+	useLimiter(t, &memoryLimiter{err: errors.New("redis mati")})
+
+	rec := serveLimited(httptest.NewRequest("POST", "/login", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "" {
+		t.Fatalf("Redis mati harus tetap melayani request tanpa header limit, dapat %d %v", rec.Code, rec.Header())
+	}
+}
@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go-sis-be/internal/models"
+	"go-sis-be/internal/utils"
+)
+
+// Permission: Hak akses dengan format "<resource>:<aksi>"
+type Permission string
+
+const (
+	PermUsersCreate Permission = "users:create"
+	PermUsersList   Permission = "users:list"
+	PermUsersRead   Permission = "users:read"
+	PermUsersUpdate Permission = "users:update"
+	PermUsersDelete Permission = "users:delete"
+
+	PermUsersResetPassword Permission = "users:reset_password"
+	PermUsersUnlock        Permission = "users:unlock"
+	PermUsersImpersonate   Permission = "users:impersonate"
+	PermUsersChangeRole    Permission = "users:change_role"
+
+	PermSessionsRevokeAll Permission = "sessions:revoke_all"
+
+	PermAuditRead Permission = "audit:read"
+
+	PermServiceAccountsManage Permission = "service_accounts:manage"
+
+	PermRegisterStudent Permission = "register:student"
+	PermRegisterTeacher Permission = "register:teacher"
+	PermRegisterAdmin   Permission = "register:admin"
+	PermRegisterParent  Permission = "register:parent"
+)
+
+// rolePermissions: Tabel deklaratif role -> daftar permission.
+// Endpoint yang menyangkut data milik user lain (detail/edit profil) tetap
+// dicek lagi di level resource oleh handler masing-masing.
+var rolePermissions = map[string][]Permission{
+	models.ADMIN_ROLE_NAME: {
+		PermUsersCreate, PermUsersList, PermUsersRead, PermUsersUpdate, PermUsersDelete,
+		PermUsersResetPassword, PermUsersUnlock, PermUsersImpersonate, PermUsersChangeRole, PermSessionsRevokeAll, PermAuditRead,
+		PermServiceAccountsManage,
+		PermRegisterStudent, PermRegisterTeacher, PermRegisterAdmin, PermRegisterParent,
+	},
+	// Guru tidak mendapat PermUsersList: daftar user memuat semua akun (termasuk admin),
+	// sedangkan policy profil hanya mengizinkan guru membaca murid di kelas yang ia ajar
+	models.TEACHER_ROLE_NAME: {
+		PermUsersRead, PermUsersUpdate,
+	},
+	models.STUDENT_ROLE_NAME: {
+		PermUsersRead, PermUsersUpdate,
+	},
+	models.PARENT_ROLE_NAME: {
+		PermUsersRead,
+	},
+}
+
+// HasPermission mengecek apakah role memiliki permission tertentu
+func HasPermission(role string, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// grantableScopes: Permission yang boleh diberikan ke service account (integrasi mesin seperti
+// kios absensi dan sistem keuangan). Sengaja allow-list: permission yang bisa membuat/mengambil alih
+// akun admin (register:admin, users:create, users:reset_password, users:impersonate), menghapus/mengubah
+// data, mencabut sesi, membaca audit, atau mengelola service account hanya boleh dipakai manusia.
+var grantableScopes = map[Permission]bool{
+	PermUsersList:       true,
+	PermUsersRead:       true,
+	PermRegisterStudent: true,
+	PermRegisterTeacher: true,
+	PermRegisterParent:  true,
+}
+
+// IsGrantableScope mengecek apakah scope boleh diberikan ke service account
+func IsGrantableScope(scope string) bool {
+	return grantableScopes[Permission(scope)]
+}
+
+// principalHasPermission: Service account memakai scope API key-nya, user memakai role.
+// Scope lama yang sudah tidak grantable tidak lagi dihormati.
+func principalHasPermission(claims *utils.JWTClaims, perm Permission) bool {
+	if IsServiceAccount(claims) {
+		if !IsGrantableScope(string(perm)) {
+			return false
+		}
+		for _, s := range claims.Scopes {
+			if Permission(s) == perm {
+				return true
+			}
+		}
+		return false
+	}
+	return HasPermission(claims.Role, perm)
+}
+
+// RequirePermission membungkus handler agar hanya bisa diakses role yang punya permission perm.
+// Wajib dipasang di belakang AuthMiddleware.
+func RequirePermission(perm Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+
+			if !principalHasPermission(claims, perm) {
+				writeJSONError(w, http.StatusForbidden, "Akses ditolak: butuh permission "+string(perm))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireRole membungkus handler agar hanya bisa diakses role yang disebutkan
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+
+			for _, role := range roles {
+				if claims.Role == role {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			writeJSONError(w, http.StatusForbidden, "Akses ditolak untuk role "+claims.Role)
+		})
+	}
+}
+
+// Fungsi helper untuk mengirim respons error JSON dari middleware
+func writeJSONError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set(utils.ContentHeader, utils.Mime)
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"testing"
+
+	"go-sis-be/internal/models"
+	"go-sis-be/internal/utils"
+)
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		role string
+		perm Permission
+		want bool
+	}{
+		{models.ADMIN_ROLE_NAME, PermUsersList, true},
+		{models.TEACHER_ROLE_NAME, PermUsersList, false},
+		{models.TEACHER_ROLE_NAME, PermUsersRead, true},
+		{models.STUDENT_ROLE_NAME, PermUsersList, false},
+		{models.PARENT_ROLE_NAME, PermUsersUpdate, false},
+		{"tidak-dikenal", PermUsersRead, false},
+	}
+	for _, tt := range tests {
+		if got := HasPermission(tt.role, tt.perm); got != tt.want {
+			t.Errorf("HasPermission(%s, %s) = %v, want %v", tt.role, tt.perm, got, tt.want)
+		}
+	}
+}
+
+func TestIsGrantableScope(t *testing.T) {
+	tests := []struct {
+		scope Permission
+		want  bool
+	}{
+		{PermUsersList, true},
+		{PermUsersRead, true},
+		{PermRegisterStudent, true},
+		// Bisa membuat atau mengambil alih akun admin
+		{PermRegisterAdmin, false},
+		{PermUsersCreate, false},
+		{PermUsersResetPassword, false},
+		{PermUsersImpersonate, false},
+		{PermSessionsRevokeAll, false},
+		{PermAuditRead, false},
+		{PermServiceAccountsManage, false},
+		{PermUsersUpdate, false},
+		{PermUsersDelete, false},
+		{"tidak:dikenal", false},
+	}
+	for _, tt := range tests {
+		if got := IsGrantableScope(string(tt.scope)); got != tt.want {
+			t.Errorf("IsGrantableScope(%s) = %v, want %v", tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestServiceAccountIgnoresNonGrantableScope(t *testing.T) {
+	// Key lama yang terlanjur menyimpan scope berbahaya tidak boleh lagi memakainya
+	claims := &utils.JWTClaims{Role: models.SERVICE_ROLE_NAME, Scopes: []string{string(PermRegisterAdmin), string(PermUsersRead)}}
+	if principalHasPermission(claims, PermRegisterAdmin) {
+		t.Error("scope register:admin di API key tidak boleh dihormati")
+	}
+	if !principalHasPermission(claims, PermUsersRead) {
+		t.Error("scope users:read di API key harus dihormati")
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"go-sis-be/internal/utils"
+)
+
+const RequestIDKey contextKey = "requestID"
+
+// RequestIDHeader: Header untuk korelasi request antar log, audit, dan layanan lain
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID: ID dari klien hanya dipakai jika formatnya wajar (mencegah log injection)
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestIDMiddleware memastikan setiap request punya request id (dari header klien atau dibuat baru)
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id, _ = utils.NewUUID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		ctx := context.WithValue(r.Context(), RequestIDKey, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestIDFromContext mengambil request id yang disimpan RequestIDMiddleware
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(RequestIDKey).(string)
+	return id
+}
@@ -1,52 +1,134 @@
 package routes
 
 import (
+	"net/http"
+
+	"go-sis-be/internal/configs"
 	"go-sis-be/internal/handlers"
 	"go-sis-be/middleware"
 
 	"github.com/gorilla/mux"
 )
 
-func InitRouter() *mux.Router {
+// guard: Membungkus handler dengan pengecekan permission (RBAC)
+func guard(perm middleware.Permission, h http.HandlerFunc) http.Handler {
+	return middleware.RequirePermission(perm)(h)
+}
+
+// selfOnly: Endpoint kredensial milik user sendiri, tertutup untuk service account dan token impersonation
+func selfOnly(h http.HandlerFunc) http.Handler {
+	return middleware.RequireUserPrincipal(middleware.DenyImpersonation(h))
+}
+
+// csrfProtected: Endpoint yang wajib membawa header X-CSRF-Token sesuai cookie csrf_token
+func csrfProtected(h http.HandlerFunc) http.HandlerFunc {
+	return middleware.RequireCSRFToken(h).ServeHTTP
+}
+
+// InitRouter memasang semua route; CORS, proxy, dan rate limit diambil dari cfg
+func InitRouter(h *handlers.Handler, cfg *configs.Config) *mux.Router {
 	r := mux.NewRouter()
 
+	// limited: Endpoint publik dengan rate limit per IP untuk grup name
+	limited := func(name string, h http.HandlerFunc) http.Handler {
+		return middleware.RateLimit(cfg.RateLimit, name)(h)
+	}
+
 	// Middleware Global
-	r.Use(middleware.CORSMiddleware)
+	r.Use(middleware.RequestIDMiddleware)
+	r.Use(middleware.ClientIP(cfg.TrustProxy))
+	r.Use(middleware.CORS(cfg.CORS))
 	r.Use(middleware.LoggingMiddleware)
 
+	// Public key JWT untuk layanan lain (tanpa prefix /api/v1, sesuai konvensi .well-known)
+	r.HandleFunc("/.well-known/jwks.json", h.HandleJWKS).Methods("GET")
+
+	// Status API & Redis untuk monitoring (tanpa token)
+	r.HandleFunc("/health", h.HandleHealth).Methods("GET")
+
 	// Subrouter Utama /api/v1
 	apiV1 := r.PathPrefix("/api/v1").Subrouter()
 
 	// ===================================
 	// A. Public Endpoints (TIDAK Butuh Token)
 	// ===================================
-	apiV1.HandleFunc("/login", handlers.LoginHandler).Methods("POST", "OPTIONS")
-	apiV1.HandleFunc("/refresh", handlers.RefreshTokenHandler).Methods("POST", "OPTIONS")
+	apiV1.Handle("/login", limited(configs.RateLimitLogin, h.LoginHandler)).Methods("POST", "OPTIONS")
+	apiV1.Handle("/login/2fa", limited(configs.RateLimitLoginTwoFactor, h.HandleTwoFactorLogin)).Methods("POST", "OPTIONS")
+	apiV1.Handle("/refresh", limited(configs.RateLimitRefresh, csrfProtected(h.RefreshTokenHandler))).Methods("POST", "OPTIONS")
+	apiV1.Handle("/password/forgot", limited(configs.RateLimitPasswordForgot, h.HandleForgotPassword)).Methods("POST", "OPTIONS")
+	apiV1.Handle("/password/reset", limited(configs.RateLimitPasswordReset, h.HandleResetForgottenPassword)).Methods("POST", "OPTIONS")
+
+	// Login lewat identity provider eksternal (OpenID Connect)
+	apiV1.HandleFunc("/auth/oidc/providers", h.HandleListOIDCProviders).Methods("GET")
+	apiV1.Handle("/auth/oidc/{provider}/login", limited(configs.RateLimitOIDC, h.HandleOIDCLogin)).Methods("GET")
+	apiV1.Handle("/auth/oidc/{provider}/callback", limited(configs.RateLimitOIDC, h.HandleOIDCCallback)).Methods("GET")
 
 	// ===================================
 	// B. Protected Endpoints (Butuh Token)
 	// ===================================
+	// Endpoint yang tetap boleh diakses walau user masih wajib ganti password / enroll 2FA
+	credentialRouter := apiV1.PathPrefix("").Subrouter()
+	credentialRouter.Use(middleware.AuthMiddleware(h.Auth))
+	credentialRouter.Use(middleware.RequireUserPrincipal)
+	credentialRouter.Use(middleware.DenyImpersonation)
+	credentialRouter.Use(middleware.RateLimit(cfg.RateLimit, configs.RateLimitAPI))
+
+	// 1. Auth Maintenance
+	credentialRouter.HandleFunc("/logout", csrfProtected(h.LogoutHandler)).Methods("POST", "OPTIONS") // <-- Hanya definisikan sekali
+	credentialRouter.HandleFunc("/me/password", h.HandleChangeMyPassword).Methods("PUT")
+	credentialRouter.HandleFunc("/me/2fa/setup", h.HandleTwoFactorSetup).Methods("POST")
+	credentialRouter.HandleFunc("/me/2fa/verify", h.HandleTwoFactorVerify).Methods("POST")
+
 	// Terapkan AuthMiddleware pada semua endpoint di subrouter ini
 	protectedRouter := apiV1.PathPrefix("").Subrouter()
-	protectedRouter.Use(middleware.AuthMiddleware)
+	protectedRouter.Use(middleware.AuthMiddleware(h.Auth))
+	protectedRouter.Use(middleware.RateLimit(cfg.RateLimit, configs.RateLimitAPI)) // Per uid, setelah principal diketahui
+	protectedRouter.Use(middleware.RequirePasswordChanged)
+	protectedRouter.Use(middleware.RequireTwoFactorEnrolled)
 
-	// 1. Auth Maintenance
-	protectedRouter.HandleFunc("/logout", handlers.LogoutHandler).Methods("POST", "OPTIONS") // <-- Hanya definisikan sekali
+	// Sesi login per perangkat
+	protectedRouter.Handle("/me/sessions", selfOnly(h.HandleListMySessions)).Methods("GET")
+	protectedRouter.Handle("/me/sessions/{id}", selfOnly(h.HandleRevokeMySession)).Methods("DELETE")
+
+	// Akun eksternal (OIDC) yang terhubung
+	protectedRouter.Handle("/me/oidc", selfOnly(h.HandleListMyIdentities)).Methods("GET")
+	protectedRouter.Handle("/me/oidc/{provider}/link", selfOnly(h.HandleOIDCLinkStart)).Methods("POST")
+	protectedRouter.Handle("/me/oidc/{provider}", selfOnly(h.HandleOIDCUnlink)).Methods("DELETE")
 
 	// 2. User Management (CRUD)
-	protectedRouter.HandleFunc("/users", handlers.CreateUserHandler).Methods("POST")
-	protectedRouter.HandleFunc("/users", handlers.GetAllUsersHandler).Methods("GET")
+	protectedRouter.Handle("/users", guard(middleware.PermUsersCreate, h.CreateUserHandler)).Methods("POST")
+	protectedRouter.Handle("/users", guard(middleware.PermUsersList, h.GetAllUsersHandler)).Methods("GET")
 
 	// Detail, Edit, Delete (UID)
-	protectedRouter.HandleFunc("/users/{uid}", handlers.HandleGetUserDetail).Methods("GET")
-	protectedRouter.HandleFunc("/users/{uid}", handlers.HandleEditProfile).Methods("PUT")
-	protectedRouter.HandleFunc("/users/{uid}", handlers.HandleDeleteProfile).Methods("DELETE")
+	protectedRouter.Handle("/users/{uid}", guard(middleware.PermUsersRead, h.HandleGetUserDetail)).Methods("GET")
+	protectedRouter.Handle("/users/{uid}", guard(middleware.PermUsersUpdate, h.HandleEditProfile)).Methods("PUT")
+	protectedRouter.Handle("/users/{uid}", guard(middleware.PermUsersDelete, h.HandleDeleteProfile)).Methods("DELETE")
+	protectedRouter.Handle("/users/{uid}/reset-password", guard(middleware.PermUsersResetPassword, h.HandleResetPassword)).Methods("POST")
+	protectedRouter.Handle("/users/{uid}/role", guard(middleware.PermUsersChangeRole, h.HandleChangeUserRole)).Methods("PUT")
+	protectedRouter.Handle("/users/{uid}/unlock", guard(middleware.PermUsersUnlock, h.HandleUnlockLogin)).Methods("POST")
+	protectedRouter.Handle("/users/{uid}/sessions", guard(middleware.PermSessionsRevokeAll, h.HandleRevokeUserSessions)).Methods("DELETE")
+
+	// Impersonation ("login sebagai") untuk support; token impersonation tidak punya permission ini
+	protectedRouter.Handle("/users/{uid}/impersonate", guard(middleware.PermUsersImpersonate, h.HandleImpersonate)).Methods("POST")
+	protectedRouter.HandleFunc("/impersonation/end", h.HandleEndImpersonation).Methods("POST")
 
 	// 3. Registrasi Spesifik (Role-specific creation)
-	protectedRouter.HandleFunc("/register/student", handlers.HandleStudentRegistration).Methods("POST")
-	protectedRouter.HandleFunc("/register/teacher", handlers.HandleTeacherRegistration).Methods("POST")
-	protectedRouter.HandleFunc("/register/admin", handlers.HandleAdminRegistration).Methods("POST")
-	protectedRouter.HandleFunc("/register/parent", handlers.HandleParentRegistration).Methods("POST")
+	protectedRouter.Handle("/register/student", guard(middleware.PermRegisterStudent, h.HandleStudentRegistration)).Methods("POST")
+	protectedRouter.Handle("/register/teacher", guard(middleware.PermRegisterTeacher, h.HandleTeacherRegistration)).Methods("POST")
+	protectedRouter.Handle("/register/admin", guard(middleware.PermRegisterAdmin, h.HandleAdminRegistration)).Methods("POST")
+	protectedRouter.Handle("/register/parent", guard(middleware.PermRegisterParent, h.HandleParentRegistration)).Methods("POST")
+
+	// 4. Audit Log (read-only)
+	protectedRouter.Handle("/audit", guard(middleware.PermAuditRead, h.HandleListAudit)).Methods("GET")
+
+	// 5. Service Account & API Key (integrasi mesin-ke-mesin)
+	protectedRouter.Handle("/service-accounts", guard(middleware.PermServiceAccountsManage, h.HandleCreateServiceAccount)).Methods("POST")
+	protectedRouter.Handle("/service-accounts", guard(middleware.PermServiceAccountsManage, h.HandleListServiceAccounts)).Methods("GET")
+	protectedRouter.Handle("/service-accounts/{id}", guard(middleware.PermServiceAccountsManage, h.HandleGetServiceAccount)).Methods("GET")
+	protectedRouter.Handle("/service-accounts/{id}", guard(middleware.PermServiceAccountsManage, h.HandleUpdateServiceAccount)).Methods("PUT")
+	protectedRouter.Handle("/service-accounts/{id}", guard(middleware.PermServiceAccountsManage, h.HandleDeleteServiceAccount)).Methods("DELETE")
+	protectedRouter.Handle("/service-accounts/{id}/keys", guard(middleware.PermServiceAccountsManage, h.HandleCreateAPIKey)).Methods("POST")
+	protectedRouter.Handle("/service-accounts/{id}/keys/{keyId}", guard(middleware.PermServiceAccountsManage, h.HandleRevokeAPIKey)).Methods("DELETE")
 
 	return r
 }